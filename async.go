@@ -0,0 +1,278 @@
+package iox
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// -----------------------------------------------------------------------------
+// Asynchronous writing.
+// -----------------------------------------------------------------------------
+
+// AsyncCfg is used to configure NewWriterWithAsyncCfg.
+type AsyncCfg struct {
+	// Queue is the max amount of values waiting to be written, <= 0 defaults
+	// to 64. Write blocks while the queue is full.
+	Queue int
+	// Workers is the amount of goroutines which write values from the queue
+	// into the wrapped Writer concurrently, <= 0 defaults to 1. With more
+	// than one, the wrapped Writer must be safe for concurrent use, and the
+	// order of writes is not kept.
+	Workers int
+	// OnErr is called (in a worker goroutine) with each err from the
+	// wrapped Writer, for fire-and-forget use: the errors are then neither
+	// returned by Write nor by Close. With more than one worker, it may be
+	// called concurrently. Nil means that the first err is kept and returned,
+	// see NewWriterWithAsyncCfg.
+	OnErr func(error)
+}
+
+// NewWriterWithAsync returns a WriteCloser where Write puts values on a queue
+// of size 'queue' and returns immediately, while a worker goroutine writes
+// them into 'w'. It is shorthand for NewWriterWithAsyncCfg with only
+// AsyncCfg.Queue set, see it for details.
+//
+// Example:
+//
+//	w := NewWriterWithAsync(slowSink, 1024)
+//	defer w.Close() // Flushes the queue, returns the first err of 'slowSink'.
+func NewWriterWithAsync[T any](w Writer[T], queue int) WriteCloser[T] {
+	return NewWriterWithAsyncCfg(w)(AsyncCfg{Queue: queue})
+}
+
+// NewWriterWithWorkers returns a WriteCloser where Write puts values on a queue
+// of size 'queue', while 'workers' goroutines write them into 'w' concurrently,
+// e.g to spread writes over the connections of a pool. 'w' must be safe for
+// concurrent use. It is shorthand for NewWriterWithAsyncCfg with only
+// AsyncCfg.Queue and AsyncCfg.Workers set, see it for details; Close waits for
+// in-flight writes, and errors can be surfaced with AsyncCfg.OnErr instead.
+//
+// Example:
+//
+//	w := NewWriterWithWorkers(httpSink, 8, 256)
+//	defer w.Close()
+func NewWriterWithWorkers[T any](w Writer[T], workers int, queue int) WriteCloser[T] {
+	return NewWriterWithAsyncCfg(w)(AsyncCfg{Queue: queue, Workers: workers})
+}
+
+// NewWriterWithAsyncCfg returns a WriteCloser where Write puts values on a
+// queue and returns immediately, while worker goroutines write them into 'w',
+// see AsyncCfg. The workers are started by the first Write, and write with the
+// values (e.g Clock or Budget) of the ctx given to that Write, but not its
+// cancellation. Write blocks while the queue is full, or while the Budget of
+// its ctx (see Budget) is exceeded, or until its ctx is done.
+//
+// Unless AsyncCfg.OnErr is set, the first err from 'w' is kept: it is returned
+// by the following Writes, and by Close. The workers keep writing the queued
+// values regardless. Close flushes the queue (i.e waits for the workers to
+// write every queued value) and returns the kept err, after which Write
+// returns io.ErrClosedPipe. 'w' itself is not closed. The returned WriteCloser
+// implements Flusher, which waits for the queue to be written without closing
+// anything. Nil 'w' returns an empty non-nil WriteCloser.
+//
+// Example:
+//
+//	w := NewWriterWithAsyncCfg(metricsSink)(AsyncCfg{
+//		Queue: 4096,
+//		OnErr: func(err error) { log.Println("metrics:", err) },
+//	})
+//
+//	defer w.Close()
+func NewWriterWithAsyncCfg[T any](w Writer[T]) func(cfg AsyncCfg) WriteCloser[T] {
+	return func(cfg AsyncCfg) WriteCloser[T] {
+		if w == nil {
+			return nilWriteCloser[T]()
+		}
+
+		if cfg.Queue <= 0 {
+			cfg.Queue = 64
+		}
+		if cfg.Workers <= 0 {
+			cfg.Workers = 1
+		}
+
+		return &asyncWriter[T]{
+			w:    w,
+			cfg:  cfg,
+			q:    make(chan asyncItem[T], cfg.Queue),
+			gate: newBudgetGate(),
+			done: make(chan struct{}),
+		}
+	}
+}
+
+// asyncItem is a queued value, and the amount reserved for it in the Budget.
+type asyncItem[T any] struct {
+	v T
+	n int64
+}
+
+type asyncWriter[T any] struct {
+	w    Writer[T]
+	cfg  AsyncCfg
+	q    chan asyncItem[T]
+	gate *budgetGate
+
+	start sync.Once
+	done  chan struct{}
+
+	// Held for reading while sending on 'q', such that Close does not close
+	// 'q' during a send.
+	mx     sync.RWMutex
+	closed bool
+
+	errMx sync.Mutex
+	err   error
+
+	// The amount of values which are queued or being written, and a chan
+	// which is closed when it drops to 0, see Flush.
+	pendMx  sync.Mutex
+	pending int
+	idle    chan struct{}
+}
+
+// work runs in the goroutines started by the first Write.
+func (a *asyncWriter[T]) work(ctx context.Context) {
+	for item := range a.q {
+		err := a.w.Write(ctx, item.v)
+		a.gate.release(item.n)
+		a.addPending(-1)
+		if err == nil {
+			continue
+		}
+
+		if a.cfg.OnErr != nil {
+			a.cfg.OnErr(err)
+			continue
+		}
+
+		a.errMx.Lock()
+		if a.err == nil {
+			a.err = err
+		}
+		a.errMx.Unlock()
+	}
+}
+
+func (a *asyncWriter[T]) addPending(n int) {
+	a.pendMx.Lock()
+	defer a.pendMx.Unlock()
+
+	if a.pending == 0 {
+		a.idle = make(chan struct{})
+	}
+
+	a.pending += n
+	if a.pending == 0 {
+		close(a.idle)
+	}
+}
+
+func (a *asyncWriter[T]) asyncErr() error {
+	a.errMx.Lock()
+	defer a.errMx.Unlock()
+	return a.err
+}
+
+func (a *asyncWriter[T]) Write(ctx context.Context, v T) error {
+	if err := ctxErr(ctx); err != nil {
+		return err
+	}
+
+	a.mx.RLock()
+	defer a.mx.RUnlock()
+	if a.closed {
+		return io.ErrClosedPipe
+	}
+
+	if err := a.asyncErr(); err != nil {
+		return err
+	}
+
+	a.start.Do(func() {
+		bg := context.Background()
+		if ctx != nil {
+			bg = context.WithoutCancel(ctx)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < a.cfg.Workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				a.work(bg)
+			}()
+		}
+
+		go func() {
+			wg.Wait()
+			close(a.done)
+		}()
+	})
+
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+
+	n, err := reserveGate(ctx, a.gate, v)
+	if err != nil {
+		return err
+	}
+
+	a.addPending(1)
+	select {
+	case a.q <- asyncItem[T]{v: v, n: n}:
+		return nil
+	case <-done:
+		a.gate.release(n)
+		a.addPending(-1)
+		return ctx.Err()
+	}
+}
+
+// Flush implements Flusher. It waits for the workers to write every value
+// queued before it, or until the ctx is done, and then flushes 'w' if it is
+// a Flusher (see AsFlusher).
+func (a *asyncWriter[T]) Flush(ctx context.Context) error {
+	ctx = orBackground(ctx)
+
+	a.pendMx.Lock()
+	var idle <-chan struct{}
+	if a.pending > 0 {
+		idle = a.idle
+	}
+	a.pendMx.Unlock()
+
+	if idle != nil {
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if err := a.asyncErr(); err != nil {
+		return err
+	}
+
+	return flushInner(ctx, a.w)
+}
+
+func (a *asyncWriter[T]) Close() error {
+	a.mx.Lock()
+	if a.closed {
+		a.mx.Unlock()
+		return a.asyncErr()
+	}
+
+	a.closed = true
+	close(a.q)
+	a.mx.Unlock()
+
+	// Marking the workers as done if they were never started.
+	a.start.Do(func() { close(a.done) })
+	<-a.done
+	return a.asyncErr()
+}
@@ -0,0 +1,148 @@
+package iox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+func TestNewWriterWithAsyncIdeal(t *testing.T) {
+	s := make([]int, 0, 3)
+	w := NewWriterWithAsync(newSliceWriter(&s), 2)
+
+	for i := 1; i <= 3; i++ {
+		assertEq("err", *new(error), w.Write(nil, i), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("err", *new(error), w.Close(), func(s string) { t.Fatal(s) })
+	assertEq("vals", []int{1, 2, 3}, s, func(s string) { t.Fatal(s) })
+
+	err := w.Write(nil, 4)
+	assertEq("err", true, err == io.ErrClosedPipe, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithAsyncWithErr(t *testing.T) {
+	errTest := errors.New("test")
+	n := 0
+	src := WriterImpl[int]{
+		Impl: func(ctx context.Context, v int) error {
+			if n++; n == 2 {
+				return errTest
+			}
+
+			return nil
+		},
+	}
+
+	w := NewWriterWithAsync[int](src, 8)
+	for i := 1; i <= 3; i++ {
+		w.Write(nil, i)
+	}
+
+	assertEq("err", true, w.Close() == errTest, func(s string) { t.Fatal(s) })
+	assertEq("writes", 3, n, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithAsyncCfgWithOnErr(t *testing.T) {
+	errTest := errors.New("test")
+	src := WriterImpl[int]{Impl: func(ctx context.Context, v int) error { return errTest }}
+
+	var mx sync.Mutex
+	errs := 0
+	w := NewWriterWithAsyncCfg[int](src)(AsyncCfg{
+		OnErr: func(err error) {
+			mx.Lock()
+			defer mx.Unlock()
+			errs++
+		},
+	})
+
+	for i := 1; i <= 3; i++ {
+		assertEq("err", *new(error), w.Write(nil, i), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("err", *new(error), w.Close(), func(s string) { t.Fatal(s) })
+	assertEq("errs", 3, errs, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithAsyncWithCancelledCtx(t *testing.T) {
+	// Blocks the worker until released, such that the queue fills up.
+	release := make(chan struct{})
+	src := WriterImpl[int]{Impl: func(ctx context.Context, v int) error { <-release; return nil }}
+
+	w := NewWriterWithAsync[int](src, 1)
+	w.Write(nil, 1) // Taken by the worker.
+	w.Write(nil, 2) // Queued.
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go cancel()
+
+	err := w.Write(ctx, 3)
+	assertEq("err", true, err == context.Canceled, func(s string) { t.Fatal(s) })
+
+	close(release)
+	assertEq("err", *new(error), w.Close(), func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithAsyncWithCloseBeforeWrite(t *testing.T) {
+	w := NewWriterWithAsync(newSliceWriter(new([]int)), 1)
+	assertEq("err", *new(error), w.Close(), func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), w.Close(), func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithAsyncWithNilWriter(t *testing.T) {
+	w := NewWriterWithAsync[int](nil, 1)
+	assertEq("err", true, w.Write(nil, 1) == io.ErrClosedPipe, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithWorkersIdeal(t *testing.T) {
+	var mx sync.Mutex
+	var inFlight, peak int
+	started := make(chan struct{}, 6)
+	release := make(chan struct{})
+
+	s := make([]int, 0, 6)
+	src := WriterImpl[int]{
+		Impl: func(ctx context.Context, v int) error {
+			mx.Lock()
+			inFlight++
+			peak = max(peak, inFlight)
+			mx.Unlock()
+
+			started <- struct{}{}
+			<-release
+
+			mx.Lock()
+			defer mx.Unlock()
+			inFlight--
+			s = append(s, v)
+			return nil
+		},
+	}
+
+	w := NewWriterWithWorkers[int](src, 3, 3)
+	for i := 1; i <= 6; i++ {
+		assertEq("err", *new(error), w.Write(nil, i), func(s string) { t.Fatal(s) })
+	}
+
+	// Wait until every worker is in flight.
+	for range 3 {
+		<-started
+	}
+
+	close(release)
+	assertEq("err", *new(error), w.Close(), func(s string) { t.Fatal(s) })
+	assertEq("len", 6, len(s), func(s string) { t.Fatal(s) })
+	assertEq("peak", 3, peak, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithWorkersWithErr(t *testing.T) {
+	errTest := errors.New("test")
+	src := WriterImpl[int]{Impl: func(ctx context.Context, v int) error { return errTest }}
+
+	w := NewWriterWithWorkers[int](src, 2, 4)
+	w.Write(nil, 1)
+	assertEq("err", true, w.Close() == errTest, func(s string) { t.Fatal(s) })
+}
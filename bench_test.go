@@ -0,0 +1,149 @@
+package iox_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/crunchypi/iox"
+	"github.com/crunchypi/iox/ioxtest"
+)
+
+// -----------------------------------------------------------------------------
+// Utils.
+// -----------------------------------------------------------------------------
+
+// newInfiniteReader returns a Reader which yields 'v' forever.
+func newInfiniteReader[T any](v T) iox.Reader[T] {
+	return iox.ReaderImpl[T]{
+		Impl: func(ctx context.Context) (T, error) { return v, nil },
+	}
+}
+
+// newDiscardWriter returns a Writer which accepts and drops everything.
+func newDiscardWriter[T any]() iox.Writer[T] {
+	return iox.WriterImpl[T]{
+		Impl: func(ctx context.Context, v T) error { return nil },
+	}
+}
+
+func newMapperChain(n int) iox.Reader[int] {
+	r := newInfiniteReader(1)
+	for i := 0; i < n; i++ {
+		r = iox.NewReaderWithMapperFn[int, int](r)(func(v int) int { return v + 1 })
+		r = iox.NewReaderWithFilterFn(r)(func(v int) bool { return v > 0 })
+	}
+
+	return r
+}
+
+// -----------------------------------------------------------------------------
+// Alloc guards.
+// -----------------------------------------------------------------------------
+
+func TestAllocsReaderMapperChain(t *testing.T) {
+	ctx := context.Background()
+	r := newMapperChain(8)
+
+	ioxtest.AssertMaxAllocs(t, 0, 0, func() { r.Read(ctx) })
+}
+
+func TestAllocsWriterMapperChain(t *testing.T) {
+	ctx := context.Background()
+	w := newDiscardWriter[int]()
+	for i := 0; i < 8; i++ {
+		w = iox.NewWriterWithMapperFn[int, int](w)(func(v int) int { return v + 1 })
+		w = iox.NewWriterWithFilterFn(w)(func(v int) bool { return v > 0 })
+	}
+
+	ioxtest.AssertMaxAllocs(t, 0, 0, func() { w.Write(ctx, 1) })
+}
+
+func TestAllocsReaderBatching(t *testing.T) {
+	ctx := context.Background()
+	r := iox.NewReaderWithBatching(newInfiniteReader(1), 64)
+
+	// The batch slice itself.
+	ioxtest.AssertMaxAllocs(t, 1, 0, func() { r.Read(ctx) })
+}
+
+func TestAllocsWriterUnbatching(t *testing.T) {
+	ctx := context.Background()
+	w := iox.NewWriterWithUnbatching(newDiscardWriter[int]())
+	s := make([]int, 64)
+
+	ioxtest.AssertMaxAllocs(t, 0, 0, func() { w.Write(ctx, s) })
+}
+
+// -----------------------------------------------------------------------------
+// Benchmarks.
+// -----------------------------------------------------------------------------
+
+func BenchmarkReaderMapperChain(b *testing.B) {
+	ctx := context.Background()
+	r := newMapperChain(8)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r.Read(ctx)
+	}
+}
+
+func BenchmarkReaderBatchingUnbatching(b *testing.B) {
+	ctx := context.Background()
+	r := iox.NewReaderWithUnbatching(iox.NewReaderWithBatching(newInfiniteReader(1), 64))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r.Read(ctx)
+	}
+}
+
+func BenchmarkWriterBatching(b *testing.B) {
+	ctx := context.Background()
+	w := iox.NewWriterWithBatching(newDiscardWriter[[]int](), 64)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		w.Write(ctx, i)
+	}
+}
+
+func BenchmarkReaderFromBytes(b *testing.B) {
+	ctx := context.Background()
+	buf := bytes.NewBuffer(nil)
+	enc := json.NewEncoder(buf)
+	for i := 0; i < b.N; i++ {
+		enc.Encode(i)
+	}
+
+	r := iox.NewReaderFromBytes[int](buf)(nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Read(ctx)
+	}
+}
+
+func BenchmarkWriterFromValues(b *testing.B) {
+	ctx := context.Background()
+	w := iox.NewWriterFromValues[int](io.Discard)(nil)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		w.Write(ctx, i)
+	}
+}
+
+func BenchmarkReaderFromValues(b *testing.B) {
+	r := iox.NewReaderFromValues(newInfiniteReader(1))(nil)
+	p := make([]byte, 64)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r.Read(p)
+	}
+}
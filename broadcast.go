@@ -0,0 +1,203 @@
+package iox
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// -----------------------------------------------------------------------------
+// Broadcasting.
+// -----------------------------------------------------------------------------
+
+// BroadcastCfg is used to configure NewReaderWithBroadcast.
+type BroadcastCfg struct {
+	// Buf is the max amount of values buffered per consumer, <= 0 defaults
+	// to 64. A non-empty buffer is also full while the Budget of the ctx
+	// given to Read is exceeded, see Budget.
+	Buf int
+	// Policy decides what happens when the buffer of a consumer is full.
+	// With QueueBlock, the fast consumers wait for the slow ones, such that
+	// every consumer sees every value.
+	Policy QueuePolicy
+}
+
+// NewReaderWithBroadcast returns 'n' ReadClosers which each see every value
+// from 'r', e.g for feeding the same stream into metrics, storage and
+// processing simultaneously. There are no goroutines involved: a consumer
+// which has nothing buffered reads from 'r' (one at a time), and buffers the
+// value for the other consumers, see BroadcastCfg for buffering and the slow
+// consumer policy. With QueueBlock, consumers should run in separate
+// goroutines, as a consumer with a full buffer blocks the others.
+//
+// An err from 'r' ends the broadcast: each consumer gets it after the values
+// buffered before it, on every Read. Closing a consumer drops its buffer and
+// stops values from being buffered for it, such that it does not block the
+// others; Read then returns io.EOF. 'r' itself is not closed. Nil 'r' returns
+// 'n' empty non-nil ReadClosers; 'n' <= 0 returns nil.
+//
+// Example:
+//
+//	rs := NewReaderWithBroadcast(events)(3, BroadcastCfg{Buf: 1024})
+//
+//	err := RunGroup(ctx,
+//		RunnableImpl{Impl: func(ctx context.Context) error { return metrics(ctx, rs[0]) }},
+//		RunnableImpl{Impl: func(ctx context.Context) error { return storage(ctx, rs[1]) }},
+//		RunnableImpl{Impl: func(ctx context.Context) error { return process(ctx, rs[2]) }},
+//	)
+func NewReaderWithBroadcast[T any](r Reader[T]) func(n int, cfg BroadcastCfg) []ReadCloser[T] {
+	return func(n int, cfg BroadcastCfg) []ReadCloser[T] {
+		if n <= 0 {
+			return nil
+		}
+
+		if r == nil {
+			rs := make([]ReadCloser[T], n)
+			for i := range rs {
+				rs[i] = nilReadCloser[T]()
+			}
+
+			return rs
+		}
+
+		if cfg.Buf <= 0 {
+			cfg.Buf = 64
+		}
+
+		b := &broadcast[T]{
+			r:      r,
+			cfg:    cfg,
+			qs:     make([]boundedQueue[T], n),
+			closed: make([]bool, n),
+			wake:   make([]chan struct{}, n),
+		}
+
+		for i := range b.wake {
+			b.qs[i] = boundedQueue[T]{max: cfg.Buf, policy: cfg.Policy}
+			b.wake[i] = make(chan struct{}, 1)
+		}
+
+		rs := make([]ReadCloser[T], n)
+		for i := range rs {
+			rs[i] = ReadCloserImpl[T]{
+				ImplC: func() error { return b.close(i) },
+				ImplR: func(ctx context.Context) (T, error) { return b.read(ctx, i) },
+			}
+		}
+
+		return rs
+	}
+}
+
+type broadcast[T any] struct {
+	r   Reader[T]
+	cfg BroadcastCfg
+
+	mx      sync.Mutex
+	qs      []boundedQueue[T]
+	closed  []bool
+	reading bool
+	srcErr  error
+	// Signals per consumer with a capacity of 1, see signalChan, which are
+	// sent on whenever the state changes, to wake up waiting consumers.
+	wake []chan struct{}
+}
+
+// notify wakes up every waiting consumer.
+func (b *broadcast[T]) notify() {
+	for _, ch := range b.wake {
+		signalChan(ch)
+	}
+}
+
+// full returns true if a consumer other than 'i' has a full buffer. It must be
+// called while holding b.mx.
+func (b *broadcast[T]) full(i int) bool {
+	for j := range b.qs {
+		if j != i && !b.closed[j] && b.qs[j].full() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (b *broadcast[T]) read(ctx context.Context, i int) (val T, err error) {
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+
+	b.mx.Lock()
+	for {
+		switch {
+		case b.closed[i]:
+			b.mx.Unlock()
+			return val, io.EOF
+		case b.qs[i].len() > 0:
+			val, _ = b.qs[i].pop()
+			b.notify()
+			b.mx.Unlock()
+			return val, nil
+		case b.srcErr != nil:
+			err = b.srcErr
+			b.mx.Unlock()
+			return val, err
+		case !b.reading && !(b.cfg.Policy == QueueBlock && b.full(i)):
+			b.reading = true
+			b.mx.Unlock()
+			return b.readSrc(ctx, i)
+		}
+
+		// Waiting for another consumer to read from 'r', or for room.
+		b.mx.Unlock()
+
+		select {
+		case <-b.wake[i]:
+		case <-done:
+			return val, ctx.Err()
+		}
+
+		b.mx.Lock()
+	}
+}
+
+// readSrc reads from 'r' on behalf of consumer 'i', and buffers the value for
+// the other consumers. It must be called with b.reading set.
+func (b *broadcast[T]) readSrc(ctx context.Context, i int) (val T, err error) {
+	val, err = b.r.Read(ctx)
+
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	defer b.notify()
+	b.reading = false
+
+	if err != nil {
+		// The ctx err of this consumer should not end the broadcast.
+		if ctx == nil || ctx.Err() == nil {
+			b.srcErr = err
+		}
+
+		return val, err
+	}
+
+	// With QueueBlock, 'r' is only read when there is room for everyone.
+	force := b.cfg.Policy == QueueBlock
+	for j := range b.qs {
+		if j != i && !b.closed[j] {
+			b.qs[j].push(ctx, val, force)
+		}
+	}
+
+	return val, nil
+}
+
+func (b *broadcast[T]) close(i int) error {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	b.closed[i] = true
+	b.qs[i].clear()
+	b.notify()
+	return nil
+}
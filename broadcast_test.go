@@ -0,0 +1,122 @@
+package iox
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func readAll[T any](r Reader[T]) ([]T, error) {
+	vs := make([]T, 0)
+	for {
+		v, err := r.Read(nil)
+		if err != nil {
+			return vs, err
+		}
+
+		vs = append(vs, v)
+	}
+}
+
+func TestNewReaderWithBroadcastIdeal(t *testing.T) {
+	rs := NewReaderWithBroadcast(NewReaderFrom(1, 2, 3))(2, BroadcastCfg{})
+
+	for i, r := range rs {
+		vs, err := readAll[int](r)
+		assertEq("err", true, err == io.EOF, func(s string) { t.Fatalf("%d: %s", i, s) })
+		assertEq("vals", []int{1, 2, 3}, vs, func(s string) { t.Fatalf("%d: %s", i, s) })
+	}
+}
+
+func TestNewReaderWithBroadcastWithBlock(t *testing.T) {
+	src := make([]int, 100)
+	for i := range src {
+		src[i] = i
+	}
+
+	rs := NewReaderWithBroadcast(NewReaderFrom(src...))(3, BroadcastCfg{Buf: 2})
+
+	var wg sync.WaitGroup
+	results := make([][]int, len(rs))
+	for i, r := range rs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = readAll[int](r)
+		}()
+	}
+
+	wg.Wait()
+	for i := range rs {
+		assertEq("vals", src, results[i], func(s string) { t.Fatalf("%d: %s", i, s) })
+	}
+}
+
+func TestNewReaderWithBroadcastWithBlockAndCtx(t *testing.T) {
+	rs := NewReaderWithBroadcast(NewReaderFrom(1, 2, 3))(2, BroadcastCfg{Buf: 1})
+
+	_, err := rs[0].Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+
+	// rs[1] has a full buffer.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+
+	_, err = rs[0].Read(ctx)
+	assertEq("err", true, err == context.DeadlineExceeded, func(s string) { t.Fatal(s) })
+
+	// Closing the slow consumer unblocks the other.
+	assertEq("err", *new(error), rs[1].Close(), func(s string) { t.Fatal(s) })
+
+	vs, err := readAll[int](rs[0])
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("vals", []int{2, 3}, vs, func(s string) { t.Fatal(s) })
+
+	_, err = rs[1].Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithBroadcastWithDropPolicies(t *testing.T) {
+	for _, c := range []struct {
+		policy QueuePolicy
+		want   []int
+	}{
+		{QueueDropOldest, []int{3, 4}},
+		{QueueDropNewest, []int{1, 2}},
+	} {
+		rs := NewReaderWithBroadcast(NewReaderFrom(1, 2, 3, 4))(2, BroadcastCfg{Buf: 2, Policy: c.policy})
+
+		vs, _ := readAll[int](rs[0])
+		assertEq("fast", []int{1, 2, 3, 4}, vs, func(s string) { t.Fatal(s) })
+
+		vs, _ = readAll[int](rs[1])
+		assertEq("slow", c.want, vs, func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestNewReaderWithBroadcastWithErr(t *testing.T) {
+	src := newResultReader([]int{1, 0, 2}, []error{nil, io.ErrUnexpectedEOF, nil})
+	rs := NewReaderWithBroadcast(src)(2, BroadcastCfg{})
+
+	for i, r := range rs {
+		vs, err := readAll[int](r)
+		assertEq("err", true, err == io.ErrUnexpectedEOF, func(s string) { t.Fatalf("%d: %s", i, s) })
+		assertEq("vals", []int{1}, vs, func(s string) { t.Fatalf("%d: %s", i, s) })
+	}
+}
+
+func TestNewReaderWithBroadcastWithNilReader(t *testing.T) {
+	rs := NewReaderWithBroadcast[int](nil)(2, BroadcastCfg{})
+	assertEq("len", 2, len(rs), func(s string) { t.Fatal(s) })
+
+	for i, r := range rs {
+		_, err := r.Read(nil)
+		assertEq("err", true, err == io.EOF, func(s string) { t.Fatalf("%d: %s", i, s) })
+		assertEq("err", *new(error), r.Close(), func(s string) { t.Fatalf("%d: %s", i, s) })
+	}
+
+	rs = NewReaderWithBroadcast(NewReaderFrom(1))(0, BroadcastCfg{})
+	assertEq("len", 0, len(rs), func(s string) { t.Fatal(s) })
+}
@@ -0,0 +1,220 @@
+package iox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrBudgetExceeded is returned when a buffering component can not retain a
+// value without exceeding the Budget carried by the ctx, see WithBudget.
+var ErrBudgetExceeded = errors.New("iox: budget exceeded")
+
+// Budget caps the approximate amount of bytes retained by the buffering parts
+// of a pipeline, e.g batching and coalescing writers, external sorting,
+// prefetching, the reorder buffer of parallel mapping, async writers, buffered
+// pipes and broadcasts. Such parts get the Budget from the ctx given to
+// Read/Write (see WithBudget; background goroutines use the ctx which started
+// them), and reserve the size of each value they buffer, releasing it once the
+// value is passed on. When a reservation fails, a part either makes room (e.g
+// flushes a batch early, spills to disk, or drops a value as its QueuePolicy
+// allows), waits for buffered values to be passed on, or returns
+// ErrBudgetExceeded. Parts which wait always take a value when they hold none,
+// such that they make progress.
+//
+// A Budget is safe for concurrent use, so one may be shared by all parts of a
+// pipeline. A nil *Budget is valid and never exceeded.
+type Budget struct {
+	limit int64
+	size  func(any) int64
+	used  atomic.Int64
+}
+
+// NewBudget returns a Budget of 'limit' bytes, where the size of each value
+// is approximated by 'size'. Nil 'size' counts each value as 1, i.e 'limit'
+// is then a limit on the number of retained values.
+//
+// Example:
+//
+//	b := NewBudget(64<<20, func(v any) int64 { return int64(len(v.(Event).Payload)) })
+//	ctx := WithBudget(context.Background(), b)
+//
+//	w := NewWriterWithBatching(bulkWriter, 10_000)
+//	w.Write(ctx, event) // Flushes early if the buffered events exceed 64MiB.
+func NewBudget(limit int64, size func(v any) int64) *Budget {
+	if size == nil {
+		size = func(any) int64 { return 1 }
+	}
+
+	return &Budget{limit: limit, size: size}
+}
+
+// Reserve reserves the size of 'v', and returns it such that it can later be
+// given to Release. ErrBudgetExceeded is returned (and nothing is reserved)
+// if the reservation would exceed the Budget.
+func (b *Budget) Reserve(v any) (int64, error) {
+	if b == nil {
+		return 0, nil
+	}
+
+	n := b.size(v)
+	if b.used.Add(n) > b.limit {
+		b.used.Add(-n)
+		return 0, ErrBudgetExceeded
+	}
+
+	return n, nil
+}
+
+// Release releases 'n' bytes previously reserved with Reserve.
+func (b *Budget) Release(n int64) {
+	if b == nil {
+		return
+	}
+
+	b.used.Add(-n)
+}
+
+// Used returns the amount of currently reserved bytes.
+func (b *Budget) Used() int64 {
+	if b == nil {
+		return 0
+	}
+
+	return b.used.Load()
+}
+
+type budgetCtxKey struct{}
+
+// WithBudget returns a copy of 'ctx' which carries the given Budget. Nil 'ctx'
+// is treated as context.Background(); nil 'b' means no Budget.
+func WithBudget(ctx context.Context, b *Budget) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return context.WithValue(ctx, budgetCtxKey{}, b)
+}
+
+// BudgetFrom returns the Budget carried by 'ctx' (see WithBudget), or nil if
+// there is none. Nil 'ctx' is allowed.
+func BudgetFrom(ctx context.Context) *Budget {
+	if ctx != nil {
+		if b, ok := ctx.Value(budgetCtxKey{}).(*Budget); ok {
+			return b
+		}
+	}
+
+	return nil
+}
+
+// budgetHold tracks the bytes reserved by one buffering component, such that
+// they can be released all at once when its buffer is passed on.
+type budgetHold struct {
+	b *Budget
+	n int64
+}
+
+// reserveHold reserves 'v' in the Budget from 'ctx'. The first non-nil Budget
+// seen is kept, so reservations are always released into the Budget they came
+// from. It is generic so that 'v' is only boxed when there is a Budget.
+func reserveHold[T any](ctx context.Context, h *budgetHold, v T) error {
+	if h.b == nil {
+		if h.b = BudgetFrom(ctx); h.b == nil {
+			return nil
+		}
+	}
+
+	n, err := h.b.Reserve(v)
+	h.n += n
+	return err
+}
+
+// release releases everything reserved with reserveHold.
+func (h *budgetHold) release() {
+	h.b.Release(h.n)
+	h.n = 0
+}
+
+// reserveOne reserves 'v' like reserveHold, and returns the amount reserved
+// for it, such that it can be released on its own with releaseOne.
+func reserveOne[T any](ctx context.Context, h *budgetHold, v T) (int64, error) {
+	n := h.n
+	err := reserveHold(ctx, h, v)
+	return h.n - n, err
+}
+
+// releaseOne releases 'n' bytes reserved with reserveOne.
+func (h *budgetHold) releaseOne(n int64) {
+	h.b.Release(n)
+	h.n -= n
+}
+
+// exceeded returns true if the Budget of 'h' is used up.
+func (h *budgetHold) exceeded() bool {
+	return h.b != nil && h.b.Used() >= h.b.limit
+}
+
+// budgetGate reserves values for a component which holds them in a background
+// goroutine until they are passed on, e.g the queue of an async Writer. It is
+// safe for concurrent use.
+type budgetGate struct {
+	mx   sync.Mutex
+	hold budgetHold
+	held int
+	// Signal with a capacity of 1, see signalChan, sent on by release.
+	released chan struct{}
+}
+
+func newBudgetGate() *budgetGate {
+	return &budgetGate{released: make(chan struct{}, 1)}
+}
+
+// reserveGate reserves 'v' with reserveOne. While the Budget is exceeded, it
+// waits for held values to be released (or until the ctx is done), but 'v' is
+// always let through when no values are held.
+func reserveGate[T any](ctx context.Context, g *budgetGate, v T) (int64, error) {
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+
+	for {
+		g.mx.Lock()
+		n, err := reserveOne(ctx, &g.hold, v)
+		if err == nil || g.held == 0 {
+			g.held++
+			g.mx.Unlock()
+			return n, nil
+		}
+
+		g.mx.Unlock()
+
+		select {
+		case <-g.released:
+		case <-done:
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// release releases a value reserved with reserveGate, as 'n' bytes.
+func (g *budgetGate) release(n int64) {
+	g.mx.Lock()
+	g.hold.releaseOne(n)
+	g.held--
+	g.mx.Unlock()
+
+	signalChan(g.released)
+}
+
+// releaseAll releases every value, e.g when the buffer is dropped on Close.
+func (g *budgetGate) releaseAll() {
+	g.mx.Lock()
+	g.hold.release()
+	g.held = 0
+	g.mx.Unlock()
+
+	signalChan(g.released)
+}
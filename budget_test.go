@@ -0,0 +1,177 @@
+package iox
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestBudgetReserveIdeal(t *testing.T) {
+	b := NewBudget(10, func(v any) int64 { return int64(len(v.(string))) })
+
+	n, err := b.Reserve("hello")
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("n", int64(5), n, func(s string) { t.Fatal(s) })
+
+	_, err = b.Reserve("world!")
+	assertEq("err", ErrBudgetExceeded, err, func(s string) { t.Fatal(s) })
+	assertEq("used", int64(5), b.Used(), func(s string) { t.Fatal(s) })
+
+	b.Release(n)
+	assertEq("used", int64(0), b.Used(), func(s string) { t.Fatal(s) })
+}
+
+func TestBudgetNil(t *testing.T) {
+	var b *Budget
+
+	_, err := b.Reserve(1)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("budget", true, BudgetFrom(context.Background()) == nil, func(s string) { t.Fatal(s) })
+	assertEq("budget", true, BudgetFrom(nil) == nil, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithBatchingWithBudget(t *testing.T) {
+	b := NewBudget(2, nil)
+	ctx := WithBudget(nil, b)
+
+	s := make([][]int, 0, 2)
+	w := NewWriterWithBatching(newSliceWriter(&s), 10)
+
+	for i := 1; i <= 5; i++ {
+		assertEq("err", *new(error), w.Write(ctx, i), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("s", [][]int{{1, 2}, {3, 4}}, s, func(s string) { t.Fatal(s) })
+	assertEq("used", int64(1), b.Used(), func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithBatchingWithTooLargeValue(t *testing.T) {
+	ctx := WithBudget(nil, NewBudget(0, nil))
+	w := NewWriterWithBatching(NewWriterFromDiscard[[]int](), 10)
+
+	assertEq("err", ErrBudgetExceeded, w.Write(ctx, 1), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithExternalSortWithBudget(t *testing.T) {
+	dir := t.TempDir()
+	b := NewBudget(2, nil)
+	ctx := WithBudget(nil, b)
+
+	less := func(a, b int) bool { return a < b }
+	r := NewReaderWithExternalSort(NewReaderFrom(5, 3, 1, 4, 2), less, 100, dir)(nil, nil)
+
+	for want := 1; want <= 5; want++ {
+		val, err := r.Read(ctx)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(ctx)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+
+	// The first run is spilled once over budget, the second stays in memory.
+	entries, _ := os.ReadDir(dir)
+	assertEq("spilled", 1, len(entries), func(s string) { t.Fatal(s) })
+
+	r.Close()
+	assertEq("used", int64(0), b.Used(), func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithCoalescingWithBudget(t *testing.T) {
+	b := NewBudget(2, nil)
+	ctx := WithBudget(nil, b)
+
+	s := make([][]string, 0, 1)
+	w := NewWriterWithCoalescing[string, string](newSliceWriter(&s))(
+		func(v string) string { return v },
+		nil,
+		10,
+	)
+
+	for _, v := range []string{"a", "b", "a", "c"} {
+		assertEq("err", *new(error), w.Write(ctx, v), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("s", [][]string{{"a", "b"}}, s, func(s string) { t.Fatal(s) })
+	assertEq("used", int64(1), b.Used(), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithPrefetchWithBudget(t *testing.T) {
+	b := NewBudget(2, nil)
+	ctx := WithBudget(nil, b)
+
+	r := NewReaderWithPrefetch(NewReaderFrom(1, 2, 3, 4, 5))(PrefetchCfg{Size: 16})
+	defer r.Close()
+
+	val, err := r.Read(ctx)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+
+	// The producer waits for the consumer rather than exceeding the Budget.
+	time.Sleep(time.Millisecond * 10)
+	assertEq("used", true, b.Used() <= 2, func(s string) { t.Fatal(s) })
+
+	vs, err := readAll(r)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("vals", []int{2, 3, 4, 5}, vs, func(s string) { t.Fatal(s) })
+	assertEq("used", int64(0), b.Used(), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithParallelMapperFnWithBudget(t *testing.T) {
+	b := NewBudget(1, nil)
+	ctx := WithBudget(nil, b)
+
+	r := NewReaderWithParallelMapperFn[int, int](NewReaderFrom(1, 2, 3, 4, 5), 4)(
+		func(ctx context.Context, v int) (int, error) { return v * 2, nil },
+	)
+	defer r.Close()
+
+	var vs []int
+	for {
+		val, err := r.Read(ctx)
+		if err != nil {
+			assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+			break
+		}
+
+		assertEq("used", true, b.Used() <= 1, func(s string) { t.Fatal(s) })
+		vs = append(vs, val)
+	}
+
+	assertEq("vals", []int{2, 4, 6, 8, 10}, vs, func(s string) { t.Fatal(s) })
+	assertEq("used", int64(0), b.Used(), func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithAsyncWithBudget(t *testing.T) {
+	b := NewBudget(2, nil)
+	ctx := WithBudget(nil, b)
+
+	var s []int
+	w := NewWriterWithAsync(newSliceWriter(&s), 16)
+	for i := 0; i < 5; i++ {
+		assertEq("err", *new(error), w.Write(ctx, i), func(s string) { t.Fatal(s) })
+		assertEq("used", true, b.Used() <= 2, func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("err", *new(error), w.Close(), func(s string) { t.Fatal(s) })
+	assertEq("vals", []int{0, 1, 2, 3, 4}, s, func(s string) { t.Fatal(s) })
+	assertEq("used", int64(0), b.Used(), func(s string) { t.Fatal(s) })
+}
+
+func TestBufferedPipeWithBudget(t *testing.T) {
+	b := NewBudget(2, nil)
+	ctx := WithBudget(nil, b)
+
+	r, w := BufferedPipe[int](PipeCfg{Cap: 10, Policy: QueueDropNewest})
+	for i := 1; i <= 5; i++ {
+		assertEq("err", *new(error), w.Write(ctx, i), func(s string) { t.Fatal(s) })
+	}
+
+	w.Close()
+	vs, err := readAll[int](r)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("vals", []int{1, 2}, vs, func(s string) { t.Fatal(s) })
+	assertEq("used", int64(0), b.Used(), func(s string) { t.Fatal(s) })
+}
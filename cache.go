@@ -0,0 +1,229 @@
+package iox
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// Cache.
+// -----------------------------------------------------------------------------
+
+// CacheCfg is used to configure NewCache.
+type CacheCfg struct {
+	// TTL is how long a loaded value is kept, <= 0 means forever. Expiry is
+	// checked with the Clock in the ctx given to Get, see ClockFrom.
+	TTL time.Duration
+	// MaxEntries limits the amount of cached values, the least recently used
+	// one is evicted when a new value would exceed it. <= 0 means no limit.
+	MaxEntries int
+}
+
+// Cache is a read-through cache of V keyed by K, see NewCache.
+type Cache[K comparable, V any] struct {
+	load func(context.Context, K) (V, error)
+	cfg  CacheCfg
+
+	mx sync.Mutex
+	// Recency list, front is most recently used.
+	lru     *list.List
+	entries map[K]*list.Element
+
+	// Generations are bumped by invalidation, such that a load which was in
+	// flight meanwhile does not store its (possibly stale) value. Per-key
+	// generations are only kept while a load of the key is in flight.
+	gen     uint64
+	gens    map[K]uint64
+	loading map[K]int
+}
+
+// cacheGen is the generation seen by a load when it started.
+type cacheGen struct {
+	key uint64
+	all uint64
+}
+
+type cacheEntry[K comparable, V any] struct {
+	key      K
+	val      V
+	loadedAt time.Time
+}
+
+// NewCache returns a read-through Cache which uses 'load' to get values which
+// are not (or no longer) cached. It is intended for enriching values in a
+// pipeline by key, e.g within the func given to NewReaderWithMapperFn.
+//
+// Errors from 'load' are returned by Get and are not cached, so the next Get
+// of the same key will try again. Nil 'load' makes every miss return an err
+// wrapping ErrInvalidArg. The Cache is safe for concurrent use; note that
+// concurrent misses of the same key may call 'load' more than once, and that
+// cached values are shared without being copied.
+//
+// Example:
+//
+//	users := NewCache(
+//		func(ctx context.Context, id int) (User, error) {
+//			// Expensive, e.g fetch user over the network.
+//		},
+//		CacheCfg{TTL: time.Minute, MaxEntries: 10_000},
+//	)
+//
+//	u, err := users.Get(ctx, 42) // Loads the user.
+//	u, err = users.Get(ctx, 42)  // Cached.
+func NewCache[K comparable, V any](load func(ctx context.Context, k K) (V, error), cfg CacheCfg) *Cache[K, V] {
+	return &Cache[K, V]{
+		load:    load,
+		cfg:     cfg,
+		lru:     list.New(),
+		entries: make(map[K]*list.Element),
+		gens:    make(map[K]uint64),
+		loading: make(map[K]int),
+	}
+}
+
+// Get returns the value for 'k', loading it if it is not cached or expired.
+func (c *Cache[K, V]) Get(ctx context.Context, k K) (val V, err error) {
+	now := ClockFrom(ctx).Now()
+	if val, ok := c.lookup(k, now); ok {
+		return val, nil
+	}
+
+	if c.load == nil {
+		return val, fmt.Errorf("%w: nil load func", ErrInvalidArg)
+	}
+
+	// Loading without holding the lock, so slow loads of one key do not
+	// block hits of other keys.
+	g := c.startLoad(k)
+	val, err = c.load(ctx, k)
+	c.finishLoad(k, g, val, err, now)
+	return val, err
+}
+
+// startLoad registers a load of 'k' as in flight, and returns the generation
+// it started at, see finishLoad.
+func (c *Cache[K, V]) startLoad(k K) cacheGen {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.loading[k]++
+	return cacheGen{key: c.gens[k], all: c.gen}
+}
+
+// finishLoad stores the result of a load started with startLoad, unless it
+// failed or 'k' was invalidated while it was in flight.
+func (c *Cache[K, V]) finishLoad(k K, g cacheGen, val V, err error, now time.Time) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	fresh := c.gens[k] == g.key && c.gen == g.all
+	if c.loading[k]--; c.loading[k] == 0 {
+		delete(c.loading, k)
+		delete(c.gens, k)
+	}
+
+	if err == nil && fresh {
+		c.storeLocked(k, val, now)
+	}
+}
+
+// lookup returns the cached value for 'k' if it has not expired at 'now'.
+func (c *Cache[K, V]) lookup(k K, now time.Time) (val V, ok bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	e, ok := c.entries[k]
+	if !ok {
+		return val, false
+	}
+
+	entry := e.Value.(*cacheEntry[K, V])
+	if c.cfg.TTL > 0 && now.Sub(entry.loadedAt) >= c.cfg.TTL {
+		c.remove(e)
+		return val, false
+	}
+
+	c.lru.MoveToFront(e)
+	return entry.val, true
+}
+
+// store caches 'val' for 'k' as loaded at 'now', evicting if needed.
+func (c *Cache[K, V]) store(k K, val V, now time.Time) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.storeLocked(k, val, now)
+}
+
+// storeLocked must be called while holding c.mx.
+func (c *Cache[K, V]) storeLocked(k K, val V, now time.Time) {
+	if e, ok := c.entries[k]; ok {
+		c.remove(e)
+	}
+	if c.cfg.MaxEntries > 0 && c.lru.Len() >= c.cfg.MaxEntries {
+		c.remove(c.lru.Back())
+	}
+
+	c.entries[k] = c.lru.PushFront(&cacheEntry[K, V]{key: k, val: val, loadedAt: now})
+}
+
+// Invalidate removes the value for 'k' from the cache, if any, such that the
+// next Get of 'k' loads it again. A load of 'k' which is in flight does not
+// store its value.
+func (c *Cache[K, V]) Invalidate(k K) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if e, ok := c.entries[k]; ok {
+		c.remove(e)
+	}
+	if c.loading[k] > 0 {
+		c.gens[k]++
+	}
+}
+
+// InvalidateAll removes all values from the cache. Loads which are in flight
+// do not store their values.
+func (c *Cache[K, V]) InvalidateAll() {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.gen++
+	c.lru.Init()
+	clear(c.entries)
+}
+
+// Len returns the amount of cached values, including expired ones which have
+// not been removed yet.
+func (c *Cache[K, V]) Len() int {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	return c.lru.Len()
+}
+
+// Invalidator returns a Writer which invalidates every key written into it.
+// This lets a stream of change events (e.g from a CDC feed) keep the Cache
+// fresh, without a TTL.
+//
+// Example:
+//
+//	w := users.Invalidator()
+//	w.Write(ctx, 42) // The next users.Get(ctx, 42) loads the user again.
+func (c *Cache[K, V]) Invalidator() Writer[K] {
+	return WriterImpl[K]{
+		Impl: func(ctx context.Context, k K) error {
+			c.Invalidate(k)
+			return nil
+		},
+	}
+}
+
+// remove must be called while holding c.mx.
+func (c *Cache[K, V]) remove(e *list.Element) {
+	delete(c.entries, e.Value.(*cacheEntry[K, V]).key)
+	c.lru.Remove(e)
+}
@@ -0,0 +1,155 @@
+package iox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// Cache.
+// -----------------------------------------------------------------------------
+
+func newCountingLoad(n *int) func(context.Context, int) (int, error) {
+	return func(ctx context.Context, k int) (int, error) {
+		*n++
+		return k * 10, nil
+	}
+}
+
+func TestCacheGetIdeal(t *testing.T) {
+	loads := 0
+	c := NewCache(newCountingLoad(&loads), CacheCfg{})
+
+	for i := 0; i < 2; i++ {
+		v, err := c.Get(nil, 1)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", 10, v, func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("loads", 1, loads, func(s string) { t.Fatal(s) })
+}
+
+func TestCacheGetWithTTL(t *testing.T) {
+	start := time.Unix(0, 0)
+	loads := 0
+	c := NewCache(newCountingLoad(&loads), CacheCfg{TTL: time.Second})
+
+	c.Get(WithClock(nil, testClock{now: start}), 1)
+	c.Get(WithClock(nil, testClock{now: start.Add(time.Millisecond * 500)}), 1)
+	assertEq("loads", 1, loads, func(s string) { t.Fatal(s) })
+
+	c.Get(WithClock(nil, testClock{now: start.Add(time.Second)}), 1)
+	assertEq("loads", 2, loads, func(s string) { t.Fatal(s) })
+}
+
+func TestCacheGetWithMaxEntries(t *testing.T) {
+	loads := 0
+	c := NewCache(newCountingLoad(&loads), CacheCfg{MaxEntries: 2})
+
+	c.Get(nil, 1)
+	c.Get(nil, 2)
+	c.Get(nil, 1) // 2 is now least recently used.
+	c.Get(nil, 3) // Evicts 2.
+	assertEq("len", 2, c.Len(), func(s string) { t.Fatal(s) })
+	assertEq("loads", 3, loads, func(s string) { t.Fatal(s) })
+
+	c.Get(nil, 1)
+	assertEq("loads", 3, loads, func(s string) { t.Fatal(s) })
+	c.Get(nil, 2)
+	assertEq("loads", 4, loads, func(s string) { t.Fatal(s) })
+}
+
+func TestCacheGetWithLoadErr(t *testing.T) {
+	errLoad := errors.New("load")
+	fail := true
+	c := NewCache(
+		func(ctx context.Context, k int) (int, error) {
+			if fail {
+				return 0, errLoad
+			}
+			return k, nil
+		},
+		CacheCfg{},
+	)
+
+	_, err := c.Get(nil, 1)
+	assertEq("err", errLoad, err, func(s string) { t.Fatal(s) })
+	assertEq("len", 0, c.Len(), func(s string) { t.Fatal(s) })
+
+	fail = false
+	v, err := c.Get(nil, 1)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, v, func(s string) { t.Fatal(s) })
+}
+
+func TestCacheGetWithNilLoad(t *testing.T) {
+	c := NewCache[int, int](nil, CacheCfg{})
+
+	_, err := c.Get(nil, 1)
+	assertEq("err", true, errors.Is(err, ErrInvalidArg), func(s string) { t.Fatal(s) })
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	loads := 0
+	c := NewCache(newCountingLoad(&loads), CacheCfg{})
+
+	c.Get(nil, 1)
+	c.Get(nil, 2)
+
+	c.Invalidate(1)
+	c.Get(nil, 1)
+	c.Get(nil, 2)
+	assertEq("loads", 3, loads, func(s string) { t.Fatal(s) })
+
+	c.InvalidateAll()
+	assertEq("len", 0, c.Len(), func(s string) { t.Fatal(s) })
+}
+
+func TestCacheInvalidateDuringLoad(t *testing.T) {
+	for _, invalidate := range []func(c *Cache[int, int]){
+		func(c *Cache[int, int]) { c.Invalidate(1) },
+		func(c *Cache[int, int]) { c.InvalidateAll() },
+	} {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		c := NewCache(
+			func(ctx context.Context, k int) (int, error) {
+				close(started)
+				<-release
+				return k * 10, nil
+			},
+			CacheCfg{},
+		)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			val, err := c.Get(nil, 1)
+			assertEq("err", *new(error), err, func(s string) { t.Error(s) })
+			assertEq("val", 10, val, func(s string) { t.Error(s) })
+		}()
+
+		// The load started before the invalidation, so its value may be
+		// stale and must not be cached.
+		<-started
+		invalidate(c)
+		close(release)
+		<-done
+
+		assertEq("len", 0, c.Len(), func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestCacheInvalidator(t *testing.T) {
+	loads := 0
+	c := NewCache(newCountingLoad(&loads), CacheCfg{})
+
+	c.Get(nil, 1)
+	err := c.Invalidator().Write(nil, 1)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+
+	c.Get(nil, 1)
+	assertEq("loads", 2, loads, func(s string) { t.Fatal(s) })
+}
@@ -0,0 +1,150 @@
+package iox
+
+import "context"
+
+// -----------------------------------------------------------------------------
+// Capabilities.
+// -----------------------------------------------------------------------------
+
+// Lener is optionally implemented by readers which know how many values they
+// have left, e.g the Reader returned by NewReaderFrom. It is only a hint, used
+// by this package to preallocate buffers, so implementations are not required
+// to be exact; returning a negative number means "unknown".
+type Lener interface {
+	Len() int
+}
+
+// Flusher is optionally implemented by writers which buffer values, e.g a
+// batching Writer. Flush writes any buffered values into the underlying sink,
+// without closing anything.
+type Flusher interface {
+	Flush(context.Context) error
+}
+
+// Positioned is optionally implemented by readers which know their position
+// in the underlying source, e.g a partition offset or a file position.
+// Position returns the position right after the last value read, i.e where
+// to resume from. See also PositionedReader.
+type Positioned[P any] interface {
+	Position() P
+}
+
+// Acker is optionally implemented by readers of sources which expect values
+// to be acknowledged once processed, e.g a message broker. Ack acknowledges
+// all values read so far.
+type Acker interface {
+	Ack(context.Context) error
+}
+
+// Wrapper is optionally implemented by readers and writers which wrap another
+// component, such that the capabilities of the wrapped component can be found
+// through them, see AsFlusher and friends. A Wrapper should only expose the
+// wrapped component if calling its capabilities directly is correct, e.g a
+// Reader which reads ahead must not expose the Position of its source.
+// ReaderImpl, WriterImpl and friends implement Wrapper through their Wraps
+// field, which the modifiers of this package (e.g NewReaderWithFilterFn) set
+// where that holds.
+type Wrapper interface {
+	Unwrap() any
+}
+
+// AsFlusher returns 'v' as a Flusher, if it (or a component it wraps, see
+// Wrapper) implements Flusher.
+//
+// Example:
+//
+//	if f, ok := AsFlusher(w); ok {
+//		err = f.Flush(ctx)
+//	}
+func AsFlusher(v any) (Flusher, bool) {
+	return asCapability[Flusher](v)
+}
+
+// AsPositioned returns 'v' as a Positioned, if it (or a component it wraps,
+// see Wrapper) implements Positioned.
+func AsPositioned[P any](v any) (Positioned[P], bool) {
+	return asCapability[Positioned[P]](v)
+}
+
+// AsAcker returns 'v' as an Acker, if it (or a component it wraps, see
+// Wrapper) implements Acker.
+func AsAcker(v any) (Acker, bool) {
+	return asCapability[Acker](v)
+}
+
+// AsLener returns 'v' as a Lener, if it (or a component it wraps, see
+// Wrapper) implements Lener.
+func AsLener(v any) (Lener, bool) {
+	return asCapability[Lener](v)
+}
+
+// asCapability returns the first of 'v' and the components it wraps (see
+// Wrapper) which implements C.
+func asCapability[C any](v any) (c C, ok bool) {
+	for v != nil {
+		if c, ok = v.(C); ok {
+			return c, true
+		}
+
+		w, ok := v.(Wrapper)
+		if !ok {
+			break
+		}
+
+		v = w.Unwrap()
+	}
+
+	return c, false
+}
+
+// flushWriter is a Writer which buffers values and implements Flusher, e.g the
+// Writer returned by NewWriterWithBatching.
+type flushWriter[T any] struct {
+	WriterImpl[T]
+	flush func(context.Context) error
+}
+
+// Flush implements Flusher by deferring to the internal "flush" func.
+func (w flushWriter[T]) Flush(ctx context.Context) error {
+	return w.flush(orBackground(ctx))
+}
+
+// flushWriteCloser is similar to flushWriter but implements io.Closer as well.
+type flushWriteCloser[T any] struct {
+	WriteCloserImpl[T]
+	flush func(context.Context) error
+}
+
+// Flush implements Flusher by deferring to the internal "flush" func.
+func (w flushWriteCloser[T]) Flush(ctx context.Context) error {
+	return w.flush(orBackground(ctx))
+}
+
+// flushInner flushes 'w' if it (or a component it wraps) implements Flusher,
+// such that Flush reaches every buffering Writer in a chain.
+func flushInner(ctx context.Context, w any) error {
+	if f, ok := AsFlusher(w); ok {
+		return f.Flush(ctx)
+	}
+
+	return nil
+}
+
+// lenReader is a Reader which implements Lener with a func, for modifiers
+// which may yield fewer values than they read, such that the Len of the
+// Reader they wrap (see Wrapper) is not taken as theirs.
+type lenReader[T any] struct {
+	ReaderImpl[T]
+	len func() int
+}
+
+// Len implements Lener by deferring to the internal "len" func.
+func (r lenReader[T]) Len() int {
+	return r.len()
+}
+
+// unknownLen is the "len" func of a lenReader which can not tell how many
+// values it has left.
+func unknownLen() int {
+	return -1
+}
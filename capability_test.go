@@ -0,0 +1,169 @@
+package iox
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// Capabilities.
+// -----------------------------------------------------------------------------
+
+// testWrapper wraps 'inner' without implementing any capabilities itself.
+type testWrapper struct{ inner any }
+
+func (w testWrapper) Unwrap() any { return w.inner }
+
+type testFlushAcker struct{ flushes, acks int }
+
+func (f *testFlushAcker) Flush(context.Context) error { f.flushes++; return nil }
+func (f *testFlushAcker) Ack(context.Context) error   { f.acks++; return nil }
+
+func TestAsFlusherIdeal(t *testing.T) {
+	inner := &testFlushAcker{}
+	f, ok := AsFlusher(testWrapper{testWrapper{inner}})
+	assertEq("ok", true, ok, func(s string) { t.Fatal(s) })
+
+	f.Flush(nil)
+	assertEq("flushes", 1, inner.flushes, func(s string) { t.Fatal(s) })
+}
+
+func TestAsAckerIdeal(t *testing.T) {
+	inner := &testFlushAcker{}
+	a, ok := AsAcker(testWrapper{inner})
+	assertEq("ok", true, ok, func(s string) { t.Fatal(s) })
+
+	a.Ack(nil)
+	assertEq("acks", 1, inner.acks, func(s string) { t.Fatal(s) })
+}
+
+func TestAsPositionedIdeal(t *testing.T) {
+	r := PositionedReaderImpl[int, int]{ImplP: func() int { return 3 }}
+
+	p, ok := AsPositioned[int](testWrapper{r})
+	assertEq("ok", true, ok, func(s string) { t.Fatal(s) })
+	assertEq("pos", 3, p.Position(), func(s string) { t.Fatal(s) })
+
+	_, ok = AsPositioned[string](r)
+	assertEq("ok", false, ok, func(s string) { t.Fatal(s) })
+}
+
+func TestAsLenerWithWrapper(t *testing.T) {
+	r := testWrapper{NewReaderFrom(1, 2, 3)}
+
+	l, ok := AsLener(r)
+	assertEq("ok", true, ok, func(s string) { t.Fatal(s) })
+	assertEq("len", 3, l.Len(), func(s string) { t.Fatal(s) })
+	assertEq("hint", 3, lenHint(r, 8, 0), func(s string) { t.Fatal(s) })
+}
+
+func TestAsFlusherWithoutCapability(t *testing.T) {
+	_, ok := AsFlusher(testWrapper{testWrapper{nil}})
+	assertEq("ok", false, ok, func(s string) { t.Fatal(s) })
+
+	_, ok = AsFlusher(nil)
+	assertEq("ok", false, ok, func(s string) { t.Fatal(s) })
+}
+
+func TestAsFlusherWithModifiers(t *testing.T) {
+	var s [][]int
+	w := NewWriterWithMapperFn[string, int](
+		NewWriterWithFilterFn(NewWriterWithBatching(newSliceWriter(&s), 10))(
+			func(v int) bool { return v != 2 },
+		),
+	)(func(v string) int { return len(v) })
+
+	for _, v := range []string{"a", "bb", "ccc"} {
+		w.Write(nil, v)
+	}
+
+	assertEq("len", 0, len(s), func(s string) { t.Fatal(s) })
+
+	f, ok := AsFlusher(w)
+	assertEq("ok", true, ok, func(s string) { t.Fatal(s) })
+	assertEq("err", true, f.Flush(nil) == nil, func(s string) { t.Fatal(s) })
+	assertEq("batches", [][]int{{1, 3}}, s, func(s string) { t.Fatal(s) })
+
+	// Nothing buffered, so there is nothing to write.
+	f.Flush(nil)
+	assertEq("batches", [][]int{{1, 3}}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestAsFlusherWithAsync(t *testing.T) {
+	var s [][]int
+	w := NewWriterWithAsync(NewWriterWithBatching(newSliceWriter(&s), 10), 4)
+	defer w.Close()
+
+	for i := 0; i < 3; i++ {
+		w.Write(nil, i)
+	}
+
+	f, ok := AsFlusher(NewWriterWithOnWriteFn(w)(func(int, error) {}))
+	assertEq("ok", true, ok, func(s string) { t.Fatal(s) })
+	assertEq("err", true, f.Flush(nil) == nil, func(s string) { t.Fatal(s) })
+	assertEq("batches", [][]int{{0, 1, 2}}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestAsPositionedWithModifiers(t *testing.T) {
+	vs := []int{1, 2, 3, 4}
+	pos := 0
+	src := PositionedReaderImpl[int, int]{
+		ImplR: func(ctx context.Context) (int, error) {
+			if pos == len(vs) {
+				return 0, io.EOF
+			}
+
+			pos++
+			return vs[pos-1], nil
+		},
+		ImplP: func() int { return pos },
+	}
+
+	acked := &testFlushAcker{}
+	r := NewReaderWithMapperFn[int, string](
+		NewReaderWithFilterFn(Reader[int](struct {
+			PositionedReaderImpl[int, int]
+			*testFlushAcker
+		}{src, acked}))(func(v int) bool { return v%2 == 0 }),
+	)(func(v int) string { return strconv.Itoa(v) })
+
+	v, _ := r.Read(nil)
+	assertEq("val", "2", v, func(s string) { t.Fatal(s) })
+
+	p, ok := AsPositioned[int](r)
+	assertEq("ok", true, ok, func(s string) { t.Fatal(s) })
+	assertEq("pos", 2, p.Position(), func(s string) { t.Fatal(s) })
+
+	a, ok := AsAcker(r)
+	assertEq("ok", true, ok, func(s string) { t.Fatal(s) })
+	a.Ack(nil)
+	assertEq("acks", 1, acked.acks, func(s string) { t.Fatal(s) })
+}
+
+func TestAsPositionedWithReadAhead(t *testing.T) {
+	r := PositionedReaderImpl[int, int]{ImplP: func() int { return 3 }}
+
+	_, ok := AsPositioned[int](NewReaderWithPrefetch[int](r)(PrefetchCfg{}))
+	assertEq("ok", false, ok, func(s string) { t.Fatal(s) })
+}
+
+func TestAsLenerWithModifiers(t *testing.T) {
+	r := NewReaderWithTake(NewReaderFrom(1, 2, 3, 4, 5), 2)
+	assertEq("hint", 2, lenHint(r, 8, 0), func(s string) { t.Fatal(s) })
+
+	r.Read(nil)
+	assertEq("hint", 1, lenHint(r, 8, 0), func(s string) { t.Fatal(s) })
+
+	r = NewReaderWithTake(NewReaderFrom(1), 2)
+	assertEq("hint", 1, lenHint(r, 8, 0), func(s string) { t.Fatal(s) })
+
+	// Filtering modifiers can not tell how many values they have left.
+	r = NewReaderWithFilterFn(NewReaderFrom(1, 2, 3))(func(v int) bool { return v > 2 })
+	assertEq("hint", 8, lenHint(r, 8, 0), func(s string) { t.Fatal(s) })
+
+	// While 1:1 modifiers report the Len of their source.
+	r = NewReaderWithMapperFn[int, int](NewReaderFrom(1, 2, 3))(func(v int) int { return v })
+	assertEq("hint", 3, lenHint(r, 8, 0), func(s string) { t.Fatal(s) })
+}
@@ -0,0 +1,153 @@
+package iox
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Clock abstracts time for the time-based parts of this package, e.g TTLs and
+// latency measurements. All such parts get their Clock from the ctx given to
+// Read/Write (see WithClock), and fall back to the system clock. This makes it
+// possible to test time-based behavior deterministically with a fake Clock,
+// such as the one in package ioxtest.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type clockCtxKey struct{}
+
+// WithClock returns a copy of 'ctx' which carries the given Clock. Nil 'ctx'
+// is treated as context.Background(); nil 'c' means the system clock.
+func WithClock(ctx context.Context, c Clock) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return context.WithValue(ctx, clockCtxKey{}, c)
+}
+
+// ClockFrom returns the Clock carried by 'ctx' (see WithClock), or the system
+// clock if there is none. Nil 'ctx' is allowed.
+func ClockFrom(ctx context.Context) Clock {
+	if ctx != nil {
+		if c, ok := ctx.Value(clockCtxKey{}).(Clock); ok && c != nil {
+			return c
+		}
+	}
+
+	return systemClock{}
+}
+
+// systemClock implements Clock with package time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// SleepCtx waits for 'd' according to the Clock in 'ctx' (see ClockFrom), or
+// until 'ctx' is done, in which case the ctx err is returned. It is intended
+// for Impl funcs which need to wait, e.g between retries, as a cancellable
+// alternative to time.Sleep. Nil 'ctx' is allowed; 'd' <= 0 returns at once.
+//
+// Example:
+//
+//	if err := SleepCtx(ctx, time.Second); err != nil {
+//		return val, err // E.g context.Canceled.
+//	}
+func SleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+
+	select {
+	case <-ClockFrom(ctx).After(d):
+		return nil
+	case <-done:
+		return ctx.Err()
+	}
+}
+
+// Backoff yields exponentially increasing delays, e.g for waiting between
+// retries. The zero Backoff starts at 100ms and doubles without a cap. A
+// Backoff is not safe for concurrent use.
+//
+// Example:
+//
+//	b := Backoff{Initial: time.Millisecond * 10, Max: time.Second}
+//	for {
+//		err := w.Write(ctx, v)
+//		if err == nil || !IsRetryable(err) {
+//			return err
+//		}
+//		if err := b.Wait(ctx); err != nil {
+//			return err
+//		}
+//	}
+type Backoff struct {
+	// Initial is the first delay, <= 0 defaults to 100ms.
+	Initial time.Duration
+	// Max caps the delay, <= 0 means no cap.
+	Max time.Duration
+	// Factor is what the delay is multiplied with after each step, <= 1
+	// defaults to 2.
+	Factor float64
+	// Jitter is the max fraction of each delay which is randomly subtracted
+	// from it, such that concurrent waiters spread out. It is clamped to
+	// [0, 1], where 0 means no jitter.
+	Jitter float64
+
+	cur time.Duration
+}
+
+// Next returns the next delay.
+func (b *Backoff) Next() time.Duration {
+	if b.cur <= 0 {
+		b.cur = b.Initial
+		if b.cur <= 0 {
+			b.cur = time.Millisecond * 100
+		}
+	}
+
+	d := b.cur
+	if b.Max > 0 {
+		d = min(d, b.Max)
+	}
+
+	factor := b.Factor
+	if factor <= 1 {
+		factor = 2
+	}
+
+	b.cur = time.Duration(float64(d) * factor)
+	if b.Max > 0 {
+		b.cur = min(b.cur, b.Max)
+	}
+
+	if jitter := min(max(b.Jitter, 0), 1); jitter > 0 {
+		d -= time.Duration(rand.Float64() * jitter * float64(d))
+	}
+
+	return d
+}
+
+// Reset makes the next delay the initial one, e.g after a success.
+func (b *Backoff) Reset() {
+	b.cur = 0
+}
+
+// Wait waits for the next delay with SleepCtx.
+func (b *Backoff) Wait(ctx context.Context) error {
+	return SleepCtx(ctx, b.Next())
+}
@@ -0,0 +1,118 @@
+package iox
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type testClock struct{ now time.Time }
+
+func (c testClock) Now() time.Time                         { return c.now }
+func (c testClock) After(d time.Duration) <-chan time.Time { return nil }
+
+// advancingClock is a Clock where After advances the time immediately, and
+// records the durations waited for.
+type advancingClock struct {
+	now   time.Time
+	waits []time.Duration
+}
+
+func (c *advancingClock) Now() time.Time { return c.now }
+func (c *advancingClock) After(d time.Duration) <-chan time.Time {
+	c.now = c.now.Add(d)
+	c.waits = append(c.waits, d)
+
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func TestClockFromIdeal(t *testing.T) {
+	now := time.Unix(100, 0)
+	ctx := WithClock(context.Background(), testClock{now: now})
+
+	assertEq("now", true, ClockFrom(ctx).Now().Equal(now), func(s string) { t.Fatal(s) })
+}
+
+func TestClockFromWithNilCtx(t *testing.T) {
+	assertEq("clock", true, ClockFrom(nil) == systemClock{}, func(s string) { t.Fatal(s) })
+}
+
+func TestClockFromWithNilClock(t *testing.T) {
+	ctx := WithClock(nil, nil)
+
+	assertEq("clock", true, ClockFrom(ctx) == systemClock{}, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTTLWithClock(t *testing.T) {
+	start := time.Unix(100, 0)
+	r := NewReaderWithTTL(
+		NewReaderFrom(
+			Timestamped[int]{Value: 1, Time: start},
+			Timestamped[int]{Value: 2, Time: start.Add(time.Second * 5)},
+		),
+		time.Second,
+	)
+
+	ctx := WithClock(context.Background(), testClock{now: start.Add(time.Second * 5)})
+
+	val, err := r.Read(ctx)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 2, val, func(s string) { t.Fatal(s) })
+}
+
+func TestSleepCtxIdeal(t *testing.T) {
+	clock := &advancingClock{now: time.Unix(100, 0)}
+	ctx := WithClock(context.Background(), clock)
+
+	assertEq("err", *new(error), SleepCtx(ctx, time.Second), func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), SleepCtx(ctx, 0), func(s string) { t.Fatal(s) })
+	assertEq("waits", []time.Duration{time.Second}, clock.waits, func(s string) { t.Fatal(s) })
+}
+
+func TestSleepCtxWithCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(WithClock(context.Background(), testClock{}))
+	cancel()
+
+	err := SleepCtx(ctx, time.Second)
+	assertEq("err", context.Canceled, err, func(s string) { t.Fatal(s) })
+}
+
+func TestBackoffIdeal(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: time.Second * 5, Factor: 3}
+
+	ds := []time.Duration{b.Next(), b.Next(), b.Next()}
+	want := []time.Duration{time.Second, time.Second * 3, time.Second * 5}
+	assertEq("delays", want, ds, func(s string) { t.Fatal(s) })
+
+	b.Reset()
+	assertEq("delay", time.Second, b.Next(), func(s string) { t.Fatal(s) })
+}
+
+func TestBackoffWithZeroValue(t *testing.T) {
+	b := Backoff{}
+
+	ds := []time.Duration{b.Next(), b.Next()}
+	want := []time.Duration{time.Millisecond * 100, time.Millisecond * 200}
+	assertEq("delays", want, ds, func(s string) { t.Fatal(s) })
+}
+
+func TestBackoffWithJitter(t *testing.T) {
+	b := Backoff{Initial: time.Second, Jitter: 0.5}
+
+	for _, hi := range []time.Duration{time.Second, time.Second * 2} {
+		d := b.Next()
+		assertEq("in range", true, d > hi/2 && d <= hi, func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestBackoffWait(t *testing.T) {
+	clock := &advancingClock{now: time.Unix(100, 0)}
+	ctx := WithClock(context.Background(), clock)
+	b := Backoff{Initial: time.Second}
+
+	assertEq("err", *new(error), b.Wait(ctx), func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), b.Wait(ctx), func(s string) { t.Fatal(s) })
+	assertEq("waits", []time.Duration{time.Second, time.Second * 2}, clock.waits, func(s string) { t.Fatal(s) })
+}
@@ -0,0 +1,106 @@
+package iox
+
+import (
+	"context"
+	"reflect"
+)
+
+// NewReaderWithCloneFn returns a reader of values from 'r' which are cloned
+// with 'f' before being returned. This is an opt-in defense against aliasing
+// bugs, for pipelines where stages mutate shared slices, maps or pointees.
+// Nil 'r' returns an empty non-nil Reader; nil 'f' makes a shallow copy (see
+// ShallowClone).
+//
+// Example:
+//
+//	buf := []int{1, 2}
+//	r := NewReaderWithCloneFn(NewReaderFrom(buf))(nil)
+//
+//	v, _ := r.Read(nil)
+//	v[0] = 9
+//	t.Log(buf) // [1, 2] <--- Unchanged.
+func NewReaderWithCloneFn[T any](r Reader[T]) func(f func(T) T) Reader[T] {
+	return func(f func(T) T) Reader[T] {
+		if r == nil {
+			return nilReader[T]()
+		}
+		if f == nil {
+			f = ShallowClone[T]
+		}
+
+		return ReaderImpl[T]{
+			Wraps: r,
+			Impl: func(ctx context.Context) (val T, err error) {
+				val, err = r.Read(ctx)
+				if err != nil {
+					return
+				}
+
+				return f(val), nil
+			},
+		}
+	}
+}
+
+// NewWriterWithCloneFn returns a writer which clones values with 'f' before
+// writing them into 'w', such that 'w' never holds a reference to anything
+// owned by the caller. Nil 'w' returns an empty Writer; nil 'f' makes a
+// shallow copy (see ShallowClone).
+func NewWriterWithCloneFn[T any](w Writer[T]) func(f func(T) T) Writer[T] {
+	return func(f func(T) T) Writer[T] {
+		if w == nil {
+			return nilWriter[T]()
+		}
+		if f == nil {
+			f = ShallowClone[T]
+		}
+
+		return WriterImpl[T]{
+			Wraps: w,
+			Impl: func(ctx context.Context, v T) error {
+				return w.Write(ctx, f(v))
+			},
+		}
+	}
+}
+
+// ShallowClone returns a one level deep copy of 'v': slices and maps get new
+// backing storage, and pointers point to a copy of the pointee. Elements of
+// slices and maps, as well as struct fields, are copied by assignment. Other
+// values are returned as-is, since they are copied by assignment anyway.
+func ShallowClone[T any](v T) T {
+	rv := reflect.ValueOf(&v).Elem()
+
+	switch rv.Kind() {
+	case reflect.Slice:
+		if rv.IsNil() {
+			return v
+		}
+
+		c := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		reflect.Copy(c, rv)
+		rv.Set(c)
+	case reflect.Map:
+		if rv.IsNil() {
+			return v
+		}
+
+		c := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), iter.Value())
+		}
+
+		rv.Set(c)
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return v
+		}
+
+		c := reflect.New(rv.Type().Elem())
+		c.Elem().Set(rv.Elem())
+		rv.Set(c)
+	}
+
+	return v
+}
@@ -0,0 +1,72 @@
+package iox
+
+import (
+	"io"
+	"testing"
+)
+
+func TestNewReaderWithCloneFnIdeal(t *testing.T) {
+	buf := []int{1, 2}
+	r := NewReaderWithCloneFn(NewReaderFrom(buf))(nil)
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", []int{1, 2}, val, func(s string) { t.Fatal(s) })
+
+	val[0] = 9
+	assertEq("buf", []int{1, 2}, buf, func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithCloneFnWithCustomFn(t *testing.T) {
+	r := NewReaderWithCloneFn(NewReaderFrom(1))(func(v int) int { return v * 10 })
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 10, val, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithCloneFnWithNilReader(t *testing.T) {
+	r := NewReaderWithCloneFn[[]int](nil)(nil)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithCloneFnIdeal(t *testing.T) {
+	s := make([]map[string]int, 0, 1)
+	w := NewWriterWithCloneFn(newSliceWriter(&s))(nil)
+
+	m := map[string]int{"a": 1}
+	assertEq("err", *new(error), w.Write(nil, m), func(s string) { t.Fatal(s) })
+
+	m["a"] = 2
+	assertEq("val", []map[string]int{{"a": 1}}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithCloneFnWithNilWriter(t *testing.T) {
+	w := NewWriterWithCloneFn[int](nil)(nil)
+
+	assertEq("err", true, w.Write(nil, 1) == io.ErrClosedPipe, func(s string) { t.Fatal(s) })
+}
+
+func TestShallowCloneWithPointer(t *testing.T) {
+	v := &struct{ X int }{X: 1}
+	c := ShallowClone(v)
+	c.X = 2
+
+	assertEq("orig", 1, v.X, func(s string) { t.Fatal(s) })
+	assertEq("clone", 2, c.X, func(s string) { t.Fatal(s) })
+}
+
+func TestShallowCloneWithNil(t *testing.T) {
+	var s []int
+	var m map[int]int
+	var p *int
+
+	assertEq("slice", true, ShallowClone(s) == nil, func(s string) { t.Fatal(s) })
+	assertEq("map", true, ShallowClone(m) == nil, func(s string) { t.Fatal(s) })
+	assertEq("ptr", true, ShallowClone(p) == nil, func(s string) { t.Fatal(s) })
+}
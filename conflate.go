@@ -0,0 +1,170 @@
+package iox
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// -----------------------------------------------------------------------------
+// Conflation.
+// -----------------------------------------------------------------------------
+
+// NewReaderWithConflation returns a ReadCloser which reads values from 'r' in
+// a goroutine, and keeps only the newest pending value per key (given by
+// 'key') until it is read, e.g for market data or status updates where a slow
+// consumer only cares about the latest state. Pending keys are read in the
+// order they first became pending, such that a frequently updated key does
+// not starve the others. As values are replaced rather than queued, the
+// goroutine never waits for the consumer, and the buffer is bounded by the
+// amount of distinct pending keys.
+//
+// As with NewReaderWithPrefetch, the goroutine is started by the first Read,
+// and reads from 'r' with the values of the ctx given to that Read, but not
+// its cancellation. An err from 'r' stops the reading, and is returned (on
+// every Read) after the pending values. Close stops the reading and waits for
+// the goroutine to return, after which Read returns io.EOF. Nil 'r' or nil
+// 'key' returns an empty non-nil ReadCloser.
+//
+// Example:
+//
+//	r := NewReaderWithConflation(quotes)(func(q Quote) string { return q.Symbol })
+//	defer r.Close()
+func NewReaderWithConflation[T any, K comparable](r Reader[T]) func(key func(T) K) ReadCloser[T] {
+	return func(key func(T) K) ReadCloser[T] {
+		if r == nil || key == nil {
+			return nilReadCloser[T]()
+		}
+
+		return &conflateReader[T, K]{
+			r:        r,
+			key:      key,
+			stop:     func() {},
+			done:     make(chan struct{}),
+			vals:     make(map[K]T),
+			notEmpty: make(chan struct{}, 1),
+		}
+	}
+}
+
+type conflateReader[T any, K comparable] struct {
+	r   Reader[T]
+	key func(T) K
+
+	start sync.Once
+	stop  context.CancelFunc
+	done  chan struct{}
+
+	mx     sync.Mutex
+	keys   []K
+	vals   map[K]T
+	srcErr error
+	closed bool
+
+	// Signal with a capacity of 1, such that a signal is never lost.
+	notEmpty chan struct{}
+}
+
+// produce runs in the goroutine started by the first Read.
+func (c *conflateReader[T, K]) produce(ctx context.Context) {
+	defer close(c.done)
+
+	for {
+		v, err := c.r.Read(ctx)
+
+		c.mx.Lock()
+		if c.closed {
+			c.mx.Unlock()
+			return
+		}
+
+		if err != nil {
+			c.srcErr = err
+			c.mx.Unlock()
+			signalChan(c.notEmpty)
+			return
+		}
+
+		k := c.key(v)
+		if _, ok := c.vals[k]; !ok {
+			c.keys = append(c.keys, k)
+		}
+
+		c.vals[k] = v
+		c.mx.Unlock()
+		signalChan(c.notEmpty)
+	}
+}
+
+func (c *conflateReader[T, K]) Read(ctx context.Context) (val T, err error) {
+	c.start.Do(func() {
+		bg := context.Background()
+		if ctx != nil {
+			bg = context.WithoutCancel(ctx)
+		}
+
+		c.mx.Lock()
+		defer c.mx.Unlock()
+		if c.closed {
+			close(c.done)
+			return
+		}
+
+		bg, c.stop = context.WithCancel(bg)
+		go c.produce(bg)
+	})
+
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+
+	for {
+		c.mx.Lock()
+		if c.closed {
+			c.mx.Unlock()
+			return val, io.EOF
+		}
+
+		if len(c.keys) > 0 {
+			k := c.keys[0]
+			c.keys = c.keys[1:]
+			val = c.vals[k]
+			delete(c.vals, k)
+			c.mx.Unlock()
+			return val, nil
+		}
+
+		if c.srcErr != nil {
+			err = c.srcErr
+			c.mx.Unlock()
+			return val, err
+		}
+
+		c.mx.Unlock()
+
+		select {
+		case <-c.notEmpty:
+		case <-done:
+			return val, ctx.Err()
+		}
+	}
+}
+
+func (c *conflateReader[T, K]) Close() error {
+	c.mx.Lock()
+	if c.closed {
+		c.mx.Unlock()
+		return nil
+	}
+
+	c.closed = true
+	c.keys, c.vals = nil, nil
+	c.stop()
+	c.mx.Unlock()
+
+	// Marking the goroutine as done if it was never started.
+	c.start.Do(func() { close(c.done) })
+	<-c.done
+	return nil
+}
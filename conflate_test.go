@@ -0,0 +1,104 @@
+package iox
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+type conflateTestQuote struct {
+	Symbol string
+	Price  int
+}
+
+func TestNewReaderWithConflationIdeal(t *testing.T) {
+	src := NewReaderFrom(
+		conflateTestQuote{"a", 1},
+		conflateTestQuote{"b", 1},
+		conflateTestQuote{"a", 2},
+		conflateTestQuote{"c", 1},
+		conflateTestQuote{"a", 3},
+	)
+
+	// Gating the source such that all values are pending before the first
+	// value is returned.
+	gate := make(chan struct{})
+	gated := ReaderImpl[conflateTestQuote]{
+		Impl: func(ctx context.Context) (conflateTestQuote, error) {
+			v, err := src.Read(ctx)
+			if err == io.EOF {
+				close(gate)
+			}
+
+			return v, err
+		},
+	}
+
+	r := NewReaderWithConflation[conflateTestQuote, string](gated)(func(q conflateTestQuote) string { return q.Symbol })
+	defer r.Close()
+
+	// Starting the goroutine with a Read which gives up at once.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.Read(ctx)
+	<-gate
+
+	want := []conflateTestQuote{{"a", 3}, {"b", 1}, {"c", 1}}
+	for _, w := range want {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", w, val, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithConflationWithErr(t *testing.T) {
+	src := newResultReader([]int{1, 0}, []error{nil, io.ErrUnexpectedEOF})
+	r := NewReaderWithConflation[int, int](src)(func(v int) int { return v })
+	defer r.Close()
+
+	for {
+		val, err := r.Read(nil)
+		if err != nil {
+			assertEq("err", true, err == io.ErrUnexpectedEOF, func(s string) { t.Fatal(s) })
+			break
+		}
+
+		assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestNewReaderWithConflationWithClose(t *testing.T) {
+	// Blocks until its ctx is done, i.e until Close.
+	src := ReaderImpl[int]{
+		Impl: func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		},
+	}
+
+	r := NewReaderWithConflation[int, int](src)(func(v int) int { return v })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+
+	_, err := r.Read(ctx)
+	assertEq("err", true, err == context.DeadlineExceeded, func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), r.Close(), func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithConflationWithNilArgs(t *testing.T) {
+	r := NewReaderWithConflation[int, int](nil)(func(v int) int { return v })
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+
+	r = NewReaderWithConflation[int, int](NewReaderFrom(1))(nil)
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
@@ -0,0 +1,157 @@
+package iox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// -----------------------------------------------------------------------------
+// Consumers.
+// -----------------------------------------------------------------------------
+
+// Reduce drains 'r' and folds its values into an accumulator with 'f',
+// starting with 'init'. It stops when 'r' returns io.EOF, in which case the
+// accumulator is returned with a nil err, or on another err from 'r' (or when
+// 'ctx' is done), in which case the accumulator so far is returned with that
+// err. Nil 'ctx' is allowed; nil 'r' is treated as empty; nil 'f' returns
+// 'init' and an err wrapping ErrInvalidArg.
+//
+// Example:
+//
+//	sum, err := Reduce(ctx, NewReaderFrom(1, 2, 3), 0, func(acc, v int) int {
+//		return acc + v
+//	})
+//
+//	t.Log(sum, err) // 6, nil
+func Reduce[T, A any](ctx context.Context, r Reader[T], init A, f func(A, T) A) (A, error) {
+	if f == nil {
+		return init, fmt.Errorf("%w: nil reduce func", ErrInvalidArg)
+	}
+
+	acc := init
+	err := ForEach(ctx, r, func(v T) error {
+		acc = f(acc, v)
+		return nil
+	})
+
+	return acc, err
+}
+
+// ForEach drains 'r' and calls 'f' with each value, i.e it is the value stream
+// equivalent of copying into a func. It stops when 'r' returns io.EOF (or an
+// err wrapping it, e.g ErrShortBatch), in which case nil is returned, or on
+// another err from 'r' or 'f' (or when 'ctx' is done), in which case that err
+// is returned. Nil 'ctx' is allowed; nil 'r' is treated as empty, also with
+// strict nil handling (see SetStrictNil); nil 'f' returns an err wrapping
+// ErrInvalidArg.
+//
+// Example:
+//
+//	err := ForEach(ctx, NewReaderFrom(1, 2), func(v int) error {
+//		t.Log(v)
+//		return nil
+//	})
+func ForEach[T any](ctx context.Context, r Reader[T], f func(T) error) error {
+	if f == nil {
+		return fmt.Errorf("%w: nil foreach func", ErrInvalidArg)
+	}
+	if r == nil {
+		return nil
+	}
+
+	for {
+		if err := ctxErr(ctx); err != nil {
+			return err
+		}
+
+		v, err := r.Read(ctx)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := f(v); err != nil {
+			return err
+		}
+	}
+}
+
+// Drain reads from 'r' until io.EOF and discards the values, e.g to let the
+// side effects of upstream stages run. It returns nil on io.EOF, and any other
+// err from 'r' (or the ctx err when 'ctx' is done). Nil 'ctx' is allowed; nil
+// 'r' is treated as empty.
+func Drain[T any](ctx context.Context, r Reader[T]) error {
+	return ForEach(ctx, r, func(T) error { return nil })
+}
+
+// Count drains 'r' and returns the amount of values read. On an err other than
+// io.EOF from 'r' (or when 'ctx' is done), the amount read so far is returned
+// along with that err. Nil 'ctx' is allowed; nil 'r' is treated as empty.
+//
+// Example:
+//
+//	n, err := Count(ctx, NewReaderFrom(1, 2, 3))
+//	t.Log(n, err) // 3, nil
+func Count[T any](ctx context.Context, r Reader[T]) (n int, err error) {
+	err = ForEach(ctx, r, func(T) error {
+		n++
+		return nil
+	})
+
+	return n, err
+}
+
+// Copy reads values from 'src' and writes them into 'dst' until 'src' returns
+// io.EOF, i.e it is the iox analog of io.Copy. It returns the amount of values
+// written, and the first err from 'src' (other than io.EOF) or 'dst', or the
+// ctx err when 'ctx' is done. Nil 'ctx' is allowed; nil 'src' is treated as
+// empty, and nil 'dst' as a closed Writer.
+//
+// Example:
+//
+//	s := make([]int, 0)
+//	w := WriterImpl[int]{Impl: func(_ context.Context, v int) error { s = append(s, v); return nil }}
+//
+//	n, err := Copy(ctx, w, NewReaderFrom(1, 2, 3))
+//	t.Log(n, err, s) // 3, nil, [1 2 3]
+func Copy[T any](ctx context.Context, dst Writer[T], src Reader[T]) (n int, err error) {
+	if dst == nil {
+		dst = nilWriter[T]()
+	}
+
+	err = ForEach(ctx, src, func(v T) error {
+		if err := dst.Write(ctx, v); err != nil {
+			return err
+		}
+
+		n++
+		return nil
+	})
+
+	return n, err
+}
+
+// CopyN is like Copy, but copies at most 'n' values, similar to io.CopyN. On
+// return, written == n if and only if err == nil; if 'src' ran out of values
+// before that, io.EOF is returned. 'n' <= 0 copies nothing.
+//
+// Example:
+//
+//	n, err := CopyN(ctx, w, NewReaderFrom(1, 2, 3), 2)
+//	t.Log(n, err) // 2, nil
+func CopyN[T any](ctx context.Context, dst Writer[T], src Reader[T], n int) (written int, err error) {
+	if n <= 0 {
+		return 0, nil
+	}
+
+	written, err = Copy(ctx, dst, NewReaderWithTake(src, n))
+	if err == nil && written < n {
+		err = io.EOF
+	}
+
+	return written, err
+}
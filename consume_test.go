@@ -0,0 +1,158 @@
+package iox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// Consumers.
+// -----------------------------------------------------------------------------
+
+func TestReduceIdeal(t *testing.T) {
+	sum, err := Reduce(nil, NewReaderFrom(1, 2, 3), 10, func(acc, v int) int { return acc + v })
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("sum", 16, sum, func(s string) { t.Fatal(s) })
+}
+
+func TestReduceWithReadErr(t *testing.T) {
+	r := newResultReader([]int{1, 2, 3}, []error{nil, io.ErrUnexpectedEOF, nil})
+
+	sum, err := Reduce(nil, r, 0, func(acc, v int) int { return acc + v })
+	assertEq("err", true, err == io.ErrUnexpectedEOF, func(s string) { t.Fatal(s) })
+	assertEq("sum", 1, sum, func(s string) { t.Fatal(s) })
+}
+
+func TestReduceWithCancelledCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Reduce(ctx, NewReaderFrom(1), 0, func(acc, v int) int { return acc + v })
+	assertEq("err", true, err == context.Canceled, func(s string) { t.Fatal(s) })
+}
+
+func TestReduceWithNilFn(t *testing.T) {
+	_, err := Reduce[int, int](nil, NewReaderFrom(1), 0, nil)
+	assertEq("err", true, errors.Is(err, ErrInvalidArg), func(s string) { t.Fatal(s) })
+}
+
+func TestForEachIdeal(t *testing.T) {
+	var vs []int
+	err := ForEach(nil, NewReaderFrom(1, 2, 3), func(v int) error {
+		vs = append(vs, v)
+		return nil
+	})
+
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("vals", []int{1, 2, 3}, vs, func(s string) { t.Fatal(s) })
+}
+
+func TestForEachWithFnErr(t *testing.T) {
+	var vs []int
+	err := ForEach(nil, NewReaderFrom(1, 2, 3), func(v int) error {
+		if v == 2 {
+			return io.ErrShortWrite
+		}
+
+		vs = append(vs, v)
+		return nil
+	})
+
+	assertEq("err", true, err == io.ErrShortWrite, func(s string) { t.Fatal(s) })
+	assertEq("vals", []int{1}, vs, func(s string) { t.Fatal(s) })
+}
+
+func TestForEachWithNilReaderInStrictMode(t *testing.T) {
+	SetStrictNil(true)
+	defer SetStrictNil(false)
+
+	err := ForEach(nil, nil, func(v int) error { return nil })
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+
+	err = ForEach(nil, NewReaderWithFilterFn[int](nil)(nil), func(v int) error { return nil })
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+}
+
+func TestDrainIdeal(t *testing.T) {
+	n := 0
+	r := NewReaderWithOnReadFn(NewReaderFrom(1, 2, 3))(func(int, error) { n++ })
+
+	assertEq("err", *new(error), Drain(nil, r), func(s string) { t.Fatal(s) })
+	assertEq("reads", 4, n, func(s string) { t.Fatal(s) })
+}
+
+func TestDrainWithReadErr(t *testing.T) {
+	r := newResultReader([]int{1, 2}, []error{nil, io.ErrUnexpectedEOF})
+
+	assertEq("err", true, Drain(nil, r) == io.ErrUnexpectedEOF, func(s string) { t.Fatal(s) })
+}
+
+func TestCountIdeal(t *testing.T) {
+	n, err := Count(nil, NewReaderFrom(1, 2, 3))
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("n", 3, n, func(s string) { t.Fatal(s) })
+}
+
+func TestCountWithReadErr(t *testing.T) {
+	r := newResultReader([]int{1, 2, 3}, []error{nil, nil, io.ErrUnexpectedEOF})
+
+	n, err := Count(nil, r)
+	assertEq("err", true, err == io.ErrUnexpectedEOF, func(s string) { t.Fatal(s) })
+	assertEq("n", 2, n, func(s string) { t.Fatal(s) })
+}
+
+func TestCopyIdeal(t *testing.T) {
+	s := make([]int, 0, 3)
+
+	n, err := Copy(nil, newSliceWriter(&s), NewReaderFrom(1, 2, 3))
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("n", 3, n, func(s string) { t.Fatal(s) })
+	assertEq("vals", []int{1, 2, 3}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestCopyWithWriteErr(t *testing.T) {
+	w := WriterImpl[int]{
+		Impl: func(ctx context.Context, v int) error {
+			if v == 2 {
+				return io.ErrShortWrite
+			}
+
+			return nil
+		},
+	}
+
+	n, err := Copy[int](nil, w, NewReaderFrom(1, 2, 3))
+	assertEq("err", true, err == io.ErrShortWrite, func(s string) { t.Fatal(s) })
+	assertEq("n", 1, n, func(s string) { t.Fatal(s) })
+}
+
+func TestCopyWithNilWriter(t *testing.T) {
+	n, err := Copy(nil, nil, NewReaderFrom(1))
+	assertEq("err", true, err == io.ErrClosedPipe, func(s string) { t.Fatal(s) })
+	assertEq("n", 0, n, func(s string) { t.Fatal(s) })
+}
+
+func TestCopyNIdeal(t *testing.T) {
+	s := make([]int, 0, 2)
+	r := NewReaderFrom(1, 2, 3)
+
+	n, err := CopyN(nil, newSliceWriter(&s), r, 2)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("n", 2, n, func(s string) { t.Fatal(s) })
+	assertEq("vals", []int{1, 2}, s, func(s string) { t.Fatal(s) })
+
+	// The rest is left in 'r'.
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 3, val, func(s string) { t.Fatal(s) })
+}
+
+func TestCopyNWithShortSource(t *testing.T) {
+	s := make([]int, 0, 2)
+
+	n, err := CopyN(nil, newSliceWriter(&s), NewReaderFrom(1), 2)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("n", 1, n, func(s string) { t.Fatal(s) })
+}
@@ -0,0 +1,284 @@
+package iox
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// Enrichment.
+// -----------------------------------------------------------------------------
+
+// EnrichCfg is used to configure NewReaderWithEnrichFn.
+type EnrichCfg[T any] struct {
+	// Concurrency is the max amount of lookups in flight. Values are read
+	// from the source in groups of this size, looked up concurrently, and
+	// yielded in source order. <= 1 means sequential lookups.
+	Concurrency int
+	// Key enables caching of lookup results, see CacheCfg. Values with the
+	// same key share a cached result. The returned keys must be comparable
+	// (i.e valid map keys). Nil means no caching.
+	Key func(T) any
+	// Cache configures the cache enabled with Key.
+	Cache CacheCfg
+	// OnErr is called with the source value and err of a failed lookup. The
+	// err it returns is given by Read, where nil skips the value. Nil means
+	// that lookup errors are returned as-is.
+	OnErr func(v T, err error) error
+}
+
+// NewReaderWithEnrichFn returns a reader of values from 'r' mapped with
+// 'lookup', which may call external systems, e.g a database or an API. This
+// is the streaming analog of a lookup join. See EnrichCfg for concurrency,
+// caching and error policy; the zero EnrichCfg does sequential lookups
+// without caching and returns lookup errors as-is. Errors from 'r' are
+// returned after the values read before them are yielded. An empty non-nil
+// Reader is returned if 'r' or 'lookup' is nil.
+//
+// Example:
+//
+//	r := NewReaderWithEnrichFn[Order, EnrichedOrder](orders)(
+//		func(ctx context.Context, v Order) (EnrichedOrder, error) {
+//			u, err := users.Fetch(ctx, v.UserID)
+//			return EnrichedOrder{Order: v, User: u}, err
+//		},
+//		EnrichCfg[Order]{
+//			Concurrency: 8,
+//			Key:         func(v Order) any { return v.UserID },
+//			Cache:       CacheCfg{TTL: time.Minute},
+//		},
+//	)
+func NewReaderWithEnrichFn[T, U any](r Reader[T]) func(lookup func(ctx context.Context, v T) (U, error), cfg EnrichCfg[T]) Reader[U] {
+	return func(lookup func(ctx context.Context, v T) (U, error), cfg EnrichCfg[T]) Reader[U] {
+		if r == nil || lookup == nil {
+			return nilReader[U]()
+		}
+
+		size := max(cfg.Concurrency, 1)
+
+		// Without a load func, as values are loaded through 'lookup' which
+		// needs the source value and not only the key.
+		var cache *Cache[any, U]
+		if cfg.Key != nil {
+			cache = NewCache[any, U](nil, cfg.Cache)
+		}
+
+		enrich := func(ctx context.Context, v T) (U, error) {
+			if cache == nil {
+				return lookup(ctx, v)
+			}
+
+			k := cfg.Key(v)
+			now := ClockFrom(ctx).Now()
+			if u, ok := cache.lookup(k, now); ok {
+				return u, nil
+			}
+
+			u, err := lookup(ctx, v)
+			if err == nil {
+				cache.store(k, u, now)
+			}
+
+			return u, err
+		}
+
+		type result struct {
+			src T
+			val U
+			err error
+		}
+
+		srcs := make([]T, 0, size)
+		results := make([]result, 0, size)
+		next := 0
+		var srcErr error
+
+		return ReaderImpl[U]{
+			Impl: func(ctx context.Context) (val U, err error) {
+				for {
+					if next < len(results) {
+						res := results[next]
+						next++
+						if res.err == nil {
+							return res.val, nil
+						}
+
+						err = res.err
+						if cfg.OnErr != nil {
+							err = cfg.OnErr(res.src, err)
+						}
+						if err == nil {
+							continue
+						}
+
+						return val, err
+					}
+
+					if srcErr != nil {
+						err, srcErr = srcErr, nil
+						return val, err
+					}
+
+					srcs = srcs[:0]
+					for len(srcs) < size {
+						v, err := r.Read(ctx)
+						if err != nil {
+							srcErr = err
+							break
+						}
+
+						srcs = append(srcs, v)
+					}
+
+					results, next = results[:len(srcs)], 0
+					if len(srcs) == 1 {
+						results[0].src = srcs[0]
+						results[0].val, results[0].err = enrich(ctx, srcs[0])
+						continue
+					}
+
+					var wg sync.WaitGroup
+					for i, v := range srcs {
+						wg.Add(1)
+						go func(i int, v T) {
+							defer wg.Done()
+							results[i].src = v
+							results[i].val, results[i].err = enrich(ctx, v)
+						}(i, v)
+					}
+
+					wg.Wait()
+				}
+			},
+		}
+	}
+}
+
+// BatchEnrichCfg is used to configure NewReaderWithBatchEnrichFn.
+type BatchEnrichCfg[T any] struct {
+	// Size is the max amount of values per bulk lookup, <= 0 defaults to 8.
+	Size int
+	// Linger is the max time spent filling a batch, counted from the first
+	// value of the batch, after which the batch is looked up even though it
+	// is not full. The Clock in the ctx is used, see ClockFrom. Note that it
+	// is checked between reads, so a slow Read of the source delays a batch
+	// regardless of Linger. <= 0 means that batches are only cut by Size.
+	Linger time.Duration
+	// OnErr is called with the batch and err of a failed bulk lookup. The
+	// err it returns is given by Read, where nil skips the batch. Nil means
+	// that lookup errors are returned as-is (and the batch is skipped). The
+	// batch is reused, so it must not be retained after OnErr returns.
+	OnErr func(vs []T, err error) error
+}
+
+// NewReaderWithBatchEnrichFn is like NewReaderWithEnrichFn, but it groups
+// values from 'r' into batches (see BatchEnrichCfg) and does one bulk lookup
+// per batch, which saves round-trips to databases and APIs. The keys given to
+// 'lookup' are those returned by 'key' for the values in the batch, with
+// duplicates removed. The result of 'lookup' is associated back to each value
+// by key, with Enriched.Found being false for keys missing from the result.
+// Errors from 'r' are returned after the values read before them are yielded.
+// An empty non-nil Reader is returned if 'r', 'key' or 'lookup' is nil.
+//
+// Example:
+//
+//	r := NewReaderWithBatchEnrichFn[Order, User, int](orders)(
+//		func(v Order) int { return v.UserID },
+//		func(ctx context.Context, ids []int) (map[int]User, error) {
+//			// E.g SELECT ... WHERE id IN (...)
+//		},
+//		BatchEnrichCfg[Order]{Size: 100, Linger: time.Millisecond * 50},
+//	)
+//
+//	v, err := r.Read(ctx) // Enriched{Value: Order{...}, Lookup: User{...}, Found: true}
+func NewReaderWithBatchEnrichFn[T, U any, K comparable](r Reader[T]) func(
+	key func(T) K,
+	lookup func(ctx context.Context, keys []K) (map[K]U, error),
+	cfg BatchEnrichCfg[T],
+) Reader[Enriched[T, U]] {
+	return func(
+		key func(T) K,
+		lookup func(ctx context.Context, keys []K) (map[K]U, error),
+		cfg BatchEnrichCfg[T],
+	) Reader[Enriched[T, U]] {
+		if r == nil || key == nil || lookup == nil {
+			return nilReader[Enriched[T, U]]()
+		}
+
+		if cfg.Size <= 0 {
+			cfg.Size = 8
+		}
+
+		batch := make([]T, 0, cfg.Size)
+		keys := make([]K, 0, cfg.Size)
+		seen := make(map[K]struct{}, cfg.Size)
+		var found map[K]U
+		next := 0
+		var srcErr error
+
+		return ReaderImpl[Enriched[T, U]]{
+			Impl: func(ctx context.Context) (val Enriched[T, U], err error) {
+				for {
+					if next < len(batch) {
+						v := batch[next]
+						next++
+
+						val.Value = v
+						val.Lookup, val.Found = found[key(v)]
+						return val, nil
+					}
+
+					if srcErr != nil {
+						err, srcErr = srcErr, nil
+						return val, err
+					}
+
+					batch, keys, next = batch[:0], keys[:0], 0
+					clear(seen)
+
+					clock := ClockFrom(ctx)
+					var start time.Time
+					for len(batch) < cfg.Size {
+						if len(batch) > 0 && cfg.Linger > 0 && clock.Now().Sub(start) >= cfg.Linger {
+							break
+						}
+
+						v, err := r.Read(ctx)
+						if err != nil {
+							srcErr = err
+							break
+						}
+
+						if len(batch) == 0 {
+							start = clock.Now()
+						}
+
+						batch = append(batch, v)
+						k := key(v)
+						if _, ok := seen[k]; !ok {
+							seen[k] = struct{}{}
+							keys = append(keys, k)
+						}
+					}
+
+					if len(batch) == 0 {
+						continue
+					}
+
+					found, err = lookup(ctx, keys)
+					if err != nil {
+						if cfg.OnErr != nil {
+							err = cfg.OnErr(batch, err)
+						}
+
+						batch = batch[:0]
+						if err != nil {
+							return val, err
+						}
+					}
+				}
+			},
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package iox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// Enrichment.
+// -----------------------------------------------------------------------------
+
+func TestNewReaderWithEnrichFnIdeal(t *testing.T) {
+	r := NewReaderWithEnrichFn[int, string](NewReaderFrom(1, 2, 3))(
+		func(ctx context.Context, v int) (string, error) {
+			return string(rune('a' + v - 1)), nil
+		},
+		EnrichCfg[int]{},
+	)
+
+	for _, want := range []string{"a", "b", "c"} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithEnrichFnWithConcurrency(t *testing.T) {
+	var inFlight, maxInFlight int64
+	r := NewReaderWithEnrichFn[int, int](NewReaderFrom(1, 2, 3, 4, 5))(
+		func(ctx context.Context, v int) (int, error) {
+			n := atomic.AddInt64(&inFlight, 1)
+			defer atomic.AddInt64(&inFlight, -1)
+			for {
+				m := atomic.LoadInt64(&maxInFlight)
+				if n <= m || atomic.CompareAndSwapInt64(&maxInFlight, m, n) {
+					break
+				}
+			}
+
+			// Later values finish first, order must still be kept.
+			time.Sleep(time.Millisecond * time.Duration(10-v))
+			return v * 10, nil
+		},
+		EnrichCfg[int]{Concurrency: 2},
+	)
+
+	for want := 10; want <= 50; want += 10 {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("maxInFlight", true, maxInFlight <= 2, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithEnrichFnWithCache(t *testing.T) {
+	lookups := 0
+	r := NewReaderWithEnrichFn[int, int](NewReaderFrom(1, 2, 1, 1, 2))(
+		func(ctx context.Context, v int) (int, error) {
+			lookups++
+			return v * 10, nil
+		},
+		EnrichCfg[int]{Key: func(v int) any { return v }},
+	)
+
+	for _, want := range []int{10, 20, 10, 10, 20} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("lookups", 2, lookups, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithEnrichFnWithOnErr(t *testing.T) {
+	errLookup := errors.New("lookup")
+	var failed []int
+	r := NewReaderWithEnrichFn[int, int](NewReaderFrom(1, 2, 3))(
+		func(ctx context.Context, v int) (int, error) {
+			if v == 2 {
+				return 0, errLookup
+			}
+			return v, nil
+		},
+		EnrichCfg[int]{
+			OnErr: func(v int, err error) error {
+				failed = append(failed, v)
+				return nil
+			},
+		},
+	)
+
+	for _, want := range []int{1, 3} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("failed", []int{2}, failed, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithEnrichFnWithReadErr(t *testing.T) {
+	src := newResultReader([]int{1, 2}, []error{nil, io.ErrUnexpectedEOF})
+	r := NewReaderWithEnrichFn[int, int](src)(
+		func(ctx context.Context, v int) (int, error) { return v, nil },
+		EnrichCfg[int]{Concurrency: 4},
+	)
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", io.ErrUnexpectedEOF, err, func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithEnrichFnWithNilArgs(t *testing.T) {
+	r := NewReaderWithEnrichFn[int, int](nil)(
+		func(ctx context.Context, v int) (int, error) { return v, nil },
+		EnrichCfg[int]{},
+	)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+
+	r = NewReaderWithEnrichFn[int, int](NewReaderFrom(1))(nil, EnrichCfg[int]{})
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithBatchEnrichFnIdeal(t *testing.T) {
+	var calls [][]int
+	r := NewReaderWithBatchEnrichFn[int, string, int](NewReaderFrom(1, 2, 1, 3, 4))(
+		func(v int) int { return v },
+		func(ctx context.Context, keys []int) (map[int]string, error) {
+			calls = append(calls, append([]int(nil), keys...))
+			m := make(map[int]string)
+			for _, k := range keys {
+				if k != 3 {
+					m[k] = string(rune('a' + k - 1))
+				}
+			}
+			return m, nil
+		},
+		BatchEnrichCfg[int]{Size: 3},
+	)
+
+	want := []Enriched[int, string]{
+		{Value: 1, Lookup: "a", Found: true},
+		{Value: 2, Lookup: "b", Found: true},
+		{Value: 1, Lookup: "a", Found: true},
+		{Value: 3},
+		{Value: 4, Lookup: "d", Found: true},
+	}
+	for _, w := range want {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", w, val, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("calls", [][]int{{1, 2}, {3, 4}}, calls, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithBatchEnrichFnWithLinger(t *testing.T) {
+	clock := &testClock{now: time.Unix(0, 0)}
+	n := 0
+	src := ReaderImpl[int]{
+		Impl: func(ctx context.Context) (int, error) {
+			clock.now = clock.now.Add(time.Second)
+			n++
+			return n, nil
+		},
+	}
+
+	var sizes []int
+	r := NewReaderWithBatchEnrichFn[int, int, int](src)(
+		func(v int) int { return v },
+		func(ctx context.Context, keys []int) (map[int]int, error) {
+			sizes = append(sizes, len(keys))
+			return nil, nil
+		},
+		BatchEnrichCfg[int]{Size: 100, Linger: time.Second * 2},
+	)
+
+	// Values are read 1s apart, so the linger cuts the batch after three.
+	ctx := WithClock(context.Background(), clock)
+	for i := 0; i < 3; i++ {
+		r.Read(ctx)
+	}
+
+	assertEq("sizes", []int{3}, sizes, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithBatchEnrichFnWithOnErr(t *testing.T) {
+	errLookup := errors.New("lookup")
+	var failed []int
+	r := NewReaderWithBatchEnrichFn[int, int, int](NewReaderFrom(1, 2, 3))(
+		func(v int) int { return v },
+		func(ctx context.Context, keys []int) (map[int]int, error) {
+			if keys[0] == 1 {
+				return nil, errLookup
+			}
+			return map[int]int{3: 30}, nil
+		},
+		BatchEnrichCfg[int]{
+			Size: 2,
+			OnErr: func(vs []int, err error) error {
+				failed = append(failed, vs...)
+				return nil
+			},
+		},
+	)
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", Enriched[int, int]{Value: 3, Lookup: 30, Found: true}, val, func(s string) { t.Fatal(s) })
+	assertEq("failed", []int{1, 2}, failed, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithBatchEnrichFnWithNilArgs(t *testing.T) {
+	r := NewReaderWithBatchEnrichFn[int, int, int](NewReaderFrom(1))(
+		nil,
+		func(ctx context.Context, keys []int) (map[int]int, error) { return nil, nil },
+		BatchEnrichCfg[int]{},
+	)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
@@ -0,0 +1,121 @@
+package iox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// -----------------------------------------------------------------------------
+// Envelope types.
+// -----------------------------------------------------------------------------
+
+// ErrUnknownEnvelope is returned by envelope readers when they encounter an
+// Envelope with a tag which has no registered decode func.
+var ErrUnknownEnvelope = errors.New("iox: unknown envelope tag")
+
+// EnvelopeTag identifies the type and version of a value inside an Envelope.
+type EnvelopeTag struct {
+	Type    string
+	Version int
+}
+
+// String implements fmt.Stringer, e.g "user@v2".
+func (tag EnvelopeTag) String() string {
+	return fmt.Sprintf("%s@v%d", tag.Type, tag.Version)
+}
+
+// Envelope wraps an encoded value with a type/version tag. Envelopes are plain
+// values, so they may be encoded with any Encoder (e.g through
+// NewWriterFromValues) and decoded with the matching Decoder. This enables
+// rolling upgrades of persisted or transmitted streams: new writers tag values
+// with a new version while readers keep decode funcs for older versions.
+type Envelope struct {
+	Type    string
+	Version int
+	Data    []byte
+}
+
+// Tag returns the EnvelopeTag of the Envelope.
+func (e Envelope) Tag() EnvelopeTag {
+	return EnvelopeTag{Type: e.Type, Version: e.Version}
+}
+
+// -----------------------------------------------------------------------------
+// Modifiers.
+// -----------------------------------------------------------------------------
+
+// NewWriterWithEnvelope returns a writer which encodes values with 'f', wraps
+// the result in an Envelope tagged with 'tag', and writes that into 'w'.
+// Nil 'w' or 'f' returns an empty Writer.
+//
+// Example:
+//
+//	b := bytes.NewBuffer(nil)
+//	w := NewWriterWithEnvelope[User](NewWriterFromValues[Envelope](b)(nil))(
+//		EnvelopeTag{Type: "user", Version: 2},
+//		func(v User) ([]byte, error) {
+//			return json.Marshal(v)
+//		},
+//	)
+//
+//	w.Write(nil, User{Name: "x"}) // b now holds the encoded Envelope.
+func NewWriterWithEnvelope[T any](w Writer[Envelope]) func(tag EnvelopeTag, f func(T) ([]byte, error)) Writer[T] {
+	return func(tag EnvelopeTag, f func(T) ([]byte, error)) Writer[T] {
+		if w == nil || f == nil {
+			return nilWriter[T]()
+		}
+
+		return WriterImpl[T]{
+			Wraps: w,
+			Impl: func(ctx context.Context, v T) error {
+				b, err := f(v)
+				if err != nil {
+					return err
+				}
+
+				return w.Write(ctx, Envelope{Type: tag.Type, Version: tag.Version, Data: b})
+			},
+		}
+	}
+}
+
+// NewReaderWithEnvelope returns a reader which reads envelopes from 'r' and
+// dispatches them to the decode func registered for their tag in 'fs'. An
+// Envelope with an unregistered tag results in an err wrapping
+// ErrUnknownEnvelope. Nil 'r' returns an empty non-nil Reader.
+//
+// Example:
+//
+//	r := NewReaderWithEnvelope[User](NewReaderFromBytes[Envelope](b)(nil))(
+//		map[EnvelopeTag]func([]byte) (User, error){
+//			{Type: "user", Version: 1}: decodeUserV1, // Legacy data.
+//			{Type: "user", Version: 2}: decodeUserV2,
+//		},
+//	)
+//
+//	t.Log(r.Read(nil)) // User, nil
+func NewReaderWithEnvelope[T any](r Reader[Envelope]) func(fs map[EnvelopeTag]func([]byte) (T, error)) Reader[T] {
+	return func(fs map[EnvelopeTag]func([]byte) (T, error)) Reader[T] {
+		if r == nil {
+			return nilReader[T]()
+		}
+
+		return ReaderImpl[T]{
+			Wraps: r,
+			Impl: func(ctx context.Context) (val T, err error) {
+				e, err := r.Read(ctx)
+				if err != nil {
+					return val, err
+				}
+
+				f := fs[e.Tag()]
+				if f == nil {
+					return val, fmt.Errorf("%w: %v", ErrUnknownEnvelope, e.Tag())
+				}
+
+				return f(e.Data)
+			},
+		}
+	}
+}
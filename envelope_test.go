@@ -0,0 +1,94 @@
+package iox
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// Envelope types.
+// -----------------------------------------------------------------------------
+
+func TestEnvelopeTagString(t *testing.T) {
+	tag := Envelope{Type: "user", Version: 2}.Tag()
+	assertEq("tag", "user@v2", tag.String(), func(s string) { t.Fatal(s) })
+}
+
+// -----------------------------------------------------------------------------
+// Modifiers.
+// -----------------------------------------------------------------------------
+
+func TestEnvelopeRoundtripIdeal(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	ew := NewWriterFromValues[Envelope](b)(nil)
+
+	// Legacy writer, encodes ints as decimal strings.
+	w1 := NewWriterWithEnvelope[int](ew)(
+		EnvelopeTag{Type: "int", Version: 1},
+		func(v int) ([]byte, error) { return []byte(strconv.Itoa(v)), nil },
+	)
+
+	// Current writer, encodes ints as json.
+	w2 := NewWriterWithEnvelope[int](ew)(
+		EnvelopeTag{Type: "int", Version: 2},
+		func(v int) ([]byte, error) { return json.Marshal(v) },
+	)
+
+	assertEq("err", *new(error), w1.Write(nil, 1), func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), w2.Write(nil, 2), func(s string) { t.Fatal(s) })
+
+	r := NewReaderWithEnvelope[int](NewReaderFromBytes[Envelope](b)(nil))(
+		map[EnvelopeTag]func([]byte) (int, error){
+			{Type: "int", Version: 1}: func(b []byte) (int, error) {
+				return strconv.Atoi(string(b))
+			},
+			{Type: "int", Version: 2}: func(b []byte) (v int, err error) {
+				err = json.Unmarshal(b, &v)
+				return
+			},
+		},
+	)
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+
+	val, err = r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 2, val, func(s string) { t.Fatal(s) })
+
+	val, err = r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+	assertEq("val", 0, val, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithEnvelopeWithNilWriter(t *testing.T) {
+	w := NewWriterWithEnvelope[int](nil)(
+		EnvelopeTag{},
+		func(v int) ([]byte, error) { return nil, nil },
+	)
+
+	err := w.Write(nil, 1)
+	assertEq("err", true, err == io.ErrClosedPipe, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithEnvelopeWithNilReader(t *testing.T) {
+	r := NewReaderWithEnvelope[int](nil)(nil)
+
+	val, err := r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+	assertEq("val", 0, val, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithEnvelopeWithUnknownTag(t *testing.T) {
+	e := Envelope{Type: "int", Version: 3}
+	r := NewReaderWithEnvelope[int](NewReaderFrom(e))(nil)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, errors.Is(err, ErrUnknownEnvelope), func(s string) { t.Fatal(s) })
+	assertEq("msg", "iox: unknown envelope tag: int@v3", err.Error(), func(s string) { t.Fatal(s) })
+}
@@ -0,0 +1,211 @@
+package iox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// -----------------------------------------------------------------------------
+// Error classification.
+// -----------------------------------------------------------------------------
+
+// ErrClass is the class of an err, which tells policies (e.g retries or
+// dead-lettering) how to treat it, see ClassifyErr.
+type ErrClass int
+
+const (
+	// ErrClassUnknown is the class of errors which are not classified, it is
+	// up to the policy to decide how to treat them.
+	ErrClassUnknown ErrClass = iota
+	// ErrClassRetryable is the class of errors which may go away if the
+	// failed operation is tried again, e.g timeouts.
+	ErrClassRetryable
+	// ErrClassTerminal is the class of errors which will not go away if the
+	// failed operation is tried again, e.g invalid input or a closed Writer.
+	ErrClassTerminal
+)
+
+// String implements fmt.Stringer.
+func (c ErrClass) String() string {
+	switch c {
+	case ErrClassRetryable:
+		return "retryable"
+	case ErrClassTerminal:
+		return "terminal"
+	default:
+		return "unknown"
+	}
+}
+
+// errClassRule is a rule of the registry used by ClassifyErr.
+type errClassRule struct {
+	target error
+	f      func(error) ErrClass
+	class  ErrClass
+}
+
+var errClassRegistry = struct {
+	mx    sync.RWMutex
+	rules []errClassRule
+}{
+	rules: []errClassRule{
+		{target: io.EOF, class: ErrClassTerminal},
+		{target: io.ErrClosedPipe, class: ErrClassTerminal},
+		{target: context.Canceled, class: ErrClassTerminal},
+		{target: context.DeadlineExceeded, class: ErrClassRetryable},
+		{target: ErrInvalidArg, class: ErrClassTerminal},
+		{target: ErrUnknownEnvelope, class: ErrClassTerminal},
+		{target: ErrUnregisteredType, class: ErrClassTerminal},
+		{target: ErrJournalCorrupt, class: ErrClassTerminal},
+		{target: ErrChunkCorrupt, class: ErrClassTerminal},
+		{target: ErrSequenceGap, class: ErrClassTerminal},
+		{target: ErrContract, class: ErrClassTerminal},
+		{target: ErrBudgetExceeded, class: ErrClassRetryable},
+		{f: func(err error) ErrClass {
+			var e *PanicError
+			if errors.As(err, &e) {
+				return ErrClassTerminal
+			}
+
+			return ErrClassUnknown
+		}},
+	},
+}
+
+// RegisterErrClass registers the class of errors which match 'target' (with
+// errors.Is), such that they are classified consistently by ClassifyErr, and
+// so by everything which uses it. Rules registered later take precedence, so
+// this may also be used to override the defaults of this package. This is
+// intended to be called during initialization; nil 'target' is ignored.
+//
+// Example:
+//
+//	iox.RegisterErrClass(sql.ErrNoRows, iox.ErrClassTerminal)
+func RegisterErrClass(target error, class ErrClass) {
+	if target == nil {
+		return
+	}
+
+	errClassRegistry.mx.Lock()
+	defer errClassRegistry.mx.Unlock()
+
+	errClassRegistry.rules = append(errClassRegistry.rules, errClassRule{target: target, class: class})
+}
+
+// RegisterErrClassFn is like RegisterErrClass, but the class is given by 'f',
+// e.g for errors of a type rather than sentinel values. 'f' should return
+// ErrClassUnknown for errors it does not know about, such that other rules
+// are tried. Nil 'f' is ignored.
+//
+// Example:
+//
+//	iox.RegisterErrClassFn(func(err error) iox.ErrClass {
+//		var e *HTTPError
+//		if errors.As(err, &e) && e.Code >= 500 {
+//			return iox.ErrClassRetryable
+//		}
+//		return iox.ErrClassUnknown
+//	})
+func RegisterErrClassFn(f func(err error) ErrClass) {
+	if f == nil {
+		return
+	}
+
+	errClassRegistry.mx.Lock()
+	defer errClassRegistry.mx.Unlock()
+
+	errClassRegistry.rules = append(errClassRegistry.rules, errClassRule{f: f})
+}
+
+// classifiedError is an err with an explicit class, see WithErrClass.
+type classifiedError struct {
+	err   error
+	class ErrClass
+}
+
+func (e *classifiedError) Error() string { return e.err.Error() }
+func (e *classifiedError) Unwrap() error { return e.err }
+
+// WithErrClass returns 'err' marked with the given class, which takes
+// precedence over registered rules in ClassifyErr. The returned err wraps
+// 'err'. Nil 'err' returns nil.
+//
+// Example:
+//
+//	if resp.StatusCode == 429 {
+//		return iox.WithErrClass(fmt.Errorf("rate limited"), iox.ErrClassRetryable)
+//	}
+func WithErrClass(err error, class ErrClass) error {
+	if err == nil {
+		return nil
+	}
+
+	return &classifiedError{err: err, class: class}
+}
+
+// ClassifyErr returns the class of 'err'. It is decided by (in order):
+//   - An explicit class, see WithErrClass.
+//   - Registered rules, see RegisterErrClass, latest first.
+//   - A "Timeout() bool" or "Temporary() bool" method (e.g of net.Error),
+//     where true gives ErrClassRetryable.
+//
+// Errors of this package and io.EOF/io.ErrClosedPipe are terminal by default,
+// except ErrBudgetExceeded which is retryable. Of the ctx errors,
+// context.Canceled is terminal while context.DeadlineExceeded is retryable,
+// e.g after a per-attempt timeout. A *PanicError is terminal. Nil 'err' and
+// errors which match nothing give ErrClassUnknown.
+func ClassifyErr(err error) ErrClass {
+	if err == nil {
+		return ErrClassUnknown
+	}
+
+	var ce *classifiedError
+	if errors.As(err, &ce) {
+		return ce.class
+	}
+
+	errClassRegistry.mx.RLock()
+	rules := errClassRegistry.rules
+	errClassRegistry.mx.RUnlock()
+
+	for i := len(rules) - 1; i >= 0; i-- {
+		rule := rules[i]
+		if rule.f != nil {
+			if class := rule.f(err); class != ErrClassUnknown {
+				return class
+			}
+
+			continue
+		}
+
+		if errors.Is(err, rule.target) {
+			return rule.class
+		}
+	}
+
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return ErrClassRetryable
+	}
+
+	var temporary interface{ Temporary() bool }
+	if errors.As(err, &temporary) && temporary.Temporary() {
+		return ErrClassRetryable
+	}
+
+	return ErrClassUnknown
+}
+
+// IsRetryable returns true if 'err' is classified as ErrClassRetryable, see
+// ClassifyErr.
+func IsRetryable(err error) bool {
+	return ClassifyErr(err) == ErrClassRetryable
+}
+
+// IsTerminal returns true if 'err' is classified as ErrClassTerminal, see
+// ClassifyErr.
+func IsTerminal(err error) bool {
+	return ClassifyErr(err) == ErrClassTerminal
+}
@@ -0,0 +1,66 @@
+package iox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+type testTimeoutErr struct{}
+
+func (testTimeoutErr) Error() string { return "timeout" }
+func (testTimeoutErr) Timeout() bool { return true }
+
+func TestClassifyErrDefaults(t *testing.T) {
+	cases := []struct {
+		err  error
+		want ErrClass
+	}{
+		{nil, ErrClassUnknown},
+		{errors.New("x"), ErrClassUnknown},
+		{io.EOF, ErrClassTerminal},
+		{ErrShortBatch, ErrClassTerminal},
+		{fmt.Errorf("wrapped: %w", ErrInvalidArg), ErrClassTerminal},
+		{context.Canceled, ErrClassTerminal},
+		{context.DeadlineExceeded, ErrClassRetryable},
+		{fmt.Errorf("%w: want 2, got 3", ErrSequenceGap), ErrClassTerminal},
+		{fmt.Errorf("%w: %w", ErrContract, errors.New("x")), ErrClassTerminal},
+		{ErrBudgetExceeded, ErrClassRetryable},
+		{&PanicError{Stage: "a", Value: "boom"}, ErrClassTerminal},
+		{fmt.Errorf("wrapped: %w", testTimeoutErr{}), ErrClassRetryable},
+	}
+
+	for i, c := range cases {
+		assertEq(fmt.Sprint("class", i), c.want, ClassifyErr(c.err), func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestRegisterErrClassIdeal(t *testing.T) {
+	errA := errors.New("a")
+	errB := errors.New("b")
+	RegisterErrClass(errA, ErrClassRetryable)
+	RegisterErrClassFn(func(err error) ErrClass {
+		if err == errB {
+			return ErrClassTerminal
+		}
+
+		return ErrClassUnknown
+	})
+
+	assertEq("retryable", true, IsRetryable(fmt.Errorf("x: %w", errA)), func(s string) { t.Fatal(s) })
+	assertEq("terminal", true, IsTerminal(errB), func(s string) { t.Fatal(s) })
+
+	// Later rules take precedence.
+	RegisterErrClass(errA, ErrClassTerminal)
+	assertEq("terminal", true, IsTerminal(errA), func(s string) { t.Fatal(s) })
+}
+
+func TestWithErrClassIdeal(t *testing.T) {
+	err := WithErrClass(io.EOF, ErrClassRetryable)
+
+	assertEq("retryable", true, IsRetryable(err), func(s string) { t.Fatal(s) })
+	assertEq("is", true, errors.Is(err, io.EOF), func(s string) { t.Fatal(s) })
+	assertEq("nil", nil, WithErrClass(nil, ErrClassTerminal), func(s string) { t.Fatal(s) })
+}
@@ -0,0 +1,360 @@
+package iox
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"sort"
+	"sync"
+)
+
+// -----------------------------------------------------------------------------
+// Distinct count.
+// -----------------------------------------------------------------------------
+
+// DistinctCounter is a Writer which estimates the number of distinct values
+// written into it, using linear counting over a fixed size bitmap. Memory
+// usage is fixed regardless of how many values are written, and the estimate
+// is accurate (within a few percent) as long as the number of distinct values
+// is not much larger than the number of bits. Create it with
+// NewDistinctCounter. It is safe for concurrent use.
+type DistinctCounter[T any] struct {
+	mx     sync.Mutex
+	bitmap []uint64
+	m      uint64
+	hash   func(T) uint64
+}
+
+// NewDistinctCounter returns a DistinctCounter using 'hash' to hash values
+// and a bitmap of 'm' bits (<= 0 defaults to 1<<16, i.e 8KiB). Nil 'hash'
+// hashes the fmt.Sprint representation of values with FNV-1a, which works
+// for anything but is slow; prefer a specialised func for large streams.
+//
+// Example:
+//
+//	c := NewDistinctCounter(func(v User) uint64 { return v.ID }, 0)
+//	w := NewWriterWithTee(sink, c) // Or use it as any other Writer.
+//	...
+//	t.Log(c.Estimate()) // ~ number of distinct user IDs.
+func NewDistinctCounter[T any](hash func(T) uint64, m int) *DistinctCounter[T] {
+	if m <= 0 {
+		m = 1 << 16
+	}
+
+	if hash == nil {
+		hash = func(v T) uint64 {
+			h := fnv.New64a()
+			fmt.Fprint(h, v)
+			return h.Sum64()
+		}
+	}
+
+	return &DistinctCounter[T]{
+		bitmap: make([]uint64, (m+63)/64),
+		m:      uint64(m),
+		hash:   hash,
+	}
+}
+
+// Write implements Writer by recording the hash of 'v'. It never fails.
+func (c *DistinctCounter[T]) Write(ctx context.Context, v T) error {
+	i := c.hash(v) % c.m
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.bitmap[i/64] |= 1 << (i % 64)
+	return nil
+}
+
+// Estimate returns the estimated number of distinct values written so far.
+// If the bitmap is saturated, the estimate is capped at m*ln(m), which means
+// that the DistinctCounter was too small for the stream.
+func (c *DistinctCounter[T]) Estimate() float64 {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	set := 0
+	for _, word := range c.bitmap {
+		set += bits.OnesCount64(word)
+	}
+
+	m := float64(c.m)
+	zero := m - float64(set)
+	if zero <= 0 {
+		return m * math.Log(m)
+	}
+
+	return -m * math.Log(zero/m)
+}
+
+// -----------------------------------------------------------------------------
+// Quantiles.
+// -----------------------------------------------------------------------------
+
+// QuantileSketch estimates quantiles of a stream of numbers with fixed memory
+// usage. It is a log-bucketed histogram (similar to DDSketch), so estimates
+// have a bounded relative error, and two sketches with the same accuracy can
+// be merged. Create it with NewQuantileSketch. It is safe for concurrent use.
+type QuantileSketch struct {
+	mx         sync.Mutex
+	accuracy   float64
+	logGamma   float64
+	maxBuckets int
+	pos        map[int]uint64
+	neg        map[int]uint64
+	zero       uint64
+	count      uint64
+}
+
+// QuantileSnapshot holds commonly used quantiles of a QuantileSketch.
+type QuantileSnapshot struct {
+	Count uint64
+	P50   float64
+	P95   float64
+	P99   float64
+}
+
+// NewQuantileSketch returns a QuantileSketch where estimates are within the
+// given relative 'accuracy' (e.g 0.01 for 1%, defaults to that if not within
+// (0, 1)). Memory is bounded by 'maxBuckets' (<= 0 defaults to 2048, and it
+// is at least 2), after which the lowest buckets are collapsed, sacrificing
+// accuracy of the lowest quantiles first.
+//
+// Example:
+//
+//	s := NewQuantileSketch(0.01, 0)
+//	r := NewReaderWithQuantiles(requests)(s, func(v Request) float64 {
+//		return v.Latency.Seconds()
+//	})
+//	...
+//	t.Log(s.Snapshot()) // {Count: ..., P50: ..., P95: ..., P99: ...}
+func NewQuantileSketch(accuracy float64, maxBuckets int) *QuantileSketch {
+	if accuracy <= 0 || accuracy >= 1 {
+		accuracy = 0.01
+	}
+	if maxBuckets <= 0 {
+		maxBuckets = 2048
+	}
+
+	// Such that collapse always has two buckets on one side to merge.
+	maxBuckets = max(maxBuckets, 2)
+
+	return &QuantileSketch{
+		accuracy:   accuracy,
+		logGamma:   math.Log((1 + accuracy) / (1 - accuracy)),
+		maxBuckets: maxBuckets,
+		pos:        make(map[int]uint64),
+		neg:        make(map[int]uint64),
+	}
+}
+
+// Add records 'v' in the sketch. NaN values are ignored.
+func (s *QuantileSketch) Add(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.count++
+	switch {
+	case v > 0:
+		s.pos[s.index(v)]++
+	case v < 0:
+		s.neg[s.index(-v)]++
+	default:
+		s.zero++
+	}
+
+	s.collapse()
+}
+
+// Merge adds all values recorded by 'o' into 's'. The sketches must have
+// been created with the same accuracy, otherwise 's' is left unchanged and
+// false is returned.
+func (s *QuantileSketch) Merge(o *QuantileSketch) bool {
+	if o == nil || o == s {
+		return o != nil
+	}
+
+	// Copy 'o' first, so that the locks are never held at the same time.
+	o.mx.Lock()
+	accuracy, zero, count := o.accuracy, o.zero, o.count
+	pos := make(map[int]uint64, len(o.pos))
+	neg := make(map[int]uint64, len(o.neg))
+	for i, n := range o.pos {
+		pos[i] = n
+	}
+	for i, n := range o.neg {
+		neg[i] = n
+	}
+	o.mx.Unlock()
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if s.accuracy != accuracy {
+		return false
+	}
+
+	for i, n := range pos {
+		s.pos[i] += n
+	}
+	for i, n := range neg {
+		s.neg[i] += n
+	}
+
+	s.zero += zero
+	s.count += count
+	s.collapse()
+	return true
+}
+
+// Count returns the number of values recorded in the sketch.
+func (s *QuantileSketch) Count() uint64 {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return s.count
+}
+
+// Quantile returns the estimated value at quantile 'q' (in [0, 1], clamped).
+// NaN is returned if the sketch is empty.
+func (s *QuantileSketch) Quantile(q float64) float64 {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return s.quantile(q)
+}
+
+// Snapshot returns the count and P50/P95/P99 of the sketch.
+func (s *QuantileSketch) Snapshot() QuantileSnapshot {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return QuantileSnapshot{
+		Count: s.count,
+		P50:   s.quantile(0.50),
+		P95:   s.quantile(0.95),
+		P99:   s.quantile(0.99),
+	}
+}
+
+func (s *QuantileSketch) index(v float64) int {
+	return int(math.Ceil(math.Log(v) / s.logGamma))
+}
+
+func (s *QuantileSketch) value(i int) float64 {
+	gamma := math.Exp(s.logGamma)
+	return 2 * math.Pow(gamma, float64(i)) / (gamma + 1)
+}
+
+// collapse merges the lowest buckets until the sketch is within maxBuckets.
+// Negative buckets are the lowest values, ordered by descending index.
+func (s *QuantileSketch) collapse() {
+	for len(s.pos)+len(s.neg) > s.maxBuckets {
+		if len(s.neg) >= 2 {
+			hi, next := extremeKeys(s.neg, func(a, b int) bool { return a > b })
+			s.neg[next] += s.neg[hi]
+			delete(s.neg, hi)
+			continue
+		}
+
+		lo, next := extremeKeys(s.pos, func(a, b int) bool { return a < b })
+		s.pos[next] += s.pos[lo]
+		delete(s.pos, lo)
+	}
+}
+
+// extremeKeys returns the first and second key of 'm' (which must have at
+// least two) when ordered by 'before', without sorting all of them.
+func extremeKeys(m map[int]uint64, before func(a, b int) bool) (first, second int) {
+	n := 0
+	for k := range m {
+		switch {
+		case n == 0:
+			first = k
+		case before(k, first):
+			first, second = k, first
+		case n == 1 || before(k, second):
+			second = k
+		}
+
+		n++
+	}
+
+	return first, second
+}
+
+func (s *QuantileSketch) quantile(q float64) float64 {
+	if s.count == 0 {
+		return math.NaN()
+	}
+
+	q = min(max(q, 0), 1)
+	rank := uint64(q * float64(s.count-1))
+
+	seen := uint64(0)
+	keys := sortedKeys(s.neg)
+	for i := len(keys) - 1; i >= 0; i-- {
+		if seen += s.neg[keys[i]]; seen > rank {
+			return -s.value(keys[i])
+		}
+	}
+
+	if seen += s.zero; seen > rank {
+		return 0
+	}
+
+	keys = sortedKeys(s.pos)
+	for _, k := range keys {
+		if seen += s.pos[k]; seen > rank {
+			return s.value(k)
+		}
+	}
+
+	return s.value(keys[len(keys)-1])
+}
+
+func sortedKeys(m map[int]uint64) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Ints(keys)
+	return keys
+}
+
+// NewReaderWithQuantiles returns a reader which passes values from 'r' through
+// unchanged, while recording the numeric projection 'f' of each value into
+// the QuantileSketch 's'. The sketch may be inspected at any time, e.g with
+// QuantileSketch.Snapshot. Nil 'r' returns an empty non-nil Reader; nil 's'
+// or 'f' returns 'r'.
+func NewReaderWithQuantiles[T any](r Reader[T]) func(s *QuantileSketch, f func(T) float64) Reader[T] {
+	return func(s *QuantileSketch, f func(T) float64) Reader[T] {
+		if r == nil {
+			return nilReader[T]()
+		}
+		if s == nil || f == nil {
+			return r
+		}
+
+		return ReaderImpl[T]{
+			Wraps: r,
+			Impl: func(ctx context.Context) (val T, err error) {
+				val, err = r.Read(ctx)
+				if err != nil {
+					return
+				}
+
+				s.Add(f(val))
+				return
+			},
+		}
+	}
+}
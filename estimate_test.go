@@ -0,0 +1,140 @@
+package iox
+
+import (
+	"math"
+	"testing"
+)
+
+// splitmix64 is a cheap, well distributed integer hash.
+func splitmix64(v int) uint64 {
+	x := uint64(v) + 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// -----------------------------------------------------------------------------
+// Distinct count.
+// -----------------------------------------------------------------------------
+
+func TestDistinctCounterIdeal(t *testing.T) {
+	c := NewDistinctCounter(splitmix64, 1<<14)
+
+	for i := 0; i < 20_000; i++ {
+		c.Write(nil, i%5_000)
+	}
+
+	have := c.Estimate()
+	assertEq("estimate", true, math.Abs(have-5_000) < 5_000*0.05, func(s string) { t.Fatal(s) })
+}
+
+func TestDistinctCounterWithNilHash(t *testing.T) {
+	c := NewDistinctCounter[string](nil, 0)
+
+	for _, v := range []string{"a", "b", "a", "c", "b"} {
+		assertEq("err", *new(error), c.Write(nil, v), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("estimate", 3, int(math.Round(c.Estimate())), func(s string) { t.Fatal(s) })
+}
+
+func TestDistinctCounterWithSaturation(t *testing.T) {
+	c := NewDistinctCounter(func(v int) uint64 { return uint64(v) }, 4)
+
+	for i := 0; i < 4; i++ {
+		c.Write(nil, i)
+	}
+
+	assertEq("estimate", 4*math.Log(4), c.Estimate(), func(s string) { t.Fatal(s) })
+}
+
+// -----------------------------------------------------------------------------
+// Quantiles.
+// -----------------------------------------------------------------------------
+
+func TestQuantileSketchIdeal(t *testing.T) {
+	s := NewQuantileSketch(0.01, 0)
+	for i := 1; i <= 1000; i++ {
+		s.Add(float64(i))
+	}
+
+	snap := s.Snapshot()
+	assertEq("count", uint64(1000), snap.Count, func(s string) { t.Fatal(s) })
+	assertEq("p50", true, math.Abs(snap.P50-500) <= 500*0.02, func(s string) { t.Fatal(s) })
+	assertEq("p95", true, math.Abs(snap.P95-950) <= 950*0.02, func(s string) { t.Fatal(s) })
+	assertEq("p99", true, math.Abs(snap.P99-990) <= 990*0.02, func(s string) { t.Fatal(s) })
+}
+
+func TestQuantileSketchWithNegativeAndZero(t *testing.T) {
+	s := NewQuantileSketch(0.01, 0)
+	for _, v := range []float64{-10, 0, 10} {
+		s.Add(v)
+	}
+
+	assertEq("min", true, math.Abs(s.Quantile(0)+10) <= 0.2, func(s string) { t.Fatal(s) })
+	assertEq("mid", 0.0, s.Quantile(0.5), func(s string) { t.Fatal(s) })
+	assertEq("max", true, math.Abs(s.Quantile(1)-10) <= 0.2, func(s string) { t.Fatal(s) })
+}
+
+func TestQuantileSketchWithEmpty(t *testing.T) {
+	s := NewQuantileSketch(0, 0)
+
+	assertEq("nan", true, math.IsNaN(s.Quantile(0.5)), func(s string) { t.Fatal(s) })
+}
+
+func TestQuantileSketchWithMaxBuckets(t *testing.T) {
+	s := NewQuantileSketch(0.01, 8)
+	for i := 1; i <= 1000; i++ {
+		s.Add(float64(i))
+	}
+
+	assertEq("buckets", 8, len(s.pos), func(s string) { t.Fatal(s) })
+	assertEq("p99", true, math.Abs(s.Quantile(0.99)-990) <= 990*0.02, func(s string) { t.Fatal(s) })
+}
+
+func TestQuantileSketchWithOneBucket(t *testing.T) {
+	s := NewQuantileSketch(0.01, 1)
+	s.Add(-1)
+	s.Add(1)
+	s.Add(2)
+
+	assertEq("buckets", 2, len(s.pos)+len(s.neg), func(s string) { t.Fatal(s) })
+	assertEq("count", uint64(3), s.Count(), func(s string) { t.Fatal(s) })
+	assertEq("min", true, math.Abs(s.Quantile(0)+1) <= 0.02, func(s string) { t.Fatal(s) })
+}
+
+func TestQuantileSketchMerge(t *testing.T) {
+	a := NewQuantileSketch(0.01, 0)
+	b := NewQuantileSketch(0.01, 0)
+	for i := 1; i <= 500; i++ {
+		a.Add(float64(i))
+		b.Add(float64(i + 500))
+	}
+
+	assertEq("ok", true, a.Merge(b), func(s string) { t.Fatal(s) })
+	assertEq("count", uint64(1000), a.Count(), func(s string) { t.Fatal(s) })
+	assertEq("p50", true, math.Abs(a.Quantile(0.5)-500) <= 500*0.02, func(s string) { t.Fatal(s) })
+
+	c := NewQuantileSketch(0.05, 0)
+	assertEq("ok", false, a.Merge(c), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithQuantilesIdeal(t *testing.T) {
+	s := NewQuantileSketch(0.01, 0)
+	r := NewReaderWithQuantiles(NewReaderFrom(1, 2, 3))(s, func(v int) float64 { return float64(v) })
+
+	for want := 1; want <= 3; want++ {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("count", uint64(3), s.Count(), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithQuantilesWithNilReader(t *testing.T) {
+	r := NewReaderWithQuantiles[int](nil)(NewQuantileSketch(0, 0), nil)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err != nil, func(s string) { t.Fatal(s) })
+}
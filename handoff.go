@@ -0,0 +1,171 @@
+package iox
+
+import (
+	"context"
+	"io"
+)
+
+// -----------------------------------------------------------------------------
+// PositionedReader iface + impl.
+// -----------------------------------------------------------------------------
+
+// PositionedReader is a Reader which knows its position in the underlying
+// source, e.g a partition offset or a file position. Position returns the
+// position right after the last value read, i.e where to resume from.
+type PositionedReader[T, P any] interface {
+	Reader[T]
+	Positioned[P]
+}
+
+// PositionedReaderImpl lets you implement PositionedReader with functions.
+// This is similar to ReaderImpl but lets you implement Position as well.
+type PositionedReaderImpl[T, P any] struct {
+	ImplR func(context.Context) (T, error)
+	ImplP func() P
+}
+
+// Read implements Reader by deferring to the internal "ImplR" func.
+// If the internal "ImplR" is not set, an io.EOF will be returned.
+func (impl PositionedReaderImpl[T, P]) Read(ctx context.Context) (r T, err error) {
+	if impl.ImplR == nil {
+		err = io.EOF
+		return
+	}
+
+	return impl.ImplR(orBackground(ctx))
+}
+
+// Position implements PositionedReader by deferring to the internal "ImplP"
+// func. If the internal "ImplP" is not set, the zero value of P is returned.
+func (impl PositionedReaderImpl[T, P]) Position() (p P) {
+	if impl.ImplP == nil {
+		return
+	}
+
+	return impl.ImplP()
+}
+
+// -----------------------------------------------------------------------------
+// CommitWriter iface + impl.
+// -----------------------------------------------------------------------------
+
+// CommitWriter is a Writer where written values only take effect once Commit
+// is called, e.g a transactional database sink.
+type CommitWriter[T any] interface {
+	Writer[T]
+	Commit(context.Context) error
+}
+
+// CommitWriterImpl lets you implement CommitWriter with functions. This is
+// similar to WriterImpl but lets you implement Commit as well.
+type CommitWriterImpl[T any] struct {
+	ImplW      func(context.Context, T) error
+	ImplCommit func(context.Context) error
+}
+
+// Write implements Writer by deferring to the internal "ImplW" func.
+// If the internal "ImplW" is not set, an io.ErrClosedPipe will be returned.
+func (impl CommitWriterImpl[T]) Write(ctx context.Context, v T) (err error) {
+	if impl.ImplW == nil {
+		err = io.ErrClosedPipe
+		return
+	}
+
+	return impl.ImplW(orBackground(ctx), v)
+}
+
+// Commit implements CommitWriter by deferring to the internal "ImplCommit"
+// func. If the internal "ImplCommit" is not set, nothing will happen.
+func (impl CommitWriterImpl[T]) Commit(ctx context.Context) (err error) {
+	if impl.ImplCommit == nil {
+		return
+	}
+
+	return impl.ImplCommit(orBackground(ctx))
+}
+
+// -----------------------------------------------------------------------------
+// Handoff.
+// -----------------------------------------------------------------------------
+
+// HandoffCfg is used to configure Handoff.
+type HandoffCfg[P any] struct {
+	// BatchSize is the number of values written between each commit,
+	// <= 0 defaults to 8.
+	BatchSize int
+	// Checkpoint stores the position of the reader, such that a restarted
+	// process may resume from it. It is only called after the writer commit
+	// succeeded. Nil means that positions are not stored.
+	Checkpoint func(context.Context, P) error
+}
+
+// Handoff moves values from 'r' into 'w' in batches, committing 'w' after
+// each batch and only then checkpointing the position of 'r'. As such, a
+// stored checkpoint never points past values which were not committed by the
+// sink, so no values are lost on failure. Handoff returns the number of
+// values committed, and a nil err once 'r' returns io.EOF (after committing
+// the final, possibly partial, batch). Nil 'r' is treated as an empty source
+// and nil 'w' as a closed sink, i.e io.ErrClosedPipe is returned.
+//
+// If the process fails between a commit and the checkpoint, the last batch
+// is delivered again on resume. Exactly-once delivery therefore requires that
+// the sink can either dedupe a batch, or that the checkpoint is stored in the
+// same transaction as the commit.
+//
+// Example:
+//
+//	n, err := Handoff(ctx, kafkaReader, dbWriter, HandoffCfg[int64]{
+//		BatchSize:  100,
+//		Checkpoint: func(ctx context.Context, offset int64) error {
+//			return store.Save(ctx, offset)
+//		},
+//	})
+func Handoff[T, P any](ctx context.Context, r PositionedReader[T, P], w CommitWriter[T], cfg HandoffCfg[P]) (n int, err error) {
+	if r == nil {
+		return 0, nil
+	}
+	if w == nil {
+		return 0, io.ErrClosedPipe
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 8
+	}
+
+	for {
+		i := 0
+		var v T
+		for ; i < cfg.BatchSize; i++ {
+			v, err = r.Read(ctx)
+			if err != nil {
+				break
+			}
+
+			if err = w.Write(ctx, v); err != nil {
+				return n, err
+			}
+		}
+
+		if err != nil && err != io.EOF {
+			return n, err
+		}
+
+		eof := err == io.EOF
+		if i > 0 {
+			if err = w.Commit(ctx); err != nil {
+				return n, err
+			}
+
+			n += i
+			if cfg.Checkpoint != nil {
+				if err = cfg.Checkpoint(ctx, r.Position()); err != nil {
+					return n, err
+				}
+			}
+		}
+
+		if eof {
+			return n, nil
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package iox
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// Test utils.
+// -----------------------------------------------------------------------------
+
+// newTestPositionedReader returns a PositionedReader over 'vs', with the
+// position being the index of the next value.
+func newTestPositionedReader(vs ...int) PositionedReader[int, int] {
+	i := 0
+	return PositionedReaderImpl[int, int]{
+		ImplR: func(ctx context.Context) (int, error) {
+			if i >= len(vs) {
+				return 0, io.EOF
+			}
+
+			i++
+			return vs[i-1], nil
+		},
+		ImplP: func() int { return i },
+	}
+}
+
+// newTestCommitWriter returns a CommitWriter which moves pending values into
+// 'committed' on commit.
+func newTestCommitWriter(committed *[]int, commitErr error) CommitWriter[int] {
+	pending := []int{}
+	return CommitWriterImpl[int]{
+		ImplW: func(ctx context.Context, v int) error {
+			pending = append(pending, v)
+			return nil
+		},
+		ImplCommit: func(ctx context.Context) error {
+			if commitErr != nil {
+				return commitErr
+			}
+
+			*committed = append(*committed, pending...)
+			pending = pending[:0]
+			return nil
+		},
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Impls.
+// -----------------------------------------------------------------------------
+
+func TestPositionedReaderImplWithNilImpl(t *testing.T) {
+	r := PositionedReaderImpl[int, int]{}
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("pos", 0, r.Position(), func(s string) { t.Fatal(s) })
+}
+
+func TestCommitWriterImplWithNilImpl(t *testing.T) {
+	w := CommitWriterImpl[int]{}
+
+	assertEq("err", true, w.Write(nil, 1) == io.ErrClosedPipe, func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), w.Commit(nil), func(s string) { t.Fatal(s) })
+}
+
+// -----------------------------------------------------------------------------
+// Handoff.
+// -----------------------------------------------------------------------------
+
+func TestHandoffIdeal(t *testing.T) {
+	committed := []int{}
+	checkpoints := []int{}
+
+	n, err := Handoff(
+		context.Background(),
+		newTestPositionedReader(1, 2, 3, 4, 5),
+		newTestCommitWriter(&committed, nil),
+		HandoffCfg[int]{
+			BatchSize: 2,
+			Checkpoint: func(ctx context.Context, pos int) error {
+				checkpoints = append(checkpoints, pos)
+				return nil
+			},
+		},
+	)
+
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("n", 5, n, func(s string) { t.Fatal(s) })
+	assertEq("committed", []int{1, 2, 3, 4, 5}, committed, func(s string) { t.Fatal(s) })
+	assertEq("checkpoints", []int{2, 4, 5}, checkpoints, func(s string) { t.Fatal(s) })
+}
+
+func TestHandoffWithCommitErr(t *testing.T) {
+	committed := []int{}
+	checkpoints := []int{}
+
+	n, err := Handoff(
+		context.Background(),
+		newTestPositionedReader(1, 2, 3),
+		newTestCommitWriter(&committed, io.ErrUnexpectedEOF),
+		HandoffCfg[int]{
+			BatchSize: 2,
+			Checkpoint: func(ctx context.Context, pos int) error {
+				checkpoints = append(checkpoints, pos)
+				return nil
+			},
+		},
+	)
+
+	assertEq("err", true, err == io.ErrUnexpectedEOF, func(s string) { t.Fatal(s) })
+	assertEq("n", 0, n, func(s string) { t.Fatal(s) })
+	assertEq("checkpoints", []int{}, checkpoints, func(s string) { t.Fatal(s) })
+}
+
+func TestHandoffWithNilReader(t *testing.T) {
+	n, err := Handoff[int, int](context.Background(), nil, CommitWriterImpl[int]{}, HandoffCfg[int]{})
+
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("n", 0, n, func(s string) { t.Fatal(s) })
+}
+
+func TestHandoffWithNilWriter(t *testing.T) {
+	n, err := Handoff(context.Background(), newTestPositionedReader(1), nil, HandoffCfg[int]{})
+
+	assertEq("err", true, err == io.ErrClosedPipe, func(s string) { t.Fatal(s) })
+	assertEq("n", 0, n, func(s string) { t.Fatal(s) })
+}
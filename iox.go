@@ -6,9 +6,190 @@
 //   - Defines interfaces for encoding and decoding.
 //   - Inherits errors, namely io.EOF and io.ErrClosedPipe
 //   - Defines converters for interoperability with io.
+//
+// A nil ctx may be given to any Read or Write of this package, and is treated
+// as context.Background(). The Impl structs (e.g ReaderImpl) pass
+// context.Background() to their funcs in place of a nil ctx, so those funcs
+// never see a nil ctx.
 package iox
 
-import "io"
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// Errors.
+// -----------------------------------------------------------------------------
+
+// ErrShortBatch is returned by batching readers in strict mode when the
+// underlying reader ended mid-batch, i.e the last batch was smaller than the
+// configured size. It wraps io.EOF, so errors.Is(ErrShortBatch, io.EOF) holds.
+var ErrShortBatch = fmt.Errorf("iox: short batch: %w", io.EOF)
+
+// ErrInvalidArg is returned (wrapped) by the "Checked" constructor variants,
+// e.g NewWriterWithMapperFnChecked, when they are given invalid arguments.
+var ErrInvalidArg = errors.New("iox: invalid argument")
+
+// ErrNilSource is returned by the Readers which constructors fall back to when
+// given a nil Reader (or another nil argument which makes the Reader useless),
+// if strict nil handling is enabled, see SetStrictNil. It wraps io.EOF, so
+// errors.Is(ErrNilSource, io.EOF) holds.
+var ErrNilSource = fmt.Errorf("iox: nil source: %w", io.EOF)
+
+// ErrNilSink is the Writer analog of ErrNilSource. It wraps io.ErrClosedPipe,
+// so errors.Is(ErrNilSink, io.ErrClosedPipe) holds.
+var ErrNilSink = fmt.Errorf("iox: nil sink: %w", io.ErrClosedPipe)
+
+// ErrSequenceGap is returned (wrapped) by NewReaderWithSequenceCheck when the
+// sequence numbers of the values it reads are not continuous, e.g because a
+// transport lost a batch.
+var ErrSequenceGap = errors.New("iox: sequence gap")
+
+// ErrContract is returned (wrapped) by NewReaderWithAssertFn when a value
+// violates the given check.
+var ErrContract = errors.New("iox: contract violation")
+
+// -----------------------------------------------------------------------------
+// Strict nil handling.
+// -----------------------------------------------------------------------------
+
+var strictNil atomic.Bool
+
+// SetStrictNil enables or disables strict nil handling. Constructors which are
+// given a nil Reader or Writer (or another nil argument which makes them
+// useless) fall back to an empty Reader (io.EOF) or Writer (io.ErrClosedPipe),
+// which is indistinguishable from genuine exhaustion. With strict nil handling
+// enabled, these fallbacks return ErrNilSource and ErrNilSink instead, which
+// makes misconfigured pipelines easy to spot. Both wrap the io errors, so code
+// which checks with errors.Is keeps working. It is disabled by default, and
+// may be toggled at any time, e.g in tests or during development.
+//
+// Example:
+//
+//	iox.SetStrictNil(true)
+//
+//	var src iox.Reader[int] // Forgot to set this.
+//	r := iox.NewReaderWithFilterFn(src)(isEven)
+//	t.Log(r.Read(nil)) // 0, iox: nil source: EOF
+func SetStrictNil(enabled bool) {
+	strictNil.Store(enabled)
+}
+
+// nilReader returns the Reader which constructors fall back to when given a
+// nil argument, see SetStrictNil.
+func nilReader[T any]() Reader[T] {
+	return ReaderImpl[T]{
+		Impl: func(ctx context.Context) (val T, err error) {
+			if strictNil.Load() {
+				return val, ErrNilSource
+			}
+
+			return val, io.EOF
+		},
+	}
+}
+
+// nilReadCloser is the ReadCloser variant of nilReader.
+func nilReadCloser[T any]() ReadCloser[T] {
+	return ReadCloserImpl[T]{ImplR: nilReader[T]().Read}
+}
+
+// nilWriter returns the Writer which constructors fall back to when given a
+// nil argument, see SetStrictNil.
+func nilWriter[T any]() Writer[T] {
+	return WriterImpl[T]{
+		Impl: func(ctx context.Context, v T) error {
+			if strictNil.Load() {
+				return ErrNilSink
+			}
+
+			return io.ErrClosedPipe
+		},
+	}
+}
+
+// nilWriteCloser is the WriteCloser variant of nilWriter.
+func nilWriteCloser[T any]() WriteCloser[T] {
+	return WriteCloserImpl[T]{ImplW: nilWriter[T]().Write}
+}
+
+// orBackground returns 'ctx', or context.Background() if 'ctx' is nil.
+func orBackground(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+
+	return ctx
+}
+
+// ctxErr returns the err of 'ctx', i.e non-nil if it is done. Nil 'ctx' is
+// never done. Sources and sinks of this package check it on every call, such
+// that cancellation stops pipelines built purely from iox pieces.
+func ctxErr(ctx context.Context) error {
+	if ctx == nil {
+		return nil
+	}
+
+	return ctx.Err()
+}
+
+// -----------------------------------------------------------------------------
+// Size hinting.
+// -----------------------------------------------------------------------------
+
+// lenHint returns the size hint of 'v' if it implements Lener (see AsLener),
+// and 'def' if it does not (or if the hint is unknown). The result is capped
+// at 'max' if 'max' is positive.
+func lenHint(v any, def, max int) int {
+	n := def
+	if l, ok := AsLener(v); ok && l.Len() >= 0 {
+		n = l.Len()
+	}
+
+	if max > 0 && n > max {
+		n = max
+	}
+
+	return n
+}
+
+// -----------------------------------------------------------------------------
+// Common value types.
+// -----------------------------------------------------------------------------
+
+// Timestamped pairs a value with a point in time, e.g when it was ingested.
+type Timestamped[T any] struct {
+	Value T
+	Time  time.Time
+}
+
+// Enriched pairs a value with the result of looking it up in some external
+// system. Found is false if the lookup had no result for the value.
+type Enriched[T, U any] struct {
+	Value  T
+	Lookup U
+	Found  bool
+}
+
+// Sequenced pairs a value with its sequence number, see e.g
+// NewWriterWithSequence.
+type Sequenced[T any] struct {
+	Seq   uint64
+	Value T
+}
+
+// Counted pairs a value with the amount of consecutive times it occurred, see
+// e.g NewReaderWithRunLength.
+type Counted[T any] struct {
+	Value T
+	N     int
+}
 
 // -----------------------------------------------------------------------------
 // Encoder.
@@ -88,10 +269,15 @@ func (impl DecoderImpl) Decode(d any) error {
 // Implementation io.Reader, io.Writer, io.ReadWriter and closer variants.
 // -----------------------------------------------------------------------------
 
+// readWriteCloserImpl is used for the io bridges in this package. ImplWS and
+// ImplRF are optional fast paths for io.StringWriter and io.ReaderFrom, which
+// fall back to ImplW if not set.
 type readWriteCloserImpl struct {
-	ImplC func() error
-	ImplR func([]byte) (int, error)
-	ImplW func([]byte) (int, error)
+	ImplC  func() error
+	ImplR  func([]byte) (int, error)
+	ImplW  func([]byte) (int, error)
+	ImplWS func(string) (int, error)
+	ImplRF func(io.Reader) (int64, error)
 }
 
 func (impl readWriteCloserImpl) Close() (err error) {
@@ -120,6 +306,50 @@ func (impl readWriteCloserImpl) Write(p []byte) (n int, err error) {
 	return impl.ImplW(p)
 }
 
+func (impl readWriteCloserImpl) WriteString(s string) (n int, err error) {
+	if impl.ImplWS == nil {
+		return impl.Write([]byte(s))
+	}
+
+	return impl.ImplWS(s)
+}
+
+func (impl readWriteCloserImpl) ReadFrom(r io.Reader) (n int64, err error) {
+	if impl.ImplRF == nil {
+		// Hides this method from io.Copy, which would otherwise recurse.
+		return io.Copy(struct{ io.Writer }{impl}, r)
+	}
+
+	return impl.ImplRF(r)
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (n int, err error) {
+	n, err = cr.r.Read(p)
+	cr.n += int64(n)
+	return
+}
+
+// fallbackReader reads from 'b' while it has data, and from 'r' otherwise,
+// such that a decoder over it can be fed from a stream after its buffer.
+type fallbackReader struct {
+	b *bytes.Buffer
+	r io.Reader
+}
+
+func (fr *fallbackReader) Read(p []byte) (n int, err error) {
+	if fr.b.Len() > 0 || fr.r == nil {
+		return fr.b.Read(p)
+	}
+
+	return fr.r.Read(p)
+}
+
 // -----------------------------------------------------------------------------
 // Abbreviations.
 // -----------------------------------------------------------------------------
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/gob"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"testing"
@@ -28,6 +29,55 @@ func assertEq[T any](subject string, a T, b T, f func(string)) {
 	f(fmt.Sprintf(s, subject, as, bs))
 }
 
+// -----------------------------------------------------------------------------
+// Strict nil handling.
+// -----------------------------------------------------------------------------
+
+func TestSetStrictNilIdeal(t *testing.T) {
+	SetStrictNil(true)
+	defer SetStrictNil(false)
+
+	r := NewReaderWithFilterFn[int](nil)(nil)
+	_, err := r.Read(nil)
+	assertEq("err", true, err == ErrNilSource, func(s string) { t.Fatal(s) })
+	assertEq("is", true, errors.Is(err, io.EOF), func(s string) { t.Fatal(s) })
+
+	w := NewWriterWithMapperFn[int, int](nil)(nil)
+	err = w.Write(nil, 1)
+	assertEq("err", true, err == ErrNilSink, func(s string) { t.Fatal(s) })
+	assertEq("is", true, errors.Is(err, io.ErrClosedPipe), func(s string) { t.Fatal(s) })
+
+	// Empty on purpose.
+	_, err = NewReaderFromEmpty[int]().Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestSetStrictNilWithDisabled(t *testing.T) {
+	_, err := NewReaderWithFilterFn[int](nil)(nil).Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+
+	err = NewWriterWithMapperFn[int, int](nil)(nil).Write(nil, 1)
+	assertEq("err", true, err == io.ErrClosedPipe, func(s string) { t.Fatal(s) })
+}
+
+// -----------------------------------------------------------------------------
+// Size hinting.
+// -----------------------------------------------------------------------------
+
+func TestLenHintIdeal(t *testing.T) {
+	r := NewReaderFrom(1, 2, 3)
+
+	assertEq("hint", 3, lenHint(r, 8, 0), func(s string) { t.Fatal(s) })
+	assertEq("hint", 2, lenHint(r, 8, 2), func(s string) { t.Fatal(s) })
+}
+
+func TestLenHintWithoutLener(t *testing.T) {
+	r := ReaderImpl[int]{}
+
+	assertEq("hint", 8, lenHint(r, 8, 0), func(s string) { t.Fatal(s) })
+	assertEq("hint", 4, lenHint(r, 8, 4), func(s string) { t.Fatal(s) })
+}
+
 // -----------------------------------------------------------------------------
 // Encoder.
 // -----------------------------------------------------------------------------
@@ -0,0 +1,24 @@
+package ioxtest
+
+import "testing"
+
+// AssertMaxAllocs fails 'tb' if 'f' allocates more than 'max' times on
+// average per call, as measured by testing.AllocsPerRun over 'runs' calls
+// (<= 0 defaults to 100). It is intended as a guard against regressions in
+// hot paths, e.g that a Read through a chain of modifiers does not allocate.
+//
+// Example:
+//
+//	r := iox.NewReaderWithMapperFn[int, int](src)(func(v int) int { return v })
+//	ioxtest.AssertMaxAllocs(t, 0, 0, func() { r.Read(ctx) })
+func AssertMaxAllocs(tb testing.TB, max float64, runs int, f func()) {
+	tb.Helper()
+
+	if runs <= 0 {
+		runs = 100
+	}
+
+	if n := testing.AllocsPerRun(runs, f); n > max {
+		tb.Fatalf("ioxtest: too many allocs per run: want <= %v, have %v", max, n)
+	}
+}
@@ -0,0 +1,20 @@
+package ioxtest
+
+import "testing"
+
+func TestAssertMaxAllocsIdeal(t *testing.T) {
+	AssertMaxAllocs(t, 0, 0, func() {})
+}
+
+func TestAssertMaxAllocsWithTooMany(t *testing.T) {
+	var sink []int
+
+	tb := &fakeTB{}
+	func() {
+		defer func() { recover() }()
+		AssertMaxAllocs(tb, 0, 10, func() { sink = make([]int, 8) })
+	}()
+
+	_ = sink
+	assertEq("failed", true, tb.failed, func(s string) { t.Fatal(s) })
+}
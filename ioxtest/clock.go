@@ -0,0 +1,126 @@
+package ioxtest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/crunchypi/iox"
+)
+
+// Clock is a fake iox.Clock which only moves when told to, through Advance or
+// Set. Place it into a ctx with Clock.Context (or iox.WithClock) and pass that
+// ctx to Read/Write to control time-based iox components. It is safe for
+// concurrent use.
+//
+// Example:
+//
+//	clock := ioxtest.NewClock(time.Unix(0, 0))
+//	ctx := clock.Context(context.Background())
+//
+//	r := iox.NewReaderWithTTL(iox.NewReaderWithTimestamps(src), time.Second)
+//	...
+//	clock.Advance(time.Second * 5) // Anything read before this is now stale.
+type Clock struct {
+	mx      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []clockWaiter
+}
+
+type clockWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewClock returns a Clock which starts at the given time.
+func NewClock(now time.Time) *Clock {
+	c := &Clock{now: now}
+	c.cond = sync.NewCond(&c.mx)
+	return c
+}
+
+// Context returns a copy of 'ctx' which carries the Clock, see iox.WithClock.
+func (c *Clock) Context(ctx context.Context) context.Context {
+	return iox.WithClock(ctx, c)
+}
+
+// Now implements iox.Clock by returning the current fake time.
+func (c *Clock) Now() time.Time {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	return c.now
+}
+
+// After implements iox.Clock. The returned chan receives the fake time once
+// the Clock has been moved at least 'd' past the current time. A 'd' <= 0
+// fires immediately.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+
+	c.waiters = append(c.waiters, clockWaiter{at: c.now.Add(d), ch: ch})
+	c.cond.Broadcast()
+	return ch
+}
+
+// Advance moves the Clock forward by 'd', firing all chans created by After
+// which are due.
+func (c *Clock) Advance(d time.Duration) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.set(c.now.Add(d))
+}
+
+// Set moves the Clock to 't', firing all chans created by After which are due.
+func (c *Clock) Set(t time.Time) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.set(t)
+}
+
+func (c *Clock) set(t time.Time) {
+	c.now = t
+
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(t) {
+			waiters = append(waiters, w)
+			continue
+		}
+
+		w.ch <- t
+	}
+
+	c.waiters = waiters
+	c.cond.Broadcast()
+}
+
+// Waiters returns the number of pending chans created by After.
+func (c *Clock) Waiters() int {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	return len(c.waiters)
+}
+
+// BlockUntil blocks until there are at least 'n' pending chans created by
+// After. This is useful for synchronising with goroutines which wait for the
+// Clock, before calling Advance.
+func (c *Clock) BlockUntil(n int) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
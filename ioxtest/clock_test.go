@@ -0,0 +1,100 @@
+package ioxtest
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/crunchypi/iox"
+)
+
+func TestClockAdvanceIdeal(t *testing.T) {
+	start := time.Unix(100, 0)
+	c := NewClock(start)
+
+	ch := c.After(time.Second)
+	assertEq("waiters", 1, c.Waiters(), func(s string) { t.Fatal(s) })
+
+	c.Advance(time.Millisecond * 999)
+	assertEq("fired", 0, len(ch), func(s string) { t.Fatal(s) })
+
+	c.Advance(time.Millisecond)
+	assertEq("fired", 1, len(ch), func(s string) { t.Fatal(s) })
+	assertEq("time", start.Add(time.Second), <-ch, func(s string) { t.Fatal(s) })
+	assertEq("waiters", 0, c.Waiters(), func(s string) { t.Fatal(s) })
+}
+
+func TestClockSetIdeal(t *testing.T) {
+	start := time.Unix(100, 0)
+	c := NewClock(start)
+
+	ch := c.After(time.Second)
+	c.Set(start.Add(time.Hour))
+
+	assertEq("fired", 1, len(ch), func(s string) { t.Fatal(s) })
+	assertEq("now", start.Add(time.Hour), c.Now(), func(s string) { t.Fatal(s) })
+}
+
+func TestClockAfterWithNonPositiveDuration(t *testing.T) {
+	c := NewClock(time.Unix(100, 0))
+
+	assertEq("fired", 1, len(c.After(0)), func(s string) { t.Fatal(s) })
+}
+
+func TestClockBlockUntil(t *testing.T) {
+	c := NewClock(time.Unix(100, 0))
+	done := make(chan struct{})
+
+	go func() {
+		<-c.After(time.Second)
+		close(done)
+	}()
+
+	c.BlockUntil(1)
+	c.Advance(time.Second)
+	<-done
+}
+
+func TestClockWithTTLReader(t *testing.T) {
+	c := NewClock(time.Unix(100, 0))
+	ctx := c.Context(context.Background())
+
+	tr := iox.NewReaderWithTimestamps(iox.NewReaderFrom(1, 2))
+	v1, _ := tr.Read(ctx)
+	c.Advance(time.Second * 5)
+	v2, _ := tr.Read(ctx)
+
+	r := iox.NewReaderWithTTL(iox.NewReaderFrom(v1, v2), time.Second)
+
+	val, err := r.Read(ctx)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 2, val, func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(ctx)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestClockWithCachedReaderFactory(t *testing.T) {
+	c := NewClock(time.Unix(100, 0))
+	ctx := c.Context(context.Background())
+
+	opened := 0
+	newReader := iox.NewCachedReaderFactoryWithTTL(
+		func(ctx context.Context) (iox.Reader[int], error) {
+			opened++
+			return iox.NewReaderFrom(opened), nil
+		},
+		time.Minute,
+	)
+
+	newReader().Read(ctx)
+	c.Advance(time.Second * 59)
+	newReader().Read(ctx)
+	assertEq("opened", 1, opened, func(s string) { t.Fatal(s) })
+
+	c.Advance(time.Second)
+	val, _ := newReader().Read(ctx)
+	assertEq("opened", 2, opened, func(s string) { t.Fatal(s) })
+	assertEq("val", 2, val, func(s string) { t.Fatal(s) })
+}
@@ -0,0 +1,133 @@
+package ioxtest
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/crunchypi/iox"
+)
+
+// NewReaderFromGen returns a Reader of 'n' synthetic values, where the i-th
+// value is given by gen(i), e.g as the source of a DryRun. Nil 'gen' or 'n'
+// <= 0 returns an empty Reader.
+//
+// Example:
+//
+//	r := ioxtest.NewReaderFromGen(3, func(i int) string { return fmt.Sprint("user-", i) })
+//
+//	t.Log(r.Read(nil)) // user-0, nil
+//	t.Log(r.Read(nil)) // user-1, nil
+//	t.Log(r.Read(nil)) // user-2, nil
+//	t.Log(r.Read(nil)) // "", io.EOF
+func NewReaderFromGen[T any](n int, gen func(i int) T) iox.Reader[T] {
+	if gen == nil {
+		return iox.NewReaderFromEmpty[T]()
+	}
+
+	i := 0
+	return iox.ReaderImpl[T]{
+		Impl: func(ctx context.Context) (val T, err error) {
+			if i >= n {
+				return val, io.EOF
+			}
+
+			val = gen(i)
+			i++
+			return val, nil
+		},
+	}
+}
+
+// DryRunReport is the result of a DryRun.
+type DryRunReport struct {
+	// In is the amount of values read from the source.
+	In int64
+	// Out is the amount of values written into the sink.
+	Out int64
+	// Elapsed is how long the pipeline ran, according to the Clock in the
+	// ctx given to DryRun, see iox.ClockFrom.
+	Elapsed time.Duration
+}
+
+// Throughput returns the amount of source values processed per second, or 0
+// if no time elapsed.
+func (r DryRunReport) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+
+	return float64(r.In) / r.Elapsed.Seconds()
+}
+
+// DryRun runs a pipeline with 'src' (e.g from NewReaderFromGen) as its source
+// and a counting discard (see iox.NewWriterDiscardCounting) as its sink, such
+// that all intermediate stages are executed without touching real systems.
+// This validates the wiring of a pipeline, and measures the throughput of its
+// transforms. The pipeline is given by 'pipeline', which wires the source and
+// the sink together and returns the Runnable that moves values between them,
+// i.e the same func which is given the real source and sink in production.
+// io.EOF and io.ErrClosedPipe from the Runnable are treated as a clean stop,
+// like in iox.Group. Nil 'src' is treated as an empty source; nil 'pipeline'
+// (or a nil Runnable) runs nothing.
+//
+// Example:
+//
+//	build := func(r iox.Reader[Event], w iox.Writer[Row]) iox.Runnable {
+//		rows := iox.NewReaderWithMapperFn[Event, Row](r)(toRow)
+//		return iox.RunnableImpl{Impl: func(ctx context.Context) error {
+//			_, err := iox.Copy(ctx, w, rows)
+//			return err
+//		}}
+//	}
+//
+//	src := ioxtest.NewReaderFromGen(100_000, fakeEvent)
+//	report, err := ioxtest.DryRun(ctx, src, build)
+//	t.Logf("%d in, %d out, %.0f/s", report.In, report.Out, report.Throughput())
+func DryRun[In, Out any](
+	ctx context.Context,
+	src iox.Reader[In],
+	pipeline func(r iox.Reader[In], w iox.Writer[Out]) iox.Runnable,
+) (report DryRunReport, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if src == nil {
+		src = iox.NewReaderFromEmpty[In]()
+	}
+	if pipeline == nil {
+		return report, nil
+	}
+
+	var in int64
+	counted := iox.ReaderImpl[In]{
+		Impl: func(ctx context.Context) (val In, err error) {
+			val, err = src.Read(ctx)
+			if err == nil {
+				atomic.AddInt64(&in, 1)
+			}
+
+			return val, err
+		},
+	}
+
+	sink, out := iox.NewWriterDiscardCounting[Out]()
+	r := pipeline(counted, sink)
+	if r == nil {
+		return report, nil
+	}
+
+	clock := iox.ClockFrom(ctx)
+	start := clock.Now()
+	err = r.Run(ctx)
+	report.Elapsed = clock.Now().Sub(start)
+	report.In = atomic.LoadInt64(&in)
+	report.Out = atomic.LoadInt64(out)
+
+	if err == io.EOF || err == io.ErrClosedPipe {
+		err = nil
+	}
+
+	return report, err
+}
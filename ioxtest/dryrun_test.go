@@ -0,0 +1,67 @@
+package ioxtest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/crunchypi/iox"
+)
+
+// copyPipeline wires 'r' to 'w' through a filter of even values.
+func copyPipeline(r iox.Reader[int], w iox.Writer[int]) iox.Runnable {
+	even := iox.NewReaderWithFilterFn(r)(func(v int) bool { return v%2 == 0 })
+	return iox.RunnableImpl{
+		Impl: func(ctx context.Context) error {
+			for {
+				v, err := even.Read(ctx)
+				if err != nil {
+					return err
+				}
+				if err := w.Write(ctx, v); err != nil {
+					return err
+				}
+			}
+		},
+	}
+}
+
+func TestNewReaderFromGenIdeal(t *testing.T) {
+	r := NewReaderFromGen(3, func(i int) int { return i * 10 })
+
+	for _, want := range []int{0, 10, 20} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestDryRunIdeal(t *testing.T) {
+	clock := NewClock(time.Unix(0, 0))
+	src := NewReaderFromGen(10, func(i int) int {
+		clock.Advance(time.Millisecond * 100)
+		return i
+	})
+
+	report, err := DryRun(clock.Context(context.Background()), src, copyPipeline)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("in", int64(10), report.In, func(s string) { t.Fatal(s) })
+	assertEq("out", int64(5), report.Out, func(s string) { t.Fatal(s) })
+	assertEq("elapsed", time.Second, report.Elapsed, func(s string) { t.Fatal(s) })
+	assertEq("throughput", 10.0, report.Throughput(), func(s string) { t.Fatal(s) })
+}
+
+func TestDryRunWithErr(t *testing.T) {
+	errBoom := errors.New("boom")
+	pipeline := func(r iox.Reader[int], w iox.Writer[int]) iox.Runnable {
+		return iox.RunnableImpl{Impl: func(ctx context.Context) error { return errBoom }}
+	}
+
+	_, err := DryRun(nil, NewReaderFromGen(1, func(i int) int { return i }), pipeline)
+	assertEq("err", true, err == errBoom, func(s string) { t.Fatal(s) })
+}
@@ -0,0 +1,119 @@
+package ioxtest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crunchypi/iox"
+)
+
+// GoldenCfg is used to configure AssertGolden.
+type GoldenCfg struct {
+	// Update makes AssertGolden (re)write the golden file with the stream
+	// instead of comparing against it. It is intended to be wired to a test
+	// flag, e.g:
+	//
+	//	var update = flag.Bool("update", false, "update golden files")
+	//	...
+	//	ioxtest.AssertGolden(t, ctx, path, r, ioxtest.GoldenCfg{Update: *update})
+	Update bool
+	// Encoder creates the Encoder used for values in the golden file. Nil
+	// uses json.NewEncoder, which gives one value per line.
+	Encoder func(io.Writer) iox.Encoder
+}
+
+// RecordGolden drains 'r' and writes all values, encoded with the Encoder
+// created by 'f', into the file at 'path'. Parent directories are created
+// as needed. Nil 'f' uses json.NewEncoder.
+func RecordGolden[T any](ctx context.Context, path string, r iox.Reader[T], f func(io.Writer) iox.Encoder) error {
+	b, err := encodeStream(ctx, r, f)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, b, 0o644)
+}
+
+// AssertGolden drains 'r' and fails 'tb' if the encoded stream differs from
+// the golden file at 'path', which is typically created with RecordGolden or
+// by running with GoldenCfg.Update.
+//
+// Example:
+//
+//	func TestPipeline(t *testing.T) {
+//		r := buildPipeline(iox.NewReaderFrom(inputs...))
+//		ioxtest.AssertGolden(t, ctx, "testdata/pipeline.golden", r, ioxtest.GoldenCfg{})
+//	}
+func AssertGolden[T any](tb testing.TB, ctx context.Context, path string, r iox.Reader[T], cfg GoldenCfg) {
+	tb.Helper()
+
+	if cfg.Update {
+		if err := RecordGolden(ctx, path, r, cfg.Encoder); err != nil {
+			tb.Fatalf("ioxtest: recording golden file %q: %v", path, err)
+		}
+
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("ioxtest: reading golden file %q: %v", path, err)
+	}
+
+	have, err := encodeStream(ctx, r, cfg.Encoder)
+	if err != nil {
+		tb.Fatalf("ioxtest: encoding stream: %v", err)
+	}
+
+	if bytes.Equal(want, have) {
+		return
+	}
+
+	i := 0
+	for i < len(want) && i < len(have) && want[i] == have[i] {
+		i++
+	}
+
+	tb.Fatalf(
+		"ioxtest: stream differs from golden file %q at byte %d:\n\twant: %q\n\thave: %q\n",
+		path, i, snippet(want, i), snippet(have, i),
+	)
+}
+
+// encodeStream drains 'r' into a buffer through NewWriterFromValues.
+func encodeStream[T any](ctx context.Context, r iox.Reader[T], f func(io.Writer) iox.Encoder) ([]byte, error) {
+	b := bytes.NewBuffer(nil)
+	w := iox.NewWriterFromValues[T](b)(f)
+
+	if r == nil {
+		return b.Bytes(), nil
+	}
+
+	for {
+		v, err := r.Read(ctx)
+		if err == io.EOF {
+			return b.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := w.Write(ctx, v); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// snippet returns up to 32 bytes of 'b' starting at 'i'.
+func snippet(b []byte, i int) string {
+	return string(b[i:min(i+32, len(b))])
+}
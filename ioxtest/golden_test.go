@@ -0,0 +1,63 @@
+package ioxtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crunchypi/iox"
+)
+
+func TestGoldenIdeal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "stream.golden")
+	ctx := context.Background()
+
+	err := RecordGolden(ctx, path, iox.NewReaderFrom(1, 2), nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+
+	b, _ := os.ReadFile(path)
+	assertEq("file", "1\n2\n", string(b), func(s string) { t.Fatal(s) })
+
+	AssertGolden(t, ctx, path, iox.NewReaderFrom(1, 2), GoldenCfg{})
+}
+
+func TestGoldenWithUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stream.golden")
+	ctx := context.Background()
+
+	AssertGolden(t, ctx, path, iox.NewReaderFrom("a"), GoldenCfg{Update: true})
+	AssertGolden(t, ctx, path, iox.NewReaderFrom("a"), GoldenCfg{})
+}
+
+func TestGoldenWithMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stream.golden")
+	ctx := context.Background()
+	RecordGolden(ctx, path, iox.NewReaderFrom(1, 2), nil)
+
+	tb := &fakeTB{}
+	func() {
+		defer func() { recover() }()
+		AssertGolden(tb, ctx, path, iox.NewReaderFrom(1, 3), GoldenCfg{})
+	}()
+
+	assertEq("failed", true, tb.failed, func(s string) { t.Fatal(s) })
+	assertEq("msg", true, strings.Contains(tb.msg, "at byte 2"), func(s string) { t.Fatal(s) })
+}
+
+// fakeTB records a Fatalf call, then panics to stop the calling func.
+type fakeTB struct {
+	testing.TB
+	failed bool
+	msg    string
+}
+
+func (tb *fakeTB) Helper() {}
+
+func (tb *fakeTB) Fatalf(format string, args ...any) {
+	tb.failed = true
+	tb.msg = fmt.Sprintf(format, args...)
+	panic(tb)
+}
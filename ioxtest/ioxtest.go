@@ -0,0 +1,3 @@
+// ioxtest provides utilities for testing code built with package iox, such
+// as a fake Clock for deterministic tests of time-based behavior.
+package ioxtest
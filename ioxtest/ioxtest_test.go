@@ -0,0 +1,25 @@
+package ioxtest
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func assertEq[T any](subject string, a T, b T, f func(string)) {
+	if f == nil {
+		return
+	}
+
+	ab, _ := json.Marshal(a)
+	bb, _ := json.Marshal(b)
+
+	as := string(ab)
+	bs := string(bb)
+
+	if as == bs {
+		return
+	}
+
+	s := "unexpected '%v':\n\twant: '%v'\n\thave: '%v'\n"
+	f(fmt.Sprintf(s, subject, as, bs))
+}
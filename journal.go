@@ -0,0 +1,190 @@
+package iox
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// ErrJournalCorrupt is returned by journal readers when a complete record has
+// a checksum which does not match its payload, or a length over
+// JournalMaxRecord.
+var ErrJournalCorrupt = errors.New("iox: corrupt journal record")
+
+// journalHeaderSize is the size of a journal record header: a big-endian
+// uint32 payload length followed by a big-endian uint32 crc32 (IEEE) checksum
+// of the payload.
+const journalHeaderSize = 8
+
+// JournalMaxRecord is the max size of a journal record payload (64 MiB).
+// NewJournal refuses to write larger values, and journal readers treat a
+// header with a larger length as corrupt, rather than allocating for it.
+const JournalMaxRecord = 64 << 20
+
+// NewJournal returns a Writer which appends values into 'w' as checksummed,
+// length-prefixed records, i.e a minimal write-ahead log which can be read
+// back with ReplayJournal. Each value is encoded into a record payload with
+// the Encoder created by 'f', and each record is written into 'w' with a
+// single Write call. If 'w' has a "Sync() error" method (e.g *os.File), it
+// is called after each record to make it durable before Write returns.
+// Values which encode into more than JournalMaxRecord bytes are not written;
+// Write returns an err wrapping ErrInvalidArg instead. Nil 'w' returns an empty non-nil Writer; nil 'f' uses json.NewEncoder.
+//
+// Example:
+//
+//	f, _ := os.OpenFile("app.journal", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+//	w := NewJournal[Event](f)(nil)
+//
+//	w.Write(ctx, Event{...}) // Synced to disk when this returns.
+func NewJournal[T any](w io.Writer) func(f encoderFn) Writer[T] {
+	return func(f func(io.Writer) Encoder) Writer[T] {
+		if w == nil {
+			return nilWriter[T]()
+		}
+
+		b := bytes.NewBuffer(nil)
+		e := Encoder(json.NewEncoder(b))
+		if f != nil {
+			if _e := f(b); _e != nil {
+				e = _e
+			}
+		}
+
+		syncer, _ := w.(interface{ Sync() error })
+		record := make([]byte, 0, 64)
+
+		return WriterImpl[T]{
+			Impl: func(ctx context.Context, v T) error {
+				if err := ctxErr(ctx); err != nil {
+					return err
+				}
+
+				b.Reset()
+				err := e.Encode(v)
+				if err != nil {
+					return err
+				}
+
+				if b.Len() > JournalMaxRecord {
+					return fmt.Errorf("%w: journal record of %d bytes", ErrInvalidArg, b.Len())
+				}
+
+				record = record[:0]
+				record = binary.BigEndian.AppendUint32(record, uint32(b.Len()))
+				record = binary.BigEndian.AppendUint32(record, crc32.ChecksumIEEE(b.Bytes()))
+				record = append(record, b.Bytes()...)
+
+				_, err = w.Write(record)
+				if err != nil {
+					return err
+				}
+
+				if syncer != nil {
+					return syncer.Sync()
+				}
+
+				return nil
+			},
+		}
+	}
+}
+
+// ReplayJournal returns a Reader which replays values from a journal written
+// by NewJournal. The decoder created by 'f' must match the encoder used when
+// writing. The journal tolerates a torn tail: an incomplete last record (e.g
+// due to a crash mid-write) is treated as the end of the journal, i.e io.EOF.
+// A complete record with a bad checksum, or a header with a length over
+// JournalMaxRecord, results in ErrJournalCorrupt.
+// Nil 'r' returns an empty non-nil Reader; nil 'f' uses json.NewDecoder.
+//
+// Example:
+//
+//	f, _ := os.Open("app.journal")
+//	r := ReplayJournal[Event](f)(nil)
+//
+//	// Recover state.
+//	for v, err := r.Read(ctx); err == nil; v, err = r.Read(ctx) {
+//		...
+//	}
+//
+// Traffic can be recorded with its timing, and replayed with the original
+// pacing (see NewReaderWithPacing), by journaling timestamped values:
+//
+//	w := NewWriterWithTimestamps(NewJournal[Timestamped[Event]](f)(nil))
+//	...
+//	r := NewReaderWithPacing(ReplayJournal[Timestamped[Event]](f)(nil), 1)
+func ReplayJournal[T any](r io.Reader) func(f decoderFn) Reader[T] {
+	return func(f func(io.Reader) Decoder) Reader[T] {
+		if r == nil {
+			return nilReader[T]()
+		}
+
+		b := bytes.NewBuffer(nil)
+		d := Decoder(json.NewDecoder(b))
+		if f != nil {
+			if _d := f(b); _d != nil {
+				d = _d
+			}
+		}
+
+		header := make([]byte, journalHeaderSize)
+		var payload []byte
+		var errCache error
+
+		return ReaderImpl[T]{
+			Impl: func(ctx context.Context) (v T, err error) {
+				if err := ctxErr(ctx); err != nil {
+					return v, err
+				}
+
+				if errCache != nil {
+					return v, errCache
+				}
+
+				_, err = io.ReadFull(r, header)
+				if err == io.ErrUnexpectedEOF {
+					err = io.EOF
+				}
+				if err != nil {
+					errCache = err
+					return v, err
+				}
+
+				n := binary.BigEndian.Uint32(header[:4])
+				sum := binary.BigEndian.Uint32(header[4:])
+				if n > JournalMaxRecord {
+					errCache = ErrJournalCorrupt
+					return v, errCache
+				}
+
+				if cap(payload) < int(n) {
+					payload = make([]byte, n)
+				}
+
+				payload = payload[:n]
+				_, err = io.ReadFull(r, payload)
+				if err == io.ErrUnexpectedEOF {
+					err = io.EOF
+				}
+				if err != nil {
+					errCache = err
+					return v, err
+				}
+
+				if crc32.ChecksumIEEE(payload) != sum {
+					errCache = ErrJournalCorrupt
+					return v, errCache
+				}
+
+				b.Write(payload)
+				err = d.Decode(&v)
+				return v, err
+			},
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package iox
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"io"
+	"testing"
+)
+
+func TestJournalIdeal(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	w := NewJournal[string](b)(nil)
+
+	assertEq("err", *new(error), w.Write(nil, "test1"), func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), w.Write(nil, "test2"), func(s string) { t.Fatal(s) })
+
+	r := ReplayJournal[string](b)(nil)
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", "test1", val, func(s string) { t.Fatal(s) })
+
+	val, err = r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", "test2", val, func(s string) { t.Fatal(s) })
+
+	val, err = r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("val", "", val, func(s string) { t.Fatal(s) })
+}
+
+func TestJournalWithGob(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	w := NewJournal[int](b)(func(w io.Writer) Encoder { return gob.NewEncoder(w) })
+
+	assertEq("err", *new(error), w.Write(nil, 1), func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), w.Write(nil, 2), func(s string) { t.Fatal(s) })
+
+	r := ReplayJournal[int](b)(func(r io.Reader) Decoder { return gob.NewDecoder(r) })
+
+	for _, want := range []int{1, 2} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestJournalWithTornTail(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	w := NewJournal[string](b)(nil)
+	w.Write(nil, "test1")
+	w.Write(nil, "test2")
+
+	torn := bytes.NewBuffer(b.Bytes()[:b.Len()-3])
+	r := ReplayJournal[string](torn)(nil)
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", "test1", val, func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestJournalWithCorruptRecord(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	w := NewJournal[string](b)(nil)
+	w.Write(nil, "test1")
+
+	corrupt := b.Bytes()
+	corrupt[len(corrupt)-2] ^= 0xff
+
+	r := ReplayJournal[string](bytes.NewBuffer(corrupt))(nil)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == ErrJournalCorrupt, func(s string) { t.Fatal(s) })
+}
+
+func TestJournalWithCorruptLength(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	w := NewJournal[string](b)(nil)
+	w.Write(nil, "test1")
+
+	// A flipped bit in the length should not cause a huge allocation.
+	corrupt := b.Bytes()
+	corrupt[0] ^= 0x80
+
+	r := ReplayJournal[string](bytes.NewBuffer(corrupt))(nil)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == ErrJournalCorrupt, func(s string) { t.Fatal(s) })
+}
+
+func TestJournalWithCancelledCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	b := bytes.NewBuffer(nil)
+	w := NewJournal[string](b)(nil)
+	assertEq("err", true, w.Write(ctx, "test1") == context.Canceled, func(s string) { t.Fatal(s) })
+	assertEq("len", 0, b.Len(), func(s string) { t.Fatal(s) })
+}
+
+func TestNewJournalWithNilWriter(t *testing.T) {
+	w := NewJournal[int](nil)(nil)
+
+	assertEq("err", true, w.Write(nil, 1) == io.ErrClosedPipe, func(s string) { t.Fatal(s) })
+}
+
+func TestReplayJournalWithNilReader(t *testing.T) {
+	r := ReplayJournal[int](nil)(nil)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
@@ -0,0 +1,145 @@
+package iox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// -----------------------------------------------------------------------------
+// JSON arrays.
+// -----------------------------------------------------------------------------
+
+// NewReaderFromJSONArray returns a Reader which streams the elements of a
+// top-level JSON array from 'r', decoding one element per Read, such that a
+// large document is never fully loaded. io.EOF is returned after the closing
+// bracket. An err is returned if the document is not an array, as opposed to
+// NewReaderFromBytes which reads a stream of values (e.g NDJSON). Nil 'r'
+// returns an empty non-nil Reader.
+//
+// Example:
+//
+//	r := NewReaderFromJSONArray[int](strings.NewReader("[1, 2]"))
+//
+//	t.Log(r.Read(nil)) // 1, nil
+//	t.Log(r.Read(nil)) // 2, nil
+//	t.Log(r.Read(nil)) // 0, io.EOF
+func NewReaderFromJSONArray[T any](r io.Reader) Reader[T] {
+	if r == nil {
+		return nilReader[T]()
+	}
+
+	dec := json.NewDecoder(r)
+	started := false
+	done := false
+
+	return ReaderImpl[T]{
+		Impl: func(ctx context.Context) (v T, err error) {
+			if err := ctxErr(ctx); err != nil {
+				return v, err
+			}
+
+			if done {
+				return v, io.EOF
+			}
+
+			if !started {
+				tok, err := dec.Token()
+				if err != nil {
+					return v, err
+				}
+				if tok != json.Delim('[') {
+					return v, fmt.Errorf("iox: expected json array, got %v", tok)
+				}
+
+				started = true
+			}
+
+			if !dec.More() {
+				// Consumes the closing bracket.
+				if _, err := dec.Token(); err != nil {
+					return v, err
+				}
+
+				done = true
+				return v, io.EOF
+			}
+
+			err = dec.Decode(&v)
+			return
+		},
+	}
+}
+
+// NewWriterFromJSONArray returns a WriteCloser which writes values into 'w'
+// as elements of a top-level JSON array. The opening bracket is written with
+// the first value, and the closing bracket on Close, which writes "[]" if
+// no values were written. Writes after Close return io.ErrClosedPipe. Close
+// does not close 'w'. Nil 'w' returns an empty non-nil WriteCloser.
+//
+// Example:
+//
+//	b := bytes.NewBuffer(nil)
+//	w := NewWriterFromJSONArray[int](b)
+//
+//	w.Write(nil, 1)
+//	w.Write(nil, 2)
+//	w.Close()
+//
+//	t.Log(b.String()) // [1,2]
+func NewWriterFromJSONArray[T any](w io.Writer) WriteCloser[T] {
+	if w == nil {
+		return nilWriteCloser[T]()
+	}
+
+	n := 0
+	closed := false
+
+	return WriteCloserImpl[T]{
+		ImplC: func() error {
+			if closed {
+				return nil
+			}
+
+			closed = true
+			if n == 0 {
+				_, err := io.WriteString(w, "[]")
+				return err
+			}
+
+			_, err := io.WriteString(w, "]")
+			return err
+		},
+		ImplW: func(ctx context.Context, v T) error {
+			if err := ctxErr(ctx); err != nil {
+				return err
+			}
+
+			if closed {
+				return io.ErrClosedPipe
+			}
+
+			p, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+
+			delim := byte(',')
+			if n == 0 {
+				delim = '['
+			}
+
+			// Single Write, so a failed value leaves no dangling delimiter.
+			buf := make([]byte, 0, len(p)+1)
+			buf = append(buf, delim)
+			buf = append(buf, p...)
+			if _, err := w.Write(buf); err != nil {
+				return err
+			}
+
+			n++
+			return nil
+		},
+	}
+}
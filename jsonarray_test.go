@@ -0,0 +1,66 @@
+package iox
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// JSON arrays.
+// -----------------------------------------------------------------------------
+
+func TestJSONArrayRoundtripIdeal(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	w := NewWriterFromJSONArray[map[string]int](b)
+
+	assertEq("err", *new(error), w.Write(nil, map[string]int{"a": 1}), func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), w.Write(nil, map[string]int{"a": 2}), func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), w.Close(), func(s string) { t.Fatal(s) })
+	assertEq("bytes", `[{"a":1},{"a":2}]`, b.String(), func(s string) { t.Fatal(s) })
+
+	r := NewReaderFromJSONArray[map[string]int](b)
+	for _, want := range []int{1, 2} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val["a"], func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderFromJSONArrayWithNonArray(t *testing.T) {
+	r := NewReaderFromJSONArray[int](strings.NewReader(`{"a": 1}`))
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err != nil && err != io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderFromJSONArrayWithEmptyArray(t *testing.T) {
+	r := NewReaderFromJSONArray[int](strings.NewReader(` [ ] `))
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterFromJSONArrayWithNoValues(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	w := NewWriterFromJSONArray[int](b)
+
+	assertEq("err", *new(error), w.Close(), func(s string) { t.Fatal(s) })
+	assertEq("bytes", "[]", b.String(), func(s string) { t.Fatal(s) })
+	assertEq("err", io.ErrClosedPipe, w.Write(nil, 1), func(s string) { t.Fatal(s) })
+}
+
+func TestJSONArrayWithNilArgs(t *testing.T) {
+	w := NewWriterFromJSONArray[int](nil)
+	assertEq("err", io.ErrClosedPipe, w.Write(nil, 1), func(s string) { t.Fatal(s) })
+
+	r := NewReaderFromJSONArray[int](nil)
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
@@ -0,0 +1,392 @@
+package iox
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// -----------------------------------------------------------------------------
+// Parallel mapping.
+// -----------------------------------------------------------------------------
+
+// NewReaderWithParallelMapperFn is like NewReaderWithMapperFn, but it maps
+// values from 'r' with 'f' in 'workers' goroutines, while yielding the results
+// in the order of 'r', e.g for CPU bound transforms. <= 0 'workers' defaults
+// to runtime.GOMAXPROCS(0). Values are read ahead from 'r' (in a goroutine)
+// such that all workers are kept busy, up to 2 x 'workers' values in flight.
+//
+// The goroutines are started by the first Read, and read and map with the
+// values (e.g Clock or Budget) of the ctx given to that Read, but not its
+// cancellation. Values in flight are reserved in the Budget, and reading
+// ahead waits while it is exceeded, see Budget. An err from 'r' or 'f' is
+// returned in place of its value, after which the goroutines are stopped and
+// the err is returned on every Read. Close stops the goroutines and waits for
+// them to return, after which Read returns io.EOF. 'r' itself is not closed.
+// Nil 'r' or nil 'f' returns an empty non-nil ReadCloser.
+//
+// Example:
+//
+//	r := NewReaderWithParallelMapperFn[[]byte, Image](blobs, 8)(
+//		func(ctx context.Context, b []byte) (Image, error) {
+//			return decode(b)
+//		},
+//	)
+//
+//	defer r.Close()
+func NewReaderWithParallelMapperFn[T, U any](r Reader[T], workers int) func(f func(context.Context, T) (U, error)) ReadCloser[U] {
+	return func(f func(context.Context, T) (U, error)) ReadCloser[U] {
+		if r == nil || f == nil {
+			return nilReadCloser[U]()
+		}
+
+		return newParallelMapper(r, f, workers, 0, true)
+	}
+}
+
+// NewReaderWithUnorderedParallelMapperFn is like NewReaderWithParallelMapperFn,
+// but results are yielded as soon as a worker is done with them, regardless
+// of the order of 'r', which gives the best throughput when the time spent in
+// 'f' varies. Up to 'buffer' results are kept while waiting to be read, <= 0
+// defaults to 'workers'. The first err from 'f' is returned as soon as it is
+// seen, while an err from 'r' is returned after the values read before it.
+// Either way, the goroutines are then stopped and the err is returned on
+// every Read. Nil 'r' or nil 'f' returns an empty non-nil ReadCloser.
+//
+// Example:
+//
+//	r := NewReaderWithUnorderedParallelMapperFn[URL, Page](urls, 16, 64)(
+//		func(ctx context.Context, u URL) (Page, error) {
+//			return fetch(ctx, u)
+//		},
+//	)
+//
+//	defer r.Close()
+func NewReaderWithUnorderedParallelMapperFn[T, U any](r Reader[T], workers, buffer int) func(f func(context.Context, T) (U, error)) ReadCloser[U] {
+	return func(f func(context.Context, T) (U, error)) ReadCloser[U] {
+		if r == nil || f == nil {
+			return nilReadCloser[U]()
+		}
+
+		return newParallelMapper(r, f, workers, buffer, false)
+	}
+}
+
+type parallelResult[U any] struct {
+	val U
+	err error
+	// The amount reserved for the value (see budgetGate), if 'held'.
+	n    int64
+	held bool
+}
+
+type parallelJob[T, U any] struct {
+	v   T
+	n   int64
+	res chan parallelResult[U]
+}
+
+type parallelMapper[T, U any] struct {
+	r       Reader[T]
+	f       func(context.Context, T) (U, error)
+	workers int
+	ordered bool
+
+	// When ordered, each value gets a result chan which is put on 'order'
+	// in the order of 'r'. Otherwise, all results go into 'results'.
+	order   chan chan parallelResult[U]
+	results chan parallelResult[U]
+
+	// Reserves the values from 'r' until their results are read.
+	gate *budgetGate
+
+	start   sync.Once
+	done    chan struct{}
+	closing chan struct{}
+
+	stateMx sync.Mutex
+	stop    context.CancelFunc
+	closed  bool
+
+	// Held by Read, such that Reads are serialized.
+	mx      sync.Mutex
+	pending chan parallelResult[U]
+	err     error
+}
+
+func newParallelMapper[T, U any](r Reader[T], f func(context.Context, T) (U, error), workers, buffer int, ordered bool) *parallelMapper[T, U] {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if buffer <= 0 {
+		buffer = workers
+	}
+
+	p := &parallelMapper[T, U]{
+		r:       r,
+		f:       f,
+		workers: workers,
+		ordered: ordered,
+		stop:    func() {},
+		gate:    newBudgetGate(),
+		done:    make(chan struct{}),
+		closing: make(chan struct{}),
+	}
+
+	if ordered {
+		p.order = make(chan chan parallelResult[U], workers)
+	} else {
+		p.results = make(chan parallelResult[U], buffer)
+	}
+
+	return p
+}
+
+// run runs in the goroutine started by the first Read. It feeds the workers
+// with values from 'r', and delivers the err from 'r' once they are done.
+func (p *parallelMapper[T, U]) run(ctx context.Context) {
+	defer close(p.done)
+
+	jobs := make(chan parallelJob[T, U], p.workers)
+	var wg sync.WaitGroup
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				val, err := p.f(ctx, j.v)
+
+				select {
+				case j.res <- parallelResult[U]{val: val, err: err, n: j.n, held: true}:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+
+	var srcErr error
+	for srcErr == nil {
+		v, err := p.r.Read(ctx)
+		if err != nil {
+			srcErr = err
+			break
+		}
+
+		n, err := reserveGate(ctx, p.gate, v)
+		if err != nil {
+			srcErr = err
+			break
+		}
+
+		j := parallelJob[T, U]{v: v, n: n, res: p.results}
+		if p.ordered {
+			j.res = make(chan parallelResult[U], 1)
+		}
+
+		select {
+		case jobs <- j:
+		case <-ctx.Done():
+			srcErr = ctx.Err()
+			continue
+		}
+
+		if p.ordered {
+			select {
+			case p.order <- j.res:
+			case <-ctx.Done():
+				srcErr = ctx.Err()
+			}
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	res := p.results
+	if p.ordered {
+		res = make(chan parallelResult[U], 1)
+		res <- parallelResult[U]{err: srcErr}
+		select {
+		case p.order <- res:
+		case <-ctx.Done():
+		}
+
+		return
+	}
+
+	select {
+	case res <- parallelResult[U]{err: srcErr}:
+	case <-ctx.Done():
+	}
+}
+
+func (p *parallelMapper[T, U]) Read(ctx context.Context) (val U, err error) {
+	if err := ctxErr(ctx); err != nil {
+		return val, err
+	}
+
+	p.start.Do(func() {
+		bg := context.Background()
+		if ctx != nil {
+			bg = context.WithoutCancel(ctx)
+		}
+
+		p.stateMx.Lock()
+		defer p.stateMx.Unlock()
+		if p.closed {
+			close(p.done)
+			return
+		}
+
+		bg, p.stop = context.WithCancel(bg)
+		go p.run(bg)
+	})
+
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	if isClosed(p.closing) {
+		return val, io.EOF
+	}
+	if p.err != nil {
+		return val, p.err
+	}
+
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+
+	results := p.results
+	if p.ordered {
+		// Kept across Reads, such that a Read which gives up on its ctx does
+		// not lose the place of the next result.
+		if p.pending == nil {
+			select {
+			case p.pending = <-p.order:
+			case <-done:
+				return val, ctx.Err()
+			case <-p.closing:
+				return val, io.EOF
+			}
+		}
+
+		results = p.pending
+	}
+
+	var res parallelResult[U]
+	select {
+	case res = <-results:
+		p.pending = nil
+		if res.held {
+			p.gate.release(res.n)
+		}
+	case <-done:
+		return val, ctx.Err()
+	case <-p.closing:
+		return val, io.EOF
+	}
+
+	if res.err != nil {
+		p.err = res.err
+		p.stateMx.Lock()
+		p.stop()
+		p.stateMx.Unlock()
+	}
+
+	return res.val, res.err
+}
+
+func (p *parallelMapper[T, U]) Close() error {
+	p.stateMx.Lock()
+	if p.closed {
+		p.stateMx.Unlock()
+		<-p.done
+		return nil
+	}
+
+	p.closed = true
+	close(p.closing)
+	p.stop()
+	p.stateMx.Unlock()
+
+	// Marking the goroutines as done if they were never started.
+	p.start.Do(func() { close(p.done) })
+	<-p.done
+	p.gate.releaseAll()
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// Stages.
+// -----------------------------------------------------------------------------
+
+// MapStageCfg is used to configure NewMapStage.
+type MapStageCfg struct {
+	// Workers is the amount of goroutines which map values concurrently, see
+	// NewReaderWithParallelMapperFn. <= 1 maps values sequentially, in the
+	// goroutine which runs the stage.
+	Workers int
+	// Unordered lets results be written as soon as they are mapped, rather
+	// than in the order of the source, see
+	// NewReaderWithUnorderedParallelMapperFn. Ignored without Workers.
+	Unordered bool
+	// Buffer is the max amount of results kept while waiting to be written
+	// when Unordered, <= 0 defaults to Workers.
+	Buffer int
+	// CloseWriter closes the Writer of the stage (if it is an io.Closer) when
+	// the stage returns, e.g to signal io.EOF to the next stage through a
+	// Pipe.
+	CloseWriter bool
+}
+
+// NewMapStage returns a Runnable stage of a pipeline, which reads values from
+// 'r', maps them with 'f' and writes the results into 'w' until 'r' returns
+// io.EOF (see Copy). The concurrency of the stage is declared with the given
+// MapStageCfg, from which the stage assembles the matching (parallel) mapper,
+// such that e.g a CPU heavy stage can be given more workers than the others.
+// Goroutines of a parallel mapper are stopped when Run returns. Nil 'f'
+// returns a Runnable which does nothing.
+//
+// Example:
+//
+//	raw, rawW := BufferedPipe[[]byte](PipeCfg{})
+//	parsed, parsedW := BufferedPipe[Event](PipeCfg{})
+//
+//	err := RunGroup(ctx,
+//		NewMapStage(urls, rawW)(fetch, MapStageCfg{Workers: 16, Unordered: true, CloseWriter: true}),
+//		NewMapStage(raw, parsedW)(parse, MapStageCfg{Workers: 8, CloseWriter: true}), // CPU heavy.
+//		NewMapStage(parsed, sink)(enrich, MapStageCfg{}),
+//	)
+func NewMapStage[T, U any](r Reader[T], w Writer[U]) func(f func(context.Context, T) (U, error), cfg MapStageCfg) Runnable {
+	return func(f func(context.Context, T) (U, error), cfg MapStageCfg) Runnable {
+		if f == nil {
+			return RunnableImpl{}
+		}
+
+		return RunnableImpl{
+			Impl: func(ctx context.Context) error {
+				if c, ok := w.(io.Closer); ok && cfg.CloseWriter {
+					defer c.Close()
+				}
+
+				if cfg.Workers <= 1 || r == nil {
+					_, err := Copy(ctx, w, NewReaderWithMapperFnE[T, U](r)(f))
+					return err
+				}
+
+				var mapped ReadCloser[U]
+				if cfg.Unordered {
+					mapped = NewReaderWithUnorderedParallelMapperFn[T, U](r, cfg.Workers, cfg.Buffer)(f)
+				} else {
+					mapped = NewReaderWithParallelMapperFn[T, U](r, cfg.Workers)(f)
+				}
+
+				defer mapped.Close()
+				_, err := Copy(ctx, w, mapped)
+				return err
+			},
+		}
+	}
+}
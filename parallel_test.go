@@ -0,0 +1,241 @@
+package iox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewReaderWithParallelMapperFnIdeal(t *testing.T) {
+	src := NewReaderFrom(1, 2, 3, 4, 5, 6, 7, 8)
+
+	// Earlier values take longer, such that results are done out of order.
+	r := NewReaderWithParallelMapperFn[int, int](src, 4)(
+		func(ctx context.Context, v int) (int, error) {
+			time.Sleep(time.Millisecond * time.Duration(8-v))
+			return v * 10, nil
+		},
+	)
+
+	defer r.Close()
+
+	vals, err := readAll[int](r)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("vals", []int{10, 20, 30, 40, 50, 60, 70, 80}, vals, func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithParallelMapperFnConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int64
+	r := NewReaderWithParallelMapperFn[int, int](NewReaderFrom(1, 2, 3, 4, 5, 6), 3)(
+		func(ctx context.Context, v int) (int, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+
+			time.Sleep(time.Millisecond * 5)
+			return v, nil
+		},
+	)
+
+	defer r.Close()
+
+	vals, _ := readAll[int](r)
+	assertEq("vals", []int{1, 2, 3, 4, 5, 6}, vals, func(s string) { t.Fatal(s) })
+	assertEq("peak", true, peak.Load() > 1 && peak.Load() <= 3, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithParallelMapperFnWithErr(t *testing.T) {
+	errTest := errors.New("test")
+	r := NewReaderWithParallelMapperFn[int, int](NewReaderFrom(1, 2, 3, 4), 2)(
+		func(ctx context.Context, v int) (int, error) {
+			if v == 3 {
+				return 0, errTest
+			}
+
+			return v, nil
+		},
+	)
+
+	defer r.Close()
+
+	vals, err := readAll[int](r)
+	assertEq("err", true, err == errTest, func(s string) { t.Fatal(s) })
+	assertEq("vals", []int{1, 2}, vals, func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", true, err == errTest, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithParallelMapperFnWithClose(t *testing.T) {
+	// Blocks until its ctx is done, i.e until Close.
+	r := NewReaderWithParallelMapperFn[int, int](NewReaderFrom(1, 2), 2)(
+		func(ctx context.Context, v int) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+
+	_, err := r.Read(ctx)
+	assertEq("err", true, err == context.DeadlineExceeded, func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), r.Close(), func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithParallelMapperFnWithCloseBeforeRead(t *testing.T) {
+	r := NewReaderWithParallelMapperFn[int, int](NewReaderFrom(1), 2)(
+		func(ctx context.Context, v int) (int, error) { return v, nil },
+	)
+
+	assertEq("err", *new(error), r.Close(), func(s string) { t.Fatal(s) })
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithParallelMapperFnWithNilArgs(t *testing.T) {
+	r := NewReaderWithParallelMapperFn[int, int](nil, 2)(
+		func(ctx context.Context, v int) (int, error) { return v, nil },
+	)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+
+	r = NewReaderWithParallelMapperFn[int, int](NewReaderFrom(1), 2)(nil)
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithUnorderedParallelMapperFnIdeal(t *testing.T) {
+	src := NewReaderFrom(1, 2, 3, 4)
+
+	// The first value takes the longest, such that it is yielded last.
+	r := NewReaderWithUnorderedParallelMapperFn[int, int](src, 4, 4)(
+		func(ctx context.Context, v int) (int, error) {
+			if v == 1 {
+				time.Sleep(time.Millisecond * 20)
+			}
+
+			return v * 10, nil
+		},
+	)
+
+	defer r.Close()
+
+	vals, err := readAll[int](r)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("len", 4, len(vals), func(s string) { t.Fatal(s) })
+	assertEq("last", 10, vals[3], func(s string) { t.Fatal(s) })
+
+	sum := 0
+	for _, v := range vals {
+		sum += v
+	}
+
+	assertEq("sum", 100, sum, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithUnorderedParallelMapperFnWithErr(t *testing.T) {
+	errTest := errors.New("test")
+
+	// The err is returned before the slow values.
+	r := NewReaderWithUnorderedParallelMapperFn[int, int](NewReaderFrom(1, 2, 3), 3, 0)(
+		func(ctx context.Context, v int) (int, error) {
+			if v == 2 {
+				return 0, errTest
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+
+			return v, nil
+		},
+	)
+
+	defer r.Close()
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == errTest, func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", true, err == errTest, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithUnorderedParallelMapperFnWithClose(t *testing.T) {
+	r := NewReaderWithUnorderedParallelMapperFn[int, int](NewReaderFrom(1, 2), 2, 1)(
+		func(ctx context.Context, v int) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+
+	_, err := r.Read(ctx)
+	assertEq("err", true, err == context.DeadlineExceeded, func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), r.Close(), func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewMapStageIdeal(t *testing.T) {
+	double := func(ctx context.Context, v int) (int, error) { return v * 2, nil }
+
+	for _, cfg := range []MapStageCfg{{}, {Workers: 3}} {
+		s := make([]int, 0, 4)
+		stage := NewMapStage[int, int](NewReaderFrom(1, 2, 3, 4), newSliceWriter(&s))(double, cfg)
+
+		err := stage.Run(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("vals", []int{2, 4, 6, 8}, s, func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestNewMapStageWithPipes(t *testing.T) {
+	double := func(ctx context.Context, v int) (int, error) { return v * 2, nil }
+	r, w := BufferedPipe[int](PipeCfg{})
+
+	s := make([]int, 0, 4)
+	err := RunGroup(context.Background(),
+		NewMapStage[int, int](NewReaderFrom(1, 2, 3, 4), w)(double, MapStageCfg{Workers: 4, Unordered: true, CloseWriter: true}),
+		NewMapStage[int, int](r, newSliceWriter(&s))(double, MapStageCfg{}),
+	)
+
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("len", 4, len(s), func(s string) { t.Fatal(s) })
+
+	sum := 0
+	for _, v := range s {
+		sum += v
+	}
+
+	assertEq("sum", 40, sum, func(s string) { t.Fatal(s) })
+}
+
+func TestNewMapStageWithErr(t *testing.T) {
+	errTest := errors.New("test")
+	fail := func(ctx context.Context, v int) (int, error) { return 0, errTest }
+
+	stage := NewMapStage[int, int](NewReaderFrom(1, 2), newSliceWriter(new([]int)))(fail, MapStageCfg{Workers: 2})
+	assertEq("err", true, stage.Run(nil) == errTest, func(s string) { t.Fatal(s) })
+}
@@ -0,0 +1,266 @@
+package iox
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// -----------------------------------------------------------------------------
+// Pipes.
+// -----------------------------------------------------------------------------
+
+// Pipe creates a synchronous in-memory pipe, similar to io.Pipe, which can be
+// used to connect a producer and a consumer in separate goroutines without
+// exposing chans. Each Write blocks until a Read takes the value (or until the
+// ctx of the Write is done); there is no internal buffering.
+//
+// Closing the WriteCloser makes Read return io.EOF, and closing the
+// ReadCloser makes Write return io.ErrClosedPipe; in both cases, blocked
+// calls return at once. After either Close, Write returns io.ErrClosedPipe
+// and Read returns io.EOF. Both may be called concurrently, and Close may be
+// called more than once.
+//
+// Example:
+//
+//	r, w := Pipe[int]()
+//
+//	go func() {
+//		defer w.Close()
+//		for i := 0; i < 3; i++ {
+//			w.Write(ctx, i)
+//		}
+//	}()
+//
+//	n, err := Count(ctx, r) // 3, nil
+func Pipe[T any]() (ReadCloser[T], WriteCloser[T]) {
+	p := &pipe[T]{
+		ch:      make(chan T),
+		rClosed: make(chan struct{}),
+		wClosed: make(chan struct{}),
+	}
+
+	r := ReadCloserImpl[T]{ImplC: p.closeRead, ImplR: p.read}
+	w := WriteCloserImpl[T]{ImplC: p.closeWrite, ImplW: p.write}
+	return r, w
+}
+
+type pipe[T any] struct {
+	ch chan T
+
+	rOnce   sync.Once
+	rClosed chan struct{}
+	wOnce   sync.Once
+	wClosed chan struct{}
+}
+
+func (p *pipe[T]) read(ctx context.Context) (val T, err error) {
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+
+	if isClosed(p.rClosed) || isClosed(p.wClosed) {
+		return val, io.EOF
+	}
+
+	select {
+	case val = <-p.ch:
+		return val, nil
+	case <-p.rClosed:
+		return val, io.EOF
+	case <-p.wClosed:
+		return val, io.EOF
+	case <-done:
+		return val, ctx.Err()
+	}
+}
+
+func (p *pipe[T]) write(ctx context.Context, v T) error {
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+
+	if isClosed(p.rClosed) || isClosed(p.wClosed) {
+		return io.ErrClosedPipe
+	}
+
+	select {
+	case p.ch <- v:
+		return nil
+	case <-p.rClosed:
+		return io.ErrClosedPipe
+	case <-p.wClosed:
+		return io.ErrClosedPipe
+	case <-done:
+		return ctx.Err()
+	}
+}
+
+func (p *pipe[T]) closeRead() error {
+	p.rOnce.Do(func() { close(p.rClosed) })
+	return nil
+}
+
+func (p *pipe[T]) closeWrite() error {
+	p.wOnce.Do(func() { close(p.wClosed) })
+	return nil
+}
+
+// PipeCfg is used to configure BufferedPipe.
+type PipeCfg struct {
+	// Cap is the max amount of buffered values, <= 0 defaults to 64.
+	Cap int
+	// Policy decides what happens when the buffer is full.
+	Policy QueuePolicy
+}
+
+// BufferedPipe is like Pipe, except that values are buffered (see PipeCfg),
+// such that a bursty producer is decoupled from a slow consumer. Write
+// returns once its value is buffered, or as decided by the policy when the
+// buffer is full; a dropped value is not an err. The buffer is also full
+// while the Budget of the ctx given to Write is exceeded, see Budget, unless
+// it is empty. Closing the WriteCloser makes Read return io.EOF once the
+// buffered values are read, while closing the ReadCloser drops them and makes
+// Write return io.ErrClosedPipe.
+//
+// Example:
+//
+//	r, w := BufferedPipe[Event](PipeCfg{Cap: 1024, Policy: QueueDropOldest})
+//
+//	go func() {
+//		defer w.Close()
+//		Copy(ctx, w, burstySource)
+//	}()
+//
+//	err := ForEach(ctx, r, slowHandler)
+func BufferedPipe[T any](cfg PipeCfg) (ReadCloser[T], WriteCloser[T]) {
+	if cfg.Cap <= 0 {
+		cfg.Cap = 64
+	}
+
+	p := &bufferedPipe[T]{
+		q:        boundedQueue[T]{max: cfg.Cap, policy: cfg.Policy},
+		notEmpty: make(chan struct{}, 1),
+		notFull:  make(chan struct{}, 1),
+	}
+
+	r := ReadCloserImpl[T]{ImplC: p.closeRead, ImplR: p.read}
+	w := WriteCloserImpl[T]{ImplC: p.closeWrite, ImplW: p.write}
+	return r, w
+}
+
+type bufferedPipe[T any] struct {
+	mx      sync.Mutex
+	q       boundedQueue[T]
+	rClosed bool
+	wClosed bool
+
+	// Signals with a capacity of 1, see signalChan. Several goroutines may
+	// wait on each, so a woken waiter passes the signal on when others may
+	// proceed as well.
+	notEmpty chan struct{}
+	notFull  chan struct{}
+}
+
+func (p *bufferedPipe[T]) read(ctx context.Context) (val T, err error) {
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+
+	p.mx.Lock()
+	for {
+		if p.rClosed {
+			p.mx.Unlock()
+			signalChan(p.notEmpty)
+			return val, io.EOF
+		}
+
+		if v, ok := p.q.pop(); ok {
+			if p.q.len() > 0 {
+				signalChan(p.notEmpty)
+			}
+
+			p.mx.Unlock()
+			signalChan(p.notFull)
+			return v, nil
+		}
+
+		if p.wClosed {
+			p.mx.Unlock()
+			signalChan(p.notEmpty)
+			return val, io.EOF
+		}
+
+		p.mx.Unlock()
+
+		select {
+		case <-p.notEmpty:
+		case <-done:
+			return val, ctx.Err()
+		}
+
+		p.mx.Lock()
+	}
+}
+
+func (p *bufferedPipe[T]) write(ctx context.Context, v T) error {
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+
+	p.mx.Lock()
+	for {
+		if p.rClosed || p.wClosed {
+			p.mx.Unlock()
+			signalChan(p.notFull)
+			return io.ErrClosedPipe
+		}
+
+		// A dropped value (see QueuePolicy) is not an err.
+		if p.q.push(ctx, v, false) {
+			if !p.q.full() {
+				signalChan(p.notFull)
+			}
+
+			p.mx.Unlock()
+			signalChan(p.notEmpty)
+			return nil
+		}
+
+		// Waiting for room.
+		p.mx.Unlock()
+
+		select {
+		case <-p.notFull:
+		case <-done:
+			return ctx.Err()
+		}
+
+		p.mx.Lock()
+	}
+}
+
+func (p *bufferedPipe[T]) closeRead() error {
+	p.mx.Lock()
+	p.rClosed = true
+	p.q.clear()
+	p.mx.Unlock()
+
+	signalChan(p.notEmpty)
+	signalChan(p.notFull)
+	return nil
+}
+
+func (p *bufferedPipe[T]) closeWrite() error {
+	p.mx.Lock()
+	p.wClosed = true
+	p.mx.Unlock()
+
+	signalChan(p.notEmpty)
+	signalChan(p.notFull)
+	return nil
+}
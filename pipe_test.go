@@ -0,0 +1,116 @@
+package iox
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// Pipes.
+// -----------------------------------------------------------------------------
+
+func TestPipeIdeal(t *testing.T) {
+	r, w := Pipe[int]()
+
+	go func() {
+		defer w.Close()
+		for i := 1; i <= 3; i++ {
+			w.Write(nil, i)
+		}
+	}()
+
+	vs, err := readAll[int](r)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("vals", []int{1, 2, 3}, vs, func(s string) { t.Fatal(s) })
+}
+
+func TestPipeWithReaderClose(t *testing.T) {
+	r, w := Pipe[int]()
+
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		r.Close()
+	}()
+
+	// Blocks until the reader is closed.
+	assertEq("err", true, w.Write(nil, 1) == io.ErrClosedPipe, func(s string) { t.Fatal(s) })
+	assertEq("err", true, w.Write(nil, 2) == io.ErrClosedPipe, func(s string) { t.Fatal(s) })
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestPipeWithCtx(t *testing.T) {
+	r, w := Pipe[int]()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+
+	_, err := r.Read(ctx)
+	assertEq("err", true, err == context.DeadlineExceeded, func(s string) { t.Fatal(s) })
+	assertEq("err", true, w.Write(ctx, 1) == context.DeadlineExceeded, func(s string) { t.Fatal(s) })
+}
+
+func TestBufferedPipeIdeal(t *testing.T) {
+	r, w := BufferedPipe[int](PipeCfg{Cap: 4})
+
+	for i := 1; i <= 3; i++ {
+		assertEq("err", *new(error), w.Write(nil, i), func(s string) { t.Fatal(s) })
+	}
+
+	// Buffered values are still read after the writer is closed.
+	w.Close()
+	vs, err := readAll[int](r)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("vals", []int{1, 2, 3}, vs, func(s string) { t.Fatal(s) })
+}
+
+func TestBufferedPipeWithBlock(t *testing.T) {
+	r, w := BufferedPipe[int](PipeCfg{Cap: 1})
+	assertEq("err", *new(error), w.Write(nil, 1), func(s string) { t.Fatal(s) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	assertEq("err", true, w.Write(ctx, 2) == context.DeadlineExceeded, func(s string) { t.Fatal(s) })
+
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		r.Read(nil)
+	}()
+
+	// Blocks until there is room.
+	assertEq("err", *new(error), w.Write(nil, 3), func(s string) { t.Fatal(s) })
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 3, val, func(s string) { t.Fatal(s) })
+}
+
+func TestBufferedPipeWithDropPolicies(t *testing.T) {
+	for policy, want := range map[QueuePolicy][]int{
+		QueueDropOldest: {3, 4},
+		QueueDropNewest: {1, 2},
+	} {
+		r, w := BufferedPipe[int](PipeCfg{Cap: 2, Policy: policy})
+		for i := 1; i <= 4; i++ {
+			assertEq("err", *new(error), w.Write(nil, i), func(s string) { t.Fatal(s) })
+		}
+
+		w.Close()
+		vs, _ := readAll[int](r)
+		assertEq("vals", want, vs, func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestBufferedPipeWithReaderClose(t *testing.T) {
+	r, w := BufferedPipe[int](PipeCfg{Cap: 2})
+	w.Write(nil, 1)
+	r.Close()
+
+	assertEq("err", true, w.Write(nil, 2) == io.ErrClosedPipe, func(s string) { t.Fatal(s) })
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
@@ -0,0 +1,273 @@
+package iox
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// -----------------------------------------------------------------------------
+// Prefetching.
+// -----------------------------------------------------------------------------
+
+// PrefetchCfg is used to configure NewReaderWithPrefetch.
+type PrefetchCfg struct {
+	// Size is the max amount of values read ahead, <= 0 defaults to 16. With
+	// Adaptive, it is the initial size, clamped to [MinSize, MaxSize].
+	Size int
+	// Adaptive enables auto-tuning of the size, based on observed producer and
+	// consumer rates: the size doubles when the consumer has to wait after
+	// the producer was held back by a full buffer (i.e a larger buffer would
+	// have absorbed a burst), and halves when the consumer has not waited for
+	// 4 x size reads (i.e the buffer is larger than needed).
+	Adaptive bool
+	// MinSize is the smallest adaptive size, <= 0 defaults to 1.
+	MinSize int
+	// MaxSize is the largest adaptive size, <= 0 defaults to 1024. It is
+	// raised to MinSize if it is smaller.
+	MaxSize int
+}
+
+// NewReaderWithPrefetch returns a ReadCloser which reads values from 'r' ahead
+// of time in a goroutine, such that reading from 'r' overlaps with processing
+// of the values, see PrefetchCfg for the amount. The goroutine is started by
+// the first Read, and reads from 'r' with the values (e.g Clock or Budget) of
+// the ctx given to that Read, but not its cancellation; reading ahead waits
+// while the Budget of that ctx is exceeded, see Budget. An err from 'r' stops
+// the prefetching, and is returned (on every Read) after the values read
+// before it. Close stops the prefetching and waits for the goroutine to
+// return (i.e for an ongoing Read of 'r' to return), after which Read returns
+// io.EOF. Nil 'r' returns an empty non-nil ReadCloser.
+//
+// Example:
+//
+//	r := NewReaderWithPrefetch(slowNetworkReader)(
+//		PrefetchCfg{Size: 64, Adaptive: true, MaxSize: 4096},
+//	)
+//
+//	defer r.Close()
+func NewReaderWithPrefetch[T any](r Reader[T]) func(cfg PrefetchCfg) ReadCloser[T] {
+	return func(cfg PrefetchCfg) ReadCloser[T] {
+		if r == nil {
+			return nilReadCloser[T]()
+		}
+
+		return newPrefetchReader(r, cfg)
+	}
+}
+
+type prefetchReader[T any] struct {
+	r   Reader[T]
+	cfg PrefetchCfg
+
+	start sync.Once
+	stop  context.CancelFunc
+	done  chan struct{}
+
+	// Reserves the values in 'q', and 'ns' holds the amount for each.
+	gate *budgetGate
+
+	mx     sync.Mutex
+	q      []T
+	ns     []int64
+	srcErr error
+	closed bool
+	// Current size, whether the producer was held back by a full buffer since
+	// the last adaption, and the amount of reads without waiting since then.
+	size    int
+	blocked bool
+	streak  int
+
+	// Signals with a capacity of 1, such that a signal is never lost.
+	notEmpty chan struct{}
+	notFull  chan struct{}
+}
+
+func newPrefetchReader[T any](r Reader[T], cfg PrefetchCfg) *prefetchReader[T] {
+	if cfg.Size <= 0 {
+		cfg.Size = 16
+	}
+	if cfg.MinSize <= 0 {
+		cfg.MinSize = 1
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = 1024
+	}
+	if cfg.MaxSize < cfg.MinSize {
+		cfg.MaxSize = cfg.MinSize
+	}
+	if cfg.Adaptive {
+		cfg.Size = min(max(cfg.Size, cfg.MinSize), cfg.MaxSize)
+	}
+
+	return &prefetchReader[T]{
+		r:        r,
+		cfg:      cfg,
+		stop:     func() {},
+		done:     make(chan struct{}),
+		gate:     newBudgetGate(),
+		size:     cfg.Size,
+		notEmpty: make(chan struct{}, 1),
+		notFull:  make(chan struct{}, 1),
+	}
+}
+
+// signalChan sends on 'ch' without blocking.
+func signalChan(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// produce runs in the goroutine started by the first Read.
+func (p *prefetchReader[T]) produce(ctx context.Context) {
+	defer close(p.done)
+
+	for {
+		v, err := p.r.Read(ctx)
+
+		var n int64
+		if err == nil {
+			if n, err = reserveGate(ctx, p.gate, v); err != nil {
+				// Stopped by Close.
+				return
+			}
+		}
+
+		p.mx.Lock()
+		if err != nil {
+			if p.srcErr == nil {
+				p.srcErr = err
+			}
+
+			p.mx.Unlock()
+			signalChan(p.notEmpty)
+			return
+		}
+
+		p.q = append(p.q, v)
+		p.ns = append(p.ns, n)
+		for len(p.q) >= p.size && !p.closed {
+			p.blocked = true
+			p.mx.Unlock()
+			signalChan(p.notEmpty)
+
+			select {
+			case <-p.notFull:
+			case <-ctx.Done():
+			}
+
+			p.mx.Lock()
+		}
+
+		closed := p.closed
+		p.mx.Unlock()
+		signalChan(p.notEmpty)
+
+		if closed {
+			return
+		}
+	}
+}
+
+func (p *prefetchReader[T]) Read(ctx context.Context) (val T, err error) {
+	p.start.Do(func() {
+		bg := context.Background()
+		if ctx != nil {
+			bg = context.WithoutCancel(ctx)
+		}
+
+		p.mx.Lock()
+		defer p.mx.Unlock()
+		if p.closed {
+			close(p.done)
+			return
+		}
+
+		bg, p.stop = context.WithCancel(bg)
+		go p.produce(bg)
+	})
+
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+
+	waited := false
+	for {
+		p.mx.Lock()
+		if p.closed {
+			p.mx.Unlock()
+			return val, io.EOF
+		}
+
+		if len(p.q) > 0 {
+			val, n := p.q[0], p.ns[0]
+			p.q, p.ns = p.q[1:], p.ns[1:]
+			p.adapt(waited)
+			p.mx.Unlock()
+
+			p.gate.release(n)
+			signalChan(p.notFull)
+			return val, nil
+		}
+
+		if p.srcErr != nil {
+			err = p.srcErr
+			p.mx.Unlock()
+			return val, err
+		}
+
+		p.mx.Unlock()
+		waited = true
+
+		select {
+		case <-p.notEmpty:
+		case <-done:
+			return val, ctx.Err()
+		}
+	}
+}
+
+// adapt adjusts the size after a read, see PrefetchCfg.Adaptive. It must be
+// called while holding p.mx.
+func (p *prefetchReader[T]) adapt(waited bool) {
+	if !p.cfg.Adaptive {
+		return
+	}
+
+	switch {
+	case waited && p.blocked:
+		p.size = min(p.size*2, p.cfg.MaxSize)
+		p.blocked, p.streak = false, 0
+	case waited:
+		p.streak = 0
+	default:
+		p.streak++
+		if p.streak >= p.size*4 {
+			p.size = max(p.size/2, p.cfg.MinSize)
+			p.blocked, p.streak = false, 0
+		}
+	}
+}
+
+func (p *prefetchReader[T]) Close() error {
+	p.mx.Lock()
+	if p.closed {
+		p.mx.Unlock()
+		return nil
+	}
+
+	p.closed = true
+	p.q, p.ns = nil, nil
+	p.stop()
+	p.mx.Unlock()
+
+	// Marking the goroutine as done if it was never started.
+	p.start.Do(func() { close(p.done) })
+	signalChan(p.notFull)
+	<-p.done
+	p.gate.releaseAll()
+	return nil
+}
@@ -0,0 +1,101 @@
+package iox
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestNewReaderWithPrefetchIdeal(t *testing.T) {
+	r := NewReaderWithPrefetch(NewReaderFrom(1, 2, 3))(PrefetchCfg{Size: 2})
+	defer r.Close()
+
+	for _, want := range []int{1, 2, 3} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := r.Read(nil)
+		assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestNewReaderWithPrefetchWithErr(t *testing.T) {
+	src := newResultReader([]int{1, 0}, []error{nil, io.ErrUnexpectedEOF})
+	r := NewReaderWithPrefetch(src)(PrefetchCfg{})
+	defer r.Close()
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", io.ErrUnexpectedEOF, err, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithPrefetchWithClose(t *testing.T) {
+	// Blocks until its ctx is done, i.e until Close.
+	src := ReaderImpl[int]{
+		Impl: func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		},
+	}
+
+	r := NewReaderWithPrefetch[int](src)(PrefetchCfg{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+
+	_, err := r.Read(ctx)
+	assertEq("err", context.DeadlineExceeded, err, func(s string) { t.Fatal(s) })
+
+	assertEq("err", *new(error), r.Close(), func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithPrefetchWithCloseBeforeRead(t *testing.T) {
+	r := NewReaderWithPrefetch(NewReaderFrom(1))(PrefetchCfg{})
+	assertEq("err", *new(error), r.Close(), func(s string) { t.Fatal(s) })
+
+	_, err := r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithPrefetchWithNilReader(t *testing.T) {
+	r := NewReaderWithPrefetch[int](nil)(PrefetchCfg{})
+
+	_, err := r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+}
+
+func TestPrefetchReaderAdapt(t *testing.T) {
+	p := newPrefetchReader(NewReaderFromEmpty[int](), PrefetchCfg{Size: 4, Adaptive: true, MinSize: 2, MaxSize: 8})
+
+	// Waiting without the producer being held back does not grow the size.
+	p.adapt(true)
+	assertEq("size", 4, p.size, func(s string) { t.Fatal(s) })
+
+	p.blocked = true
+	p.adapt(true)
+	assertEq("size", 8, p.size, func(s string) { t.Fatal(s) })
+
+	p.blocked = true
+	p.adapt(true)
+	assertEq("size", 8, p.size, func(s string) { t.Fatal(s) })
+
+	for i := 0; i < 8*4; i++ {
+		p.adapt(false)
+	}
+	assertEq("size", 4, p.size, func(s string) { t.Fatal(s) })
+
+	for i := 0; i < 100; i++ {
+		p.adapt(false)
+	}
+	assertEq("size", 2, p.size, func(s string) { t.Fatal(s) })
+}
@@ -0,0 +1,94 @@
+package iox
+
+import "context"
+
+// -----------------------------------------------------------------------------
+// Bounded queues.
+// -----------------------------------------------------------------------------
+
+// QueuePolicy decides what happens when a value arrives at a full buffer, e.g
+// of a BufferedPipe (see PipeCfg) or of a consumer of NewReaderWithBroadcast
+// (see BroadcastCfg).
+type QueuePolicy int
+
+const (
+	// QueueBlock makes the producer wait until there is room (or until its
+	// ctx is done), i.e the producer is slowed down to the pace of the
+	// consumer.
+	QueueBlock QueuePolicy = iota
+	// QueueDropOldest drops the oldest buffered value to make room for the
+	// new one.
+	QueueDropOldest
+	// QueueDropNewest drops the new value.
+	QueueDropNewest
+)
+
+// boundedQueue is a FIFO buffer of at most 'max' values, which applies its
+// QueuePolicy when full. Values are reserved in the Budget of the ctx given to
+// push (see WithBudget), and the queue is also full while it is exceeded. It
+// is not safe for concurrent use; the owner guards it, and wakes up waiters
+// with signalChan.
+type boundedQueue[T any] struct {
+	vs     []T
+	max    int
+	policy QueuePolicy
+
+	// The amount reserved for each value in 'vs'.
+	hold budgetHold
+	ns   []int64
+}
+
+func (q *boundedQueue[T]) len() int {
+	return len(q.vs)
+}
+
+// full returns true if there is no room. An empty queue always has room.
+func (q *boundedQueue[T]) full() bool {
+	return len(q.vs) >= q.max || (len(q.vs) > 0 && q.hold.exceeded())
+}
+
+// push adds 'v' as decided by the policy. It returns false if the queue is
+// full and the policy is QueueBlock, i.e when the caller has to wait for room.
+// With 'force', 'v' is added when there is room, even if the Budget can not
+// take it, e.g because the caller already waited for room.
+func (q *boundedQueue[T]) push(ctx context.Context, v T, force bool) bool {
+	for {
+		if !q.full() {
+			n, err := reserveOne(ctx, &q.hold, v)
+			if err == nil || force || len(q.vs) == 0 {
+				q.vs = append(q.vs, v)
+				q.ns = append(q.ns, n)
+				return true
+			}
+		}
+
+		switch q.policy {
+		case QueueDropOldest:
+			q.pop()
+		case QueueDropNewest:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// pop removes and returns the oldest value, if any.
+func (q *boundedQueue[T]) pop() (v T, ok bool) {
+	if len(q.vs) == 0 {
+		return v, false
+	}
+
+	v = q.vs[0]
+	q.vs = q.vs[1:]
+	q.hold.releaseOne(q.ns[0])
+	q.ns = q.ns[1:]
+	return v, true
+}
+
+// clear drops every value.
+func (q *boundedQueue[T]) clear() {
+	q.vs = nil
+	q.ns = nil
+	q.hold.release()
+}
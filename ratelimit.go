@@ -0,0 +1,213 @@
+package iox
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// Rate limiting.
+// -----------------------------------------------------------------------------
+
+// Limiter limits the rate of events, e.g Reads or Writes. Wait blocks until an
+// event is allowed, or returns an err if it can not be (e.g the ctx is done).
+// It is satisfied by *rate.Limiter from golang.org/x/time/rate, as well as by
+// the Limiter returned by NewLimiter.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// NewLimiter returns a token bucket Limiter which allows one event per
+// 'every', with bursts of up to 'burst' events. The bucket starts full. Time
+// is measured with the Clock in the ctx given to Wait (see ClockFrom). It is
+// safe for concurrent use. 'every' <= 0 allows all events; 'burst' <= 0
+// defaults to 1.
+//
+// Example:
+//
+//	l := NewLimiter(time.Second/100, 10) // 100 per second, bursts of 10.
+func NewLimiter(every time.Duration, burst int) Limiter {
+	if burst <= 0 {
+		burst = 1
+	}
+
+	return &tokenBucket{every: every, burst: float64(burst), tokens: float64(burst)}
+}
+
+type tokenBucket struct {
+	every time.Duration
+	burst float64
+
+	mx     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	if err := ctxErr(ctx); err != nil {
+		return err
+	}
+	if b.every <= 0 {
+		return nil
+	}
+
+	b.mx.Lock()
+	now := ClockFrom(ctx).Now()
+	if !b.last.IsZero() {
+		b.tokens = min(b.tokens+float64(now.Sub(b.last))/float64(b.every), b.burst)
+	}
+
+	// Reserving a token, possibly ahead of time, such that concurrent
+	// waiters queue up rather than wake up at once.
+	b.last = now
+	b.tokens--
+	d := time.Duration(-b.tokens * float64(b.every))
+	b.mx.Unlock()
+
+	if err := SleepCtx(ctx, d); err != nil {
+		b.mx.Lock()
+		b.tokens++
+		b.mx.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// NewReaderWithRateLimit returns a reader of values from 'r' which waits on
+// 'limit' before each Read of 'r', e.g to stay within the quota of an API
+// behind 'r'. An err from 'limit' (e.g a ctx err) is returned without reading
+// from 'r'. Nil 'r' returns an empty non-nil Reader; nil 'limit' returns 'r'.
+//
+// Example:
+//
+//	r := NewReaderWithRateLimit(apiReader, NewLimiter(time.Second/10, 1)) // 10/s.
+func NewReaderWithRateLimit[T any](r Reader[T], limit Limiter) Reader[T] {
+	if r == nil {
+		return nilReader[T]()
+	}
+	if limit == nil {
+		return r
+	}
+
+	return ReaderImpl[T]{
+		Wraps: r,
+		Impl: func(ctx context.Context) (val T, err error) {
+			if err := limit.Wait(ctx); err != nil {
+				return val, err
+			}
+
+			return r.Read(ctx)
+		},
+	}
+}
+
+// NewWriterWithRateLimit is the Writer analog of NewReaderWithRateLimit: it
+// waits on 'limit' before each Write into 'w', e.g to protect a downstream
+// API with a rate quota. Nil 'w' returns an empty Writer; nil 'limit' returns
+// 'w'.
+//
+// Example:
+//
+//	w := NewWriterWithRateLimit(apiWriter, rate.NewLimiter(50, 5)) // x/time/rate.
+func NewWriterWithRateLimit[T any](w Writer[T], limit Limiter) Writer[T] {
+	if w == nil {
+		return nilWriter[T]()
+	}
+	if limit == nil {
+		return w
+	}
+
+	return WriterImpl[T]{
+		Wraps: w,
+		Impl: func(ctx context.Context, v T) error {
+			if err := limit.Wait(ctx); err != nil {
+				return err
+			}
+
+			return w.Write(ctx, v)
+		},
+	}
+}
+
+// DelayCfg is used to configure NewReaderWithDelay and NewWriterWithDelay.
+type DelayCfg struct {
+	// Delay is the time slept per operation, <= 0 means no delay.
+	Delay time.Duration
+	// Jitter is the max fraction of Delay which is randomly subtracted from
+	// it (as with Backoff), such that concurrent pipelines spread out. It is
+	// clamped to [0, 1], where 0 means no jitter.
+	Jitter float64
+}
+
+func (cfg DelayCfg) next() time.Duration {
+	d := cfg.Delay
+	if jitter := min(max(cfg.Jitter, 0), 1); jitter > 0 && d > 0 {
+		d -= time.Duration(rand.Float64() * jitter * float64(d))
+	}
+
+	return d
+}
+
+// NewReaderWithDelay returns a reader of values from 'r' which sleeps before
+// each Read of 'r', see DelayCfg, e.g for pacing a pipeline or simulating a
+// slow source in tests. The sleep uses the Clock in the ctx (see ClockFrom),
+// and is interrupted by the ctx, in which case the ctx err is returned without
+// reading from 'r'. Nil 'r' returns an empty non-nil Reader.
+//
+// Example:
+//
+//	r := NewReaderWithDelay(NewReaderFrom(1, 2, 3))(
+//		DelayCfg{Delay: time.Millisecond * 50, Jitter: 0.2},
+//	)
+func NewReaderWithDelay[T any](r Reader[T]) func(cfg DelayCfg) Reader[T] {
+	return func(cfg DelayCfg) Reader[T] {
+		if r == nil {
+			return nilReader[T]()
+		}
+
+		return ReaderImpl[T]{
+			Wraps: r,
+			Impl: func(ctx context.Context) (val T, err error) {
+				if err := ctxErr(ctx); err != nil {
+					return val, err
+				}
+				if err := SleepCtx(ctx, cfg.next()); err != nil {
+					return val, err
+				}
+
+				return r.Read(ctx)
+			},
+		}
+	}
+}
+
+// NewWriterWithDelay is the Writer analog of NewReaderWithDelay: it sleeps
+// before each Write into 'w', see DelayCfg. Nil 'w' returns an empty Writer.
+//
+// Example:
+//
+//	w := NewWriterWithDelay(slowSinkStub)(DelayCfg{Delay: time.Millisecond * 10})
+func NewWriterWithDelay[T any](w Writer[T]) func(cfg DelayCfg) Writer[T] {
+	return func(cfg DelayCfg) Writer[T] {
+		if w == nil {
+			return nilWriter[T]()
+		}
+
+		return WriterImpl[T]{
+			Wraps: w,
+			Impl: func(ctx context.Context, v T) error {
+				if err := ctxErr(ctx); err != nil {
+					return err
+				}
+				if err := SleepCtx(ctx, cfg.next()); err != nil {
+					return err
+				}
+
+				return w.Write(ctx, v)
+			},
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package iox
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// Rate limiting.
+// -----------------------------------------------------------------------------
+
+func TestNewLimiterIdeal(t *testing.T) {
+	clock := &advancingClock{now: time.Unix(100, 0)}
+	ctx := WithClock(context.Background(), clock)
+
+	l := NewLimiter(time.Second, 2)
+	for i := 0; i < 4; i++ {
+		assertEq("err", *new(error), l.Wait(ctx), func(s string) { t.Fatal(s) })
+	}
+
+	// The burst is free, after which each event waits for a token.
+	assertEq("waits", []time.Duration{time.Second, time.Second}, clock.waits, func(s string) { t.Fatal(s) })
+}
+
+func TestNewLimiterWithCancelledCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	l := NewLimiter(time.Hour, 1)
+	assertEq("err", *new(error), l.Wait(ctx), func(s string) { t.Fatal(s) })
+
+	cancel()
+	assertEq("err", true, l.Wait(ctx) == context.Canceled, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithRateLimitIdeal(t *testing.T) {
+	clock := &advancingClock{now: time.Unix(100, 0)}
+	ctx := WithClock(context.Background(), clock)
+
+	s := make([]int, 0, 3)
+	w := NewWriterWithRateLimit(newSliceWriter(&s), NewLimiter(time.Millisecond*100, 1))
+	for i := 1; i <= 3; i++ {
+		assertEq("err", *new(error), w.Write(ctx, i), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("vals", []int{1, 2, 3}, s, func(s string) { t.Fatal(s) })
+	assertEq("waits", 2, len(clock.waits), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithRateLimitIdeal(t *testing.T) {
+	clock := &advancingClock{now: time.Unix(100, 0)}
+	ctx := WithClock(context.Background(), clock)
+
+	r := NewReaderWithRateLimit(NewReaderFrom(1, 2), NewLimiter(time.Second, 1))
+	for _, want := range []int{1, 2} {
+		val, err := r.Read(ctx)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("waits", []time.Duration{time.Second}, clock.waits, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithDelayIdeal(t *testing.T) {
+	clock := &advancingClock{now: time.Unix(100, 0)}
+	ctx := WithClock(context.Background(), clock)
+
+	r := NewReaderWithDelay(NewReaderFrom(1, 2))(DelayCfg{Delay: time.Second})
+	for _, want := range []int{1, 2} {
+		val, err := r.Read(ctx)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(ctx)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+
+	// Also slept before the Read which gave io.EOF.
+	want := []time.Duration{time.Second, time.Second, time.Second}
+	assertEq("waits", want, clock.waits, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithDelayJitter(t *testing.T) {
+	clock := &advancingClock{now: time.Unix(100, 0)}
+	ctx := WithClock(context.Background(), clock)
+
+	r := NewReaderWithDelay(NewReaderFrom(1, 2, 3))(DelayCfg{Delay: time.Second, Jitter: 0.5})
+	for i := 0; i < 3; i++ {
+		_, err := r.Read(ctx)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	}
+
+	for _, d := range clock.waits {
+		assertEq("in range", true, d > time.Second/2 && d <= time.Second, func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestNewWriterWithDelayIdeal(t *testing.T) {
+	clock := &advancingClock{now: time.Unix(100, 0)}
+	ctx := WithClock(context.Background(), clock)
+
+	s := make([]int, 0, 2)
+	w := NewWriterWithDelay(newSliceWriter(&s))(DelayCfg{Delay: time.Millisecond})
+	for i := 1; i <= 2; i++ {
+		assertEq("err", *new(error), w.Write(ctx, i), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("vals", []int{1, 2}, s, func(s string) { t.Fatal(s) })
+	assertEq("waits", 2, len(clock.waits), func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithDelayWithCancelledCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s := make([]int, 0)
+	w := NewWriterWithDelay(newSliceWriter(&s))(DelayCfg{Delay: time.Hour})
+	assertEq("err", true, w.Write(ctx, 1) == context.Canceled, func(s string) { t.Fatal(s) })
+	assertEq("vals", []int{}, s, func(s string) { t.Fatal(s) })
+}
@@ -2,9 +2,16 @@ package iox
 
 import (
 	"bytes"
+	"container/list"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
 )
 
 // -----------------------------------------------------------------------------
@@ -12,6 +19,8 @@ import (
 // -----------------------------------------------------------------------------
 
 // Reader reads T, it is intended as a generic variant of io.Reader.
+// Readers of this package return the ctx err when the ctx given to Read is
+// done; a nil ctx is allowed and never done.
 type Reader[T any] interface {
 	Read(context.Context) (T, error)
 }
@@ -33,6 +42,8 @@ type Reader[T any] interface {
 //	}
 type ReaderImpl[T any] struct {
 	Impl func(context.Context) (T, error)
+	// Wraps is optionally the component this one wraps, see Wrapper.
+	Wraps any
 }
 
 // Read implements Reader by deferring to the internal "Impl" func.
@@ -43,7 +54,12 @@ func (impl ReaderImpl[T]) Read(ctx context.Context) (r T, err error) {
 		return
 	}
 
-	return impl.Impl(ctx)
+	return impl.Impl(orBackground(ctx))
+}
+
+// Unwrap implements Wrapper by returning the internal "Wraps" value.
+func (impl ReaderImpl[T]) Unwrap() any {
+	return impl.Wraps
 }
 
 // -----------------------------------------------------------------------------
@@ -64,6 +80,8 @@ type ReadCloser[T any] interface {
 type ReadCloserImpl[T any] struct {
 	ImplC func() error
 	ImplR func(context.Context) (T, error)
+	// Wraps is optionally the component this one wraps, see Wrapper.
+	Wraps any
 }
 
 // Read implements Closer by deferring to the internal "ImplC" func.
@@ -84,7 +102,12 @@ func (impl ReadCloserImpl[T]) Read(ctx context.Context) (r T, err error) {
 		return
 	}
 
-	return impl.ImplR(ctx)
+	return impl.ImplR(orBackground(ctx))
+}
+
+// Unwrap implements Wrapper by returning the internal "Wraps" value.
+func (impl ReadCloserImpl[T]) Unwrap() any {
+	return impl.Wraps
 }
 
 // -----------------------------------------------------------------------------
@@ -92,24 +115,367 @@ func (impl ReadCloserImpl[T]) Read(ctx context.Context) (r T, err error) {
 // -----------------------------------------------------------------------------
 
 // NewReaderFrom returns a Reader which yields values from the given vals.
+// The returned Reader implements Lener, reporting the number of values left.
 //
 // Example (interactive):
 //   - https://go.dev/play/p/bP73PU1mQvf
 func NewReaderFrom[T any](vs ...T) Reader[T] {
-	i := 0
+	return &sliceReader[T]{vs: vs}
+}
+
+// sliceReader is the Reader (and Lener) returned by NewReaderFrom.
+type sliceReader[T any] struct {
+	vs []T
+}
+
+func (r *sliceReader[T]) Read(ctx context.Context) (val T, err error) {
+	if err := ctxErr(ctx); err != nil {
+		return val, err
+	}
+	if len(r.vs) == 0 {
+		return val, io.EOF
+	}
+
+	val = r.vs[0]
+	r.vs = r.vs[1:]
+	return
+}
+
+func (r *sliceReader[T]) Len() int {
+	return len(r.vs)
+}
+
+// NewReaderFromEmpty returns a Reader which is always empty, i.e it returns
+// io.EOF on every Read. Constructors in this package return an equivalent
+// Reader when given a nil Reader, unless strict nil handling is enabled, see
+// SetStrictNil.
+func NewReaderFromEmpty[T any]() Reader[T] {
+	return ReaderImpl[T]{
+		Impl: func(ctx context.Context) (val T, err error) {
+			if err := ctxErr(ctx); err != nil {
+				return val, err
+			}
+
+			return val, io.EOF
+		},
+	}
+}
+
+// NewReaderFromErr returns a Reader which returns 'err' on every Read. Nil
+// 'err' is treated as io.EOF, i.e the same as NewReaderFromEmpty, since a
+// Reader which never fails would yield zero values forever.
+func NewReaderFromErr[T any](err error) Reader[T] {
+	if err == nil {
+		err = io.EOF
+	}
+
+	return ReaderImpl[T]{
+		Impl: func(ctx context.Context) (val T, _ error) {
+			if err := ctxErr(ctx); err != nil {
+				return val, err
+			}
+
+			return val, err
+		},
+	}
+}
+
+// NewReaderFromMulti returns a Reader which is the logical concatenation of
+// the given readers, similar to io.MultiReader. Values are read from each
+// Reader in order, moving on to the next one on io.EOF; other errors are
+// returned immediately, and the same Reader is read again on the next Read.
+// Nil readers are skipped.
+//
+// Example:
+//
+//	r := NewReaderFromMulti(NewReaderFrom(1, 2), NewReaderFrom(3))
+//
+//	t.Log(r.Read(nil)) // 1, nil
+//	t.Log(r.Read(nil)) // 2, nil
+//	t.Log(r.Read(nil)) // 3, nil
+//	t.Log(r.Read(nil)) // 0, io.EOF
+func NewReaderFromMulti[T any](rs ...Reader[T]) Reader[T] {
+	// Copying, so the caller can not modify the slice while it is in use.
+	rs = slices.Clone(rs)
+
+	return ReaderImpl[T]{
+		Impl: func(ctx context.Context) (val T, err error) {
+			for len(rs) > 0 {
+				if rs[0] == nil {
+					rs = rs[1:]
+					continue
+				}
+
+				val, err = rs[0].Read(ctx)
+				if err != io.EOF {
+					return val, err
+				}
+
+				rs = rs[1:]
+			}
+
+			return val, io.EOF
+		},
+	}
+}
+
+// NewReaderFromChan returns a Reader of values received from 'ch'. Read
+// returns io.EOF once 'ch' is closed (and drained), and the ctx err if the ctx
+// is done before a value is received. Nil 'ch' returns an empty non-nil Reader.
+//
+// Example:
+//
+//	ch := make(chan int, 2)
+//	ch <- 1
+//	close(ch)
+//
+//	r := NewReaderFromChan(ch)
+//	t.Log(r.Read(nil)) // 1, nil
+//	t.Log(r.Read(nil)) // 0, io.EOF
+func NewReaderFromChan[T any](ch <-chan T) Reader[T] {
+	if ch == nil {
+		return nilReader[T]()
+	}
+
 	return ReaderImpl[T]{
 		Impl: func(ctx context.Context) (val T, err error) {
-			if i >= len(vs) {
+			if err := ctxErr(ctx); err != nil {
+				return val, err
+			}
+
+			select {
+			case v, ok := <-ch:
+				if !ok {
+					return val, io.EOF
+				}
+
+				return v, nil
+			case <-ctx.Done():
+				return val, ctx.Err()
+			}
+		},
+	}
+}
+
+// NewReaderFromPoller returns a ReadCloser of values sampled from 'f' at the
+// given interval, e.g to turn an in-process gauge (such as an expvar or a
+// queue length) into a stream. The first Read samples at once, after which
+// each Read waits until 'interval' has passed since the previous sample,
+// according to the Clock in the ctx (see ClockFrom). A slow consumer does not
+// cause a burst of samples to catch up; the next one is then taken at once.
+// Close stops the polling, interrupting a waiting Read, after which Read
+// returns io.EOF. Nil 'f' returns an empty non-nil ReadCloser; 'interval' <= 0
+// samples on every Read without waiting.
+//
+// Example:
+//
+//	r := NewReaderFromPoller(func() int { return len(queue) }, time.Second)
+//	defer r.Close()
+//
+//	t.Log(r.Read(ctx)) // E.g 12, nil
+//	t.Log(r.Read(ctx)) // E.g 9, nil, a second later.
+func NewReaderFromPoller[T any](f func() T, interval time.Duration) ReadCloser[T] {
+	if f == nil {
+		return nilReadCloser[T]()
+	}
+
+	return newIntervalReader(func(time.Time) T { return f() }, interval, true)
+}
+
+// NewReaderFromTicker returns a ReadCloser of the current time at the given
+// interval, similar to time.Ticker, e.g to drive periodic flushes or
+// heartbeats. Each Read waits until 'interval' has passed since the previous
+// tick (or since the first Read), according to the Clock in the ctx (see
+// ClockFrom), and yields the time of the tick. Like NewReaderFromPoller, a
+// slow consumer does not cause a burst of ticks. Close stops the ticker,
+// interrupting a waiting Read, after which Read returns io.EOF. 'interval' <=
+// 0 ticks on every Read without waiting.
+//
+// Example:
+//
+//	r := NewReaderFromTicker(time.Second * 10)
+//	defer r.Close()
+//
+//	for {
+//		now, err := r.Read(ctx) // Every 10s, until Close or ctx cancel.
+//		...
+//	}
+func NewReaderFromTicker(interval time.Duration) ReadCloser[time.Time] {
+	return newIntervalReader(func(now time.Time) time.Time { return now }, interval, false)
+}
+
+// newIntervalReader returns a ReadCloser which yields sample(now) at most once
+// per interval, see NewReaderFromPoller and NewReaderFromTicker. If
+// 'immediate' is true, the first Read does not wait.
+func newIntervalReader[T any](sample func(now time.Time) T, interval time.Duration, immediate bool) ReadCloser[T] {
+	var once sync.Once
+	stop := make(chan struct{})
+
+	var next time.Time
+	return ReadCloserImpl[T]{
+		ImplC: func() error {
+			once.Do(func() { close(stop) })
+			return nil
+		},
+		ImplR: func(ctx context.Context) (val T, err error) {
+			var done <-chan struct{}
+			if ctx != nil {
+				done = ctx.Done()
+			}
+
+			if isClosed(stop) {
 				return val, io.EOF
 			}
 
-			val = vs[i]
-			i++
-			return
+			clock := ClockFrom(ctx)
+			if next.IsZero() && !immediate {
+				next = clock.Now().Add(interval)
+			}
+
+			if d := next.Sub(clock.Now()); !next.IsZero() && d > 0 {
+				select {
+				case <-clock.After(d):
+				case <-stop:
+					return val, io.EOF
+				case <-done:
+					return val, ctx.Err()
+				}
+			}
+
+			now := clock.Now()
+			next = now.Add(interval)
+			return sample(now), nil
 		},
 	}
 }
 
+// NewCachedReaderFactory returns a func which creates replayable readers of
+// values from a Reader opened with 'open'. The source is opened and drained
+// once, on the first Read of any created Reader, after which all values are
+// cached in memory and replayed for every Reader created by the factory. This
+// is shorthand for NewCachedReaderFactoryWithTTL(open, 0), i.e no expiry.
+//
+// See NewCachedReaderFactoryWithTTL for details.
+func NewCachedReaderFactory[T any](
+	open func(context.Context) (Reader[T], error),
+) func() Reader[T] {
+	return NewCachedReaderFactoryWithTTL(open, 0)
+}
+
+// drainAndClose reads all values from 'r' until io.EOF, and closes 'r' if it
+// is an io.Closer, regardless of read errors. Close errors are joined with
+// the read err.
+func drainAndClose[T any](ctx context.Context, r Reader[T]) (vs []T, err error) {
+	if c, ok := r.(io.Closer); ok {
+		defer func() {
+			if cerr := c.Close(); cerr != nil {
+				err = errors.Join(err, cerr)
+			}
+		}()
+	}
+
+	vs = make([]T, 0, lenHint(r, 0, 0))
+	for r != nil {
+		v, err := r.Read(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		vs = append(vs, v)
+	}
+
+	return vs, nil
+}
+
+// NewCachedReaderFactoryWithTTL is like NewCachedReaderFactory, but the cache
+// expires after the given 'ttl' (<= 0 means never, the Clock in the ctx is
+// used, see ClockFrom), such that the next Reader to start reading re-opens
+// the source. Readers which already started reading keep their snapshot. Nil
+// 'open' makes the factory return empty readers.
+//
+// Errors from 'open' or the opened Reader (other than io.EOF) are returned by
+// the Read that triggered the load, and are not cached, so the next Read will
+// try again. The opened Reader is closed after draining if it is an io.Closer.
+// The factory and its readers are safe for concurrent use, but note that the
+// cached values are shared between readers without being copied.
+//
+// Example:
+//
+//	newReader := NewCachedReaderFactoryWithTTL(
+//		func(ctx context.Context) (Reader[string], error) {
+//			// Expensive, e.g fetch reference data over the network.
+//		},
+//		time.Minute,
+//	)
+//
+//	r1 := newReader() // Opens the source on the first Read.
+//	r2 := newReader() // Replays the cached values.
+func NewCachedReaderFactoryWithTTL[T any](
+	open func(context.Context) (Reader[T], error),
+	ttl time.Duration,
+) func() Reader[T] {
+	if open == nil {
+		return func() Reader[T] { return nilReader[T]() }
+	}
+
+	var mx sync.Mutex
+	var cache []T
+	var cached bool
+	var cachedAt time.Time
+
+	load := func(ctx context.Context) ([]T, error) {
+		mx.Lock()
+		defer mx.Unlock()
+
+		now := ClockFrom(ctx).Now()
+		if cached && (ttl <= 0 || now.Sub(cachedAt) < ttl) {
+			return cache, nil
+		}
+
+		r, err := open(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		vs, err := drainAndClose(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+
+		cache, cached, cachedAt = vs, true, now
+		return cache, nil
+	}
+
+	return func() Reader[T] {
+		var vs []T
+		var started bool
+
+		return ReaderImpl[T]{
+			Impl: func(ctx context.Context) (val T, err error) {
+				if !started {
+					vs, err = load(ctx)
+					if err != nil {
+						return val, err
+					}
+
+					started = true
+				}
+
+				if len(vs) == 0 {
+					return val, io.EOF
+				}
+
+				val = vs[0]
+				vs = vs[1:]
+				return
+			},
+		}
+	}
+}
+
 // NewReaderFromBytes converts an io.Reader (bytes) into a iox.Reader (values).
 // Nil 'r' returns an empty non-nil Reader; nil 'f' uses json.NewDecoder.
 //
@@ -135,7 +501,7 @@ func NewReaderFrom[T any](vs ...T) Reader[T] {
 func NewReaderFromBytes[T any](r io.Reader) func(f decoderFn) Reader[T] {
 	return func(f func(io.Reader) Decoder) Reader[T] {
 		if r == nil {
-			return ReaderImpl[T]{}
+			return nilReader[T]()
 		}
 
 		var d Decoder = json.NewDecoder(r)
@@ -147,6 +513,10 @@ func NewReaderFromBytes[T any](r io.Reader) func(f decoderFn) Reader[T] {
 
 		return ReaderImpl[T]{
 			Impl: func(ctx context.Context) (v T, err error) {
+				if err := ctxErr(ctx); err != nil {
+					return v, err
+				}
+
 				err = d.Decode(&v)
 				return
 			},
@@ -215,6 +585,8 @@ func NewReaderFromValues[T any](r Reader[T]) func(f encoderFn) io.Reader {
 // the given size. Nil 'r' returns an empty non-nil Reader, size <= 0 defaults
 // to 8. Note, the last []T before an err (e.g io.EOF) may be smaller than 'size'.
 //
+// This is shorthand for NewReaderWithBatchingCfg(r)(BatchingCfg{Size: size}).
+//
 // Example (interactive):
 //   - https://go.dev/play/p/Mn3Cipq8-Gy
 //
@@ -227,39 +599,187 @@ func NewReaderFromValues[T any](r Reader[T]) func(f encoderFn) io.Reader {
 //	t.Log(sr.Read(nil)) // [3], nil
 //	t.Log(sr.Read(nil)) // [], io.EOF
 func NewReaderWithBatching[T any](r Reader[T], size int) Reader[[]T] {
-	if r == nil {
-		return ReaderImpl[[]T]{}
-	}
+	return NewReaderWithBatchingCfg(r)(BatchingCfg{Size: size})
+}
 
-	if size <= 0 {
-		size = 8
-	}
+// BatchingCfg is used to configure NewReaderWithBatchingCfg.
+type BatchingCfg struct {
+	// Size is the max length of each batch, <= 0 defaults to 8.
+	Size int
+	// Transient reports whether an err from the underlying Reader should be
+	// returned only once, as opposed to being repeated for all later reads.
+	// This is intended for errors such as context.Canceled, where the caller
+	// may want to continue reading with a new context. Nil means that all
+	// errors are permanent.
+	Transient func(error) bool
+	// Strict makes the reader return ErrShortBatch instead of io.EOF if 'r'
+	// ended mid-batch, i.e if the last batch was smaller than Size. A plain
+	// io.EOF then means that 'r' ended exactly on a batch boundary.
+	Strict bool
+}
 
-	var errCache error
-	return ReaderImpl[[]T]{
-		Impl: func(ctx context.Context) (s []T, err error) {
-			s = make([]T, 0, size)
-			if errCache != nil {
-				return s, errCache
+// NewReaderWithBatchingCfg returns a reader which batches 'r' into slices as
+// specified by the given BatchingCfg. Nil 'r' returns an empty non-nil Reader.
+//
+// If 'r' implements Lener, then batches are never preallocated with a larger
+// capacity than necessary.
+//
+// Values are never dropped due to errors: if 'r' returns an err mid-batch
+// (e.g a ctx is cancelled), then the partially filled batch is returned with
+// a nil err, and the err itself is returned by the following call. If the err
+// is transient (see BatchingCfg.Transient), then the call after that resumes
+// reading from 'r'. See BatchingCfg.Strict for detecting truncated tails.
+//
+// Example:
+//
+//	// Reader which yields 1, 2, context.Canceled, 3, io.EOF.
+//	vr := ...
+//	sr := NewReaderWithBatchingCfg(vr)(
+//		BatchingCfg{
+//			Size:      4,
+//			Transient: func(err error) bool { return err == context.Canceled },
+//		},
+//	)
+//
+//	t.Log(sr.Read(nil)) // [1, 2], nil
+//	t.Log(sr.Read(nil)) // [], context.Canceled
+//	t.Log(sr.Read(nil)) // [3], nil
+//	t.Log(sr.Read(nil)) // [], io.EOF
+func NewReaderWithBatchingCfg[T any](r Reader[T]) func(cfg BatchingCfg) Reader[[]T] {
+	return func(cfg BatchingCfg) Reader[[]T] {
+		if r == nil {
+			return nilReader[[]T]()
+		}
+
+		if cfg.Size <= 0 {
+			cfg.Size = 8
+		}
+
+		var errCache error
+		popErr := func() (err error) {
+			err = errCache
+			if cfg.Transient != nil && cfg.Transient(err) {
+				errCache = nil
 			}
 
-			var v T
-			for i := 0; i < size; i++ {
-				v, errCache = r.Read(ctx)
+			return err
+		}
+
+		return ReaderImpl[[]T]{
+			Impl: func(ctx context.Context) (s []T, err error) {
 				if errCache != nil {
-					break
+					return make([]T, 0), popErr()
+				}
 
+				s = make([]T, 0, lenHint(r, cfg.Size, cfg.Size))
+
+				var v T
+				for i := 0; i < cfg.Size; i++ {
+					if errCache = ctxErr(ctx); errCache != nil {
+						break
+					}
+
+					v, errCache = r.Read(ctx)
+					if errCache != nil {
+						break
+					}
+
+					s = append(s, v)
 				}
 
-				s = append(s, v)
-			}
+				if errCache != nil && len(s) == 0 {
+					return s, popErr()
+				}
 
-			if errCache != nil && len(s) == 0 {
-				return s, errCache
-			}
+				if cfg.Strict && errCache == io.EOF {
+					errCache = ErrShortBatch
+				}
 
-			return s, err
-		},
+				return s, err
+			},
+		}
+	}
+}
+
+// GapBatchingCfg is used to configure NewReaderWithGapBatching.
+type GapBatchingCfg[T any] struct {
+	// Time extracts the timestamp of a value.
+	Time func(T) time.Time
+	// Gap is the max time between consecutive values of a batch, a larger
+	// gap starts a new batch.
+	Gap time.Duration
+	// MaxSize caps the size of a batch, such that a long burst is split into
+	// several batches. <= 0 means no limit.
+	MaxSize int
+}
+
+// NewReaderWithGapBatching returns a reader which batches 'r' into bursts: a
+// new batch is started when the time between consecutive values (as given by
+// GapBatchingCfg.Time) exceeds GapBatchingCfg.Gap. This segments a stream by
+// activity, as opposed to fixed windows or count-based batching. The value
+// which ends a batch is held back as the start of the next one, so a batch is
+// only returned once the value after it is read. Like NewReaderWithBatchingCfg,
+// an err from 'r' mid-batch gives the partial batch first, and the err on the
+// following call. Nil 'r' or GapBatchingCfg.Time returns an empty non-nil
+// Reader.
+//
+// Example:
+//
+//	// Reader of events at 0s, 1s, 10s, 11s.
+//	r := NewReaderWithGapBatching(events)(
+//		GapBatchingCfg[Event]{
+//			Time: func(v Event) time.Time { return v.Time },
+//			Gap:  time.Second * 5,
+//		},
+//	)
+//
+//	t.Log(r.Read(nil)) // [0s, 1s], nil
+//	t.Log(r.Read(nil)) // [10s, 11s], nil
+//	t.Log(r.Read(nil)) // [], io.EOF
+func NewReaderWithGapBatching[T any](r Reader[T]) func(cfg GapBatchingCfg[T]) Reader[[]T] {
+	return func(cfg GapBatchingCfg[T]) Reader[[]T] {
+		if r == nil || cfg.Time == nil {
+			return nilReader[[]T]()
+		}
+
+		var errCache error
+		var pending T
+		var hasPending bool
+
+		return ReaderImpl[[]T]{
+			Impl: func(ctx context.Context) (s []T, err error) {
+				if !hasPending {
+					if errCache != nil {
+						return make([]T, 0), errCache
+					}
+
+					pending, errCache = r.Read(ctx)
+					if errCache != nil {
+						return make([]T, 0), errCache
+					}
+				}
+
+				s = append(make([]T, 0, 1), pending)
+				hasPending = false
+
+				for cfg.MaxSize <= 0 || len(s) < cfg.MaxSize {
+					var v T
+					v, errCache = r.Read(ctx)
+					if errCache != nil {
+						break
+					}
+
+					if cfg.Time(v).Sub(cfg.Time(s[len(s)-1])) > cfg.Gap {
+						pending, hasPending = v, true
+						break
+					}
+
+					s = append(s, v)
+				}
+
+				return s, nil
+			},
+		}
 	}
 }
 
@@ -281,7 +801,7 @@ func NewReaderWithBatching[T any](r Reader[T], size int) Reader[[]T] {
 //	t.Log(vr.Read(nil)) // 0, io.EOF
 func NewReaderWithUnbatching[T any](r Reader[[]T]) Reader[T] {
 	if r == nil {
-		return ReaderImpl[T]{}
+		return nilReader[T]()
 	}
 
 	var errCache error
@@ -319,44 +839,336 @@ func NewReaderWithUnbatching[T any](r Reader[[]T]) Reader[T] {
 	}
 }
 
-// NewReaderWithFilterFn returns a reader of values from 'r', except for those
-// filtered by 'f'. Nil 'r' returns an empty non-nil Reader; nil 'f' returns 'r'.
+// NewReaderWithTake returns a reader which yields at most 'n' values from 'r',
+// and io.EOF after that, without reading any further from 'r'. This mirrors
+// io.LimitReader for value streams. The returned Reader implements Lener, as
+// the lower of the 'n' values left and the Len of 'r' (see AsLener). Nil 'r'
+// or 'n' <= 0 returns an empty non-nil Reader.
 //
-// Example (interactive):
-//   - https://go.dev/play/p/vYCJChGUKF_Y
+// Example:
+//
+//	r := NewReaderWithTake(NewReaderFrom(1, 2, 3), 2)
+//
+//	t.Log(r.Read(nil)) // 1, nil
+//	t.Log(r.Read(nil)) // 2, nil
+//	t.Log(r.Read(nil)) // 0, io.EOF
+func NewReaderWithTake[T any](r Reader[T], n int) Reader[T] {
+	if r == nil {
+		return nilReader[T]()
+	}
+	if n <= 0 {
+		return NewReaderFromEmpty[T]()
+	}
+
+	return lenReader[T]{
+		ReaderImpl: ReaderImpl[T]{
+			Wraps: r,
+			Impl: func(ctx context.Context) (val T, err error) {
+				if n <= 0 {
+					return val, io.EOF
+				}
+
+				val, err = r.Read(ctx)
+				if err == nil {
+					n--
+				}
+
+				return val, err
+			},
+		},
+		// At most the 'n' values left, fewer if 'r' has fewer.
+		len: func() int {
+			if l, ok := AsLener(r); ok && l.Len() >= 0 {
+				return min(n, l.Len())
+			}
+
+			return -1
+		},
+	}
+}
+
+// NewReaderWithTakeWhileFn returns a reader which yields values from 'r' while
+// they satisfy 'f'. The first value which does not is dropped, and io.EOF is
+// returned from then on, without reading any further from 'r'. Nil 'r'
+// returns an empty non-nil Reader; nil 'f' returns 'r'.
 //
 // Example:
 //
-//	r := NewReaderFrom(1, 2, 3)
-//	r = NewReaderWithFilterFn(r)(
+//	r := NewReaderWithTakeWhileFn(NewReaderFrom(1, 2, 3, 1))(
 //		func(v int) bool {
-//			return v > 1
+//			return v < 3
 //		},
 //	)
 //
+//	t.Log(r.Read(nil)) // 1, nil
 //	t.Log(r.Read(nil)) // 2, nil
-//	t.Log(r.Read(nil)) // 3, nil
 //	t.Log(r.Read(nil)) // 0, io.EOF
-func NewReaderWithFilterFn[T any](r Reader[T]) func(f func(v T) bool) Reader[T] {
-	return func(f func(v T) bool) Reader[T] {
+func NewReaderWithTakeWhileFn[T any](r Reader[T]) func(f func(T) bool) Reader[T] {
+	return func(f func(T) bool) Reader[T] {
 		if r == nil {
-			return ReaderImpl[T]{}
+			return nilReader[T]()
 		}
 		if f == nil {
 			return r
 		}
 
-		return ReaderImpl[T]{
-			Impl: func(ctx context.Context) (val T, err error) {
-				for val, err = r.Read(ctx); err == nil; val, err = r.Read(ctx) {
-					if f(val) {
-						return
+		done := false
+		return lenReader[T]{
+			ReaderImpl: ReaderImpl[T]{
+				Wraps: r,
+				Impl: func(ctx context.Context) (val T, err error) {
+					if done {
+						return val, io.EOF
 					}
-				}
 
-				return
-			},
-		}
+					v, err := r.Read(ctx)
+					if err != nil {
+						return val, err
+					}
+
+					if !f(v) {
+						done = true
+						return val, io.EOF
+					}
+
+					return v, nil
+				},
+			},
+			len: unknownLen,
+		}
+	}
+}
+
+// NewReaderWithSkipWhileFn returns a reader which drops values from 'r' while
+// they satisfy 'f', and yields all values from the first one which does not.
+// Nil 'r' returns an empty non-nil Reader; nil 'f' returns 'r'.
+//
+// Example:
+//
+//	r := NewReaderWithSkipWhileFn(NewReaderFrom(1, 2, 3, 1))(
+//		func(v int) bool {
+//			return v < 3
+//		},
+//	)
+//
+//	t.Log(r.Read(nil)) // 3, nil
+//	t.Log(r.Read(nil)) // 1, nil
+//	t.Log(r.Read(nil)) // 0, io.EOF
+func NewReaderWithSkipWhileFn[T any](r Reader[T]) func(f func(T) bool) Reader[T] {
+	return func(f func(T) bool) Reader[T] {
+		if r == nil {
+			return nilReader[T]()
+		}
+		if f == nil {
+			return r
+		}
+
+		skipping := true
+		return lenReader[T]{
+			ReaderImpl: ReaderImpl[T]{
+				Wraps: r,
+				Impl: func(ctx context.Context) (val T, err error) {
+					for {
+						v, err := r.Read(ctx)
+						if err != nil {
+							return val, err
+						}
+
+						if skipping && f(v) {
+							continue
+						}
+
+						skipping = false
+						return v, nil
+					}
+				},
+			},
+			len: unknownLen,
+		}
+	}
+}
+
+// NewReaderWithFilterFn returns a reader of values from 'r', except for those
+// filtered by 'f'. Nil 'r' returns an empty non-nil Reader; nil 'f' returns 'r'.
+//
+// Example (interactive):
+//   - https://go.dev/play/p/vYCJChGUKF_Y
+//
+// Example:
+//
+//	r := NewReaderFrom(1, 2, 3)
+//	r = NewReaderWithFilterFn(r)(
+//		func(v int) bool {
+//			return v > 1
+//		},
+//	)
+//
+//	t.Log(r.Read(nil)) // 2, nil
+//	t.Log(r.Read(nil)) // 3, nil
+//	t.Log(r.Read(nil)) // 0, io.EOF
+func NewReaderWithFilterFn[T any](r Reader[T]) func(f func(v T) bool) Reader[T] {
+	return func(f func(v T) bool) Reader[T] {
+		if r == nil {
+			return nilReader[T]()
+		}
+		if f == nil {
+			return r
+		}
+
+		return lenReader[T]{
+			ReaderImpl: ReaderImpl[T]{
+				Wraps: r,
+				Impl: func(ctx context.Context) (val T, err error) {
+					for val, err = r.Read(ctx); err == nil; val, err = r.Read(ctx) {
+						if f(val) {
+							return
+						}
+					}
+
+					return
+				},
+			},
+			len: unknownLen,
+		}
+	}
+}
+
+// NewReaderWithFilterFnCtx is like NewReaderWithFilterFn, except that 'f' gets
+// the ctx given to Read, e.g to honor deadlines or use request-scoped values.
+// Nil 'r' returns an empty non-nil Reader; nil 'f' returns 'r'.
+func NewReaderWithFilterFnCtx[T any](r Reader[T]) func(f func(ctx context.Context, v T) bool) Reader[T] {
+	return func(f func(ctx context.Context, v T) bool) Reader[T] {
+		if r == nil {
+			return nilReader[T]()
+		}
+		if f == nil {
+			return r
+		}
+
+		return lenReader[T]{
+			ReaderImpl: ReaderImpl[T]{
+				Wraps: r,
+				Impl: func(ctx context.Context) (val T, err error) {
+					for val, err = r.Read(ctx); err == nil; val, err = r.Read(ctx) {
+						if f(ctx, val) {
+							return
+						}
+					}
+
+					return
+				},
+			},
+			len: unknownLen,
+		}
+	}
+}
+
+// NewReaderWithSampleNth returns a reader which yields every n-th value from
+// 'r', starting with the first, e.g to downsample a high-volume stream for
+// logging. Nil 'r' returns an empty non-nil Reader; 'n' <= 1 returns 'r'.
+//
+// Example:
+//
+//	r := NewReaderWithSampleNth(NewReaderFrom(1, 2, 3, 4, 5), 2)
+//
+//	t.Log(r.Read(nil)) // 1, nil
+//	t.Log(r.Read(nil)) // 3, nil
+//	t.Log(r.Read(nil)) // 5, nil
+//	t.Log(r.Read(nil)) // 0, io.EOF
+func NewReaderWithSampleNth[T any](r Reader[T], n int) Reader[T] {
+	if r == nil {
+		return nilReader[T]()
+	}
+	if n <= 1 {
+		return r
+	}
+
+	return NewReaderWithFilterFn(r)(newSampleNthFn[T](n))
+}
+
+// NewReaderWithSampleProb returns a reader which yields each value from 'r'
+// with probability 'p', e.g to downsample a high-volume stream for metrics.
+// The randomness is seeded with 'seed', so the same seed gives the same sample
+// of the same stream. Nil 'r' returns an empty non-nil Reader; 'p' >= 1
+// returns 'r', and 'p' <= 0 yields nothing (while still draining 'r').
+//
+// Example:
+//
+//	r := NewReaderWithSampleProb(requests, 0.01, 42) // ~1% of the requests.
+func NewReaderWithSampleProb[T any](r Reader[T], p float64, seed uint64) Reader[T] {
+	if r == nil {
+		return nilReader[T]()
+	}
+	if p >= 1 {
+		return r
+	}
+
+	return NewReaderWithFilterFn(r)(newSampleProbFn[T](p, seed))
+}
+
+// newSampleNthFn returns a filter func which is true for every n-th value,
+// starting with the first.
+func newSampleNthFn[T any](n int) func(T) bool {
+	i := 0
+	return func(T) bool {
+		ok := i == 0
+		i = (i + 1) % n
+		return ok
+	}
+}
+
+// newSampleProbFn returns a filter func which is true with probability 'p'.
+func newSampleProbFn[T any](p float64, seed uint64) func(T) bool {
+	rng := rand.New(rand.NewPCG(seed, seed))
+	return func(T) bool {
+		return rng.Float64() < p
+	}
+}
+
+// NewReaderWithAssertFn returns a reader of values from 'r' which checks each
+// value with 'check'. This formalizes "this should never happen" checks, and
+// is mostly intended for development and testing. If 'check' returns an err,
+// Read returns an err which wraps both ErrContract and that err, and which
+// renders the offending value (with %+v); the value is not yielded. Nil 'r'
+// returns an empty non-nil Reader; nil 'check' returns 'r'.
+//
+// Example:
+//
+//	r := NewReaderWithAssertFn(NewReaderFrom(1, -1))(
+//		func(v int) error {
+//			if v < 0 {
+//				return errors.New("negative")
+//			}
+//			return nil
+//		},
+//	)
+//
+//	t.Log(r.Read(nil)) // 1, nil
+//	t.Log(r.Read(nil)) // 0, iox: contract violation: negative: -1
+func NewReaderWithAssertFn[T any](r Reader[T]) func(check func(T) error) Reader[T] {
+	return func(check func(T) error) Reader[T] {
+		if r == nil {
+			return nilReader[T]()
+		}
+		if check == nil {
+			return r
+		}
+
+		return ReaderImpl[T]{
+			Wraps: r,
+			Impl: func(ctx context.Context) (val T, err error) {
+				v, err := r.Read(ctx)
+				if err != nil {
+					return val, err
+				}
+
+				if err := check(v); err != nil {
+					return val, fmt.Errorf("%w: %w: %+v", ErrContract, err, v)
+				}
+
+				return v, nil
+			},
+		}
 	}
 }
 
@@ -381,10 +1193,11 @@ func NewReaderWithFilterFn[T any](r Reader[T]) func(f func(v T) bool) Reader[T]
 func NewReaderWithMapperFn[T, U any](r Reader[T]) func(f func(T) U) Reader[U] {
 	return func(f func(T) U) Reader[U] {
 		if r == nil || f == nil {
-			return ReaderImpl[U]{}
+			return nilReader[U]()
 		}
 
 		return ReaderImpl[U]{
+			Wraps: r,
 			Impl: func(ctx context.Context) (valOut U, err error) {
 				valIn, err := r.Read(ctx)
 				if err != nil {
@@ -396,3 +1209,1137 @@ func NewReaderWithMapperFn[T, U any](r Reader[T]) func(f func(T) U) Reader[U] {
 		}
 	}
 }
+
+// NewReaderWithMapperFnCtx is like NewReaderWithMapperFn, except that 'f' gets
+// the ctx given to Read. See NewReaderWithMapperFnE for a mapper which may
+// also fail. An empty non-nil Reader is returned if either 'r' or 'f' is nil.
+func NewReaderWithMapperFnCtx[T, U any](r Reader[T]) func(f func(ctx context.Context, v T) U) Reader[U] {
+	return func(f func(ctx context.Context, v T) U) Reader[U] {
+		if f == nil {
+			return nilReader[U]()
+		}
+
+		return NewReaderWithMapperFnE[T, U](r)(
+			func(ctx context.Context, v T) (U, error) {
+				return f(ctx, v), nil
+			},
+		)
+	}
+}
+
+// NewReaderWithMapperFnE is like NewReaderWithMapperFn, except that 'f' gets
+// the ctx given to Read and may fail, e.g when parsing or validating values.
+// An err from 'f' is returned by Read as-is. An empty non-nil Reader is
+// returned if either 'r' or 'f' is nil.
+//
+// Example:
+//
+//	ri := NewReaderFrom("1", "x")
+//	rs := NewReaderWithMapperFnE[string, int](ri)(
+//		func(ctx context.Context, v string) (int, error) {
+//			return strconv.Atoi(v)
+//		},
+//	)
+//
+//	t.Log(rs.Read(nil)) // 1, nil
+//	t.Log(rs.Read(nil)) // 0, strconv.Atoi: parsing "x": invalid syntax
+//	t.Log(rs.Read(nil)) // 0, io.EOF
+func NewReaderWithMapperFnE[T, U any](r Reader[T]) func(f func(context.Context, T) (U, error)) Reader[U] {
+	return func(f func(context.Context, T) (U, error)) Reader[U] {
+		if r == nil || f == nil {
+			return nilReader[U]()
+		}
+
+		return ReaderImpl[U]{
+			Wraps: r,
+			Impl: func(ctx context.Context) (valOut U, err error) {
+				valIn, err := r.Read(ctx)
+				if err != nil {
+					return valOut, err
+				}
+
+				return f(ctx, valIn)
+			},
+		}
+	}
+}
+
+// NewReaderWithFlatMapFn returns a reader which maps each value from 'r' into
+// a Reader with 'f', and yields all values of that Reader (until io.EOF) before
+// moving on to the next value from 'r'. A nil Reader from 'f' yields nothing.
+// A non-EOF err from a Reader given by 'f' is returned, and that Reader is
+// abandoned. An empty non-nil Reader is returned if either 'r' or 'f' is nil.
+//
+// Example:
+//
+//	r := NewReaderWithFlatMapFn[int, int](NewReaderFrom(1, 2))(
+//		func(v int) Reader[int] {
+//			return NewReaderFrom(v, v*10)
+//		},
+//	)
+//
+//	t.Log(r.Read(nil)) // 1, nil
+//	t.Log(r.Read(nil)) // 10, nil
+//	t.Log(r.Read(nil)) // 2, nil
+//	t.Log(r.Read(nil)) // 20, nil
+//	t.Log(r.Read(nil)) // 0, io.EOF
+func NewReaderWithFlatMapFn[T, U any](r Reader[T]) func(f func(T) Reader[U]) Reader[U] {
+	return func(f func(T) Reader[U]) Reader[U] {
+		if r == nil || f == nil {
+			return nilReader[U]()
+		}
+
+		var inner Reader[U]
+		return ReaderImpl[U]{
+			Impl: func(ctx context.Context) (val U, err error) {
+				for {
+					if inner != nil {
+						val, err = inner.Read(ctx)
+						if err == nil {
+							return val, nil
+						}
+
+						inner = nil
+						if err != io.EOF {
+							return val, err
+						}
+					}
+
+					v, err := r.Read(ctx)
+					if err != nil {
+						return val, err
+					}
+
+					inner = f(v)
+				}
+			},
+		}
+	}
+}
+
+// NewReaderWithFlatMapSliceFn is like NewReaderWithFlatMapFn, except that 'f'
+// maps each value into a slice. An empty non-nil Reader is returned if either
+// 'r' or 'f' is nil.
+//
+// Example:
+//
+//	r := NewReaderWithFlatMapSliceFn[string, string](NewReaderFrom("a b", "c"))(
+//		strings.Fields,
+//	)
+//
+//	t.Log(r.Read(nil)) // "a", nil
+//	t.Log(r.Read(nil)) // "b", nil
+//	t.Log(r.Read(nil)) // "c", nil
+//	t.Log(r.Read(nil)) // "", io.EOF
+func NewReaderWithFlatMapSliceFn[T, U any](r Reader[T]) func(f func(T) []U) Reader[U] {
+	return func(f func(T) []U) Reader[U] {
+		if f == nil {
+			return nilReader[U]()
+		}
+
+		return NewReaderWithFlatMapFn[T, U](r)(
+			func(v T) Reader[U] {
+				return NewReaderFrom(f(v)...)
+			},
+		)
+	}
+}
+
+// NewReaderWithBatchMapperFn returns a reader of batches from 'r', mapped as a
+// whole with 'f'. This lets batch-level transforms (e.g vectorized operations
+// or bulk API calls) be applied without unbatching and rebatching around a
+// per-value mapper. An err from 'f' is returned as-is, and the batch is
+// skipped. An empty non-nil Reader is returned if either 'r' or 'f' is nil.
+//
+// Example:
+//
+//	r := NewReaderWithBatchMapperFn[int, string](NewReaderFrom([]int{1, 2}, []int{3}))(
+//		func(vs []int) ([]string, error) {
+//			return MapSlice(vs, strconv.Itoa), nil
+//		},
+//	)
+//
+//	t.Log(r.Read(nil)) // ["1", "2"], nil
+//	t.Log(r.Read(nil)) // ["3"], nil
+//	t.Log(r.Read(nil)) // [], io.EOF
+func NewReaderWithBatchMapperFn[T, U any](r Reader[[]T]) func(f func([]T) ([]U, error)) Reader[[]U] {
+	return func(f func([]T) ([]U, error)) Reader[[]U] {
+		if r == nil || f == nil {
+			return nilReader[[]U]()
+		}
+
+		return ReaderImpl[[]U]{
+			Wraps: r,
+			Impl: func(ctx context.Context) ([]U, error) {
+				vs, err := r.Read(ctx)
+				if err != nil {
+					return nil, err
+				}
+
+				return f(vs)
+			},
+		}
+	}
+}
+
+// NewReaderWithTee returns a reader which writes each value read from 'r' into
+// 'w' before yielding it, similar to io.TeeReader. This makes it trivial to
+// mirror or audit a stream. An err from 'w' is returned by Read along with
+// the value, as io.TeeReader returns the bytes read along with the err. Nil
+// 'r' returns an empty non-nil Reader; nil 'w' returns 'r'.
+//
+// Example:
+//
+//	// Writes which logs values through 't.Log'.
+//	logWriter := WriterImpl[int]{}
+//	logWriter.Impl = func(_ context.Context, v int) error { t.Log(v); return nil }
+//
+//	r := NewReaderWithTee(NewReaderFrom(1, 2), logWriter)
+//
+//	t.Log(r.Read(nil)) // Logs: 1, then 1, nil
+//	t.Log(r.Read(nil)) // Logs: 2, then 2, nil
+func NewReaderWithTee[T any](r Reader[T], w Writer[T]) Reader[T] {
+	if r == nil {
+		return nilReader[T]()
+	}
+	if w == nil {
+		return r
+	}
+
+	return ReaderImpl[T]{
+		Wraps: r,
+		Impl: func(ctx context.Context) (val T, err error) {
+			val, err = r.Read(ctx)
+			if err != nil {
+				return val, err
+			}
+
+			return val, w.Write(ctx, val)
+		},
+	}
+}
+
+// NewReaderWithOnReadFn returns a reader of values from 'r' which calls 'f'
+// with the result of every Read of 'r', without altering it, e.g for logging,
+// debugging or metrics. Nil 'r' returns an empty non-nil Reader; nil 'f'
+// returns 'r'.
+//
+// Example:
+//
+//	r := NewReaderWithOnReadFn(NewReaderFrom(1))(
+//		func(v int, err error) { t.Log("read:", v, err) },
+//	)
+//
+//	t.Log(r.Read(nil)) // Logs: read: 1 <nil>, then 1, nil
+//	t.Log(r.Read(nil)) // Logs: read: 0 EOF, then 0, io.EOF
+func NewReaderWithOnReadFn[T any](r Reader[T]) func(f func(T, error)) Reader[T] {
+	return func(f func(T, error)) Reader[T] {
+		if r == nil {
+			return nilReader[T]()
+		}
+		if f == nil {
+			return r
+		}
+
+		return ReaderImpl[T]{
+			Wraps: r,
+			Impl: func(ctx context.Context) (val T, err error) {
+				val, err = r.Read(ctx)
+				f(val, err)
+				return val, err
+			},
+		}
+	}
+}
+
+// NewReaderWithStopSignal returns a reader of values from 'r' which stops when
+// 'stop' is closed, e.g a legacy done chan or the Done chan of a ctx from
+// signal.NotifyContext. This bridges code which does not thread a ctx through
+// to every Read. After 'stop' is closed, Read returns io.EOF without reading
+// from 'r'. A Read of 'r' which is ongoing when 'stop' is closed gets its ctx
+// cancelled, and returns io.EOF unless it returns a value. 'stop' is watched
+// by one goroutine, started by the first Read, which returns once 'stop' is
+// closed. Nil 'r' returns an empty non-nil Reader; nil 'stop' returns 'r'.
+//
+// Example:
+//
+//	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+//	defer cancel()
+//
+//	r := NewReaderWithStopSignal(events, ctx.Done())
+//	for {
+//		v, err := r.Read(nil) // io.EOF after Ctrl+C.
+//		...
+//	}
+func NewReaderWithStopSignal[T any](r Reader[T], stop <-chan struct{}) Reader[T] {
+	if r == nil {
+		return nilReader[T]()
+	}
+	if stop == nil {
+		return r
+	}
+
+	signal := &stopSignal{stop: stop}
+	return ReaderImpl[T]{
+		Wraps: r,
+		Impl: func(ctx context.Context) (val T, err error) {
+			if isClosed(stop) {
+				return val, io.EOF
+			}
+
+			ctx, done := signal.with(ctx)
+			defer done()
+
+			val, err = r.Read(ctx)
+			if err != nil && isClosed(stop) {
+				return val, io.EOF
+			}
+
+			return val, err
+		},
+	}
+}
+
+// isClosed returns true if 'ch' is closed. It is intended for signal chans,
+// which are only ever closed; a value sent on 'ch' would be consumed.
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// stopSignal derives ctxs which are cancelled when 'stop' is closed, see
+// NewReaderWithStopSignal. One goroutine (started by the first call to with)
+// watches 'stop' for all of them, and returns once 'stop' is closed.
+type stopSignal struct {
+	stop <-chan struct{}
+	once sync.Once
+	// Cancelled when 'stop' is closed.
+	ctx context.Context
+}
+
+// with returns a copy of 'ctx' which is also cancelled when 'stop' is closed.
+// The returned func must be called once the copy is no longer used. Nil 'ctx'
+// is treated as context.Background().
+func (s *stopSignal) with(ctx context.Context) (context.Context, func()) {
+	s.once.Do(func() {
+		var cancel context.CancelFunc
+		s.ctx, cancel = context.WithCancel(context.Background())
+		go func() {
+			<-s.stop
+			cancel()
+		}()
+	})
+
+	if ctx == nil {
+		return s.ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	unregister := context.AfterFunc(s.ctx, cancel)
+	return ctx, func() {
+		unregister()
+		cancel()
+	}
+}
+
+// NewReaderWithTimestamps returns a reader which pairs each value read from
+// 'r' with the time it was read, according to the Clock in the ctx (see
+// ClockFrom). Nil 'r' returns an empty non-nil Reader. This is intended to be
+// used with NewReaderWithTTL, see its docs.
+func NewReaderWithTimestamps[T any](r Reader[T]) Reader[Timestamped[T]] {
+	if r == nil {
+		return nilReader[Timestamped[T]]()
+	}
+
+	return ReaderImpl[Timestamped[T]]{
+		Wraps: r,
+		Impl: func(ctx context.Context) (val Timestamped[T], err error) {
+			val.Value, err = r.Read(ctx)
+			if err != nil {
+				return
+			}
+
+			val.Time = ClockFrom(ctx).Now()
+			return
+		},
+	}
+}
+
+// NewReaderWithSequenceCheck returns a reader which unwraps sequenced values
+// from 'r', as written by NewWriterWithSequence, and verifies that their
+// sequence numbers are continuous. The first sequence number read is accepted
+// as-is. A discontinuity (a gap, duplicate or reordering) gives an err
+// wrapping ErrSequenceGap, after which the check resumes from the value which
+// revealed it; that value is yielded by the next Read. Nil 'r' returns an
+// empty non-nil Reader.
+//
+// Example:
+//
+//	r := NewReaderWithSequenceCheck(
+//		NewReaderFrom(
+//			Sequenced[string]{Seq: 0, Value: "a"},
+//			Sequenced[string]{Seq: 2, Value: "c"},
+//		),
+//	)
+//
+//	t.Log(r.Read(nil)) // "a", nil
+//	t.Log(r.Read(nil)) // "", iox: sequence gap: want 1, got 2
+//	t.Log(r.Read(nil)) // "c", nil
+//	t.Log(r.Read(nil)) // "", io.EOF
+func NewReaderWithSequenceCheck[T any](r Reader[Sequenced[T]]) Reader[T] {
+	if r == nil {
+		return nilReader[T]()
+	}
+
+	var next uint64
+	started := false
+	var held *Sequenced[T]
+
+	return ReaderImpl[T]{
+		Impl: func(ctx context.Context) (val T, err error) {
+			if held != nil {
+				v := *held
+				held = nil
+				next = v.Seq + 1
+				return v.Value, nil
+			}
+
+			v, err := r.Read(ctx)
+			if err != nil {
+				return val, err
+			}
+
+			if started && v.Seq != next {
+				held = &v
+				return val, fmt.Errorf("%w: want %d, got %d", ErrSequenceGap, next, v.Seq)
+			}
+
+			started = true
+			next = v.Seq + 1
+			return v.Value, nil
+		},
+	}
+}
+
+// NewReaderWithTTL returns a reader which unwraps timestamped values from 'r',
+// dropping those which are older than 'ttl' at read time, according to the
+// Clock in the ctx (see ClockFrom). This is useful for latency sensitive
+// consumers which would rather skip stale values than spend time on them, e.g
+// after values have been sitting in internal buffers. Nil 'r' returns an empty
+// non-nil Reader; 'ttl' <= 0 drops nothing.
+//
+// Example:
+//
+//	now := time.Now()
+//	r := NewReaderWithTTL(
+//		NewReaderFrom(
+//			Timestamped[int]{Value: 1, Time: now.Add(-time.Hour)},
+//			Timestamped[int]{Value: 2, Time: now},
+//		),
+//		time.Minute,
+//	)
+//
+//	t.Log(r.Read(nil)) // 2, nil <--- 1 expired.
+//	t.Log(r.Read(nil)) // 0, io.EOF
+func NewReaderWithTTL[T any](r Reader[Timestamped[T]], ttl time.Duration) Reader[T] {
+	if r == nil {
+		return nilReader[T]()
+	}
+
+	return lenReader[T]{
+		ReaderImpl: ReaderImpl[T]{
+			Wraps: r,
+			Impl: func(ctx context.Context) (val T, err error) {
+				for {
+					v, err := r.Read(ctx)
+					if err != nil {
+						return val, err
+					}
+
+					if ttl <= 0 || ClockFrom(ctx).Now().Sub(v.Time) <= ttl {
+						return v.Value, nil
+					}
+				}
+			},
+		},
+		len: unknownLen,
+	}
+}
+
+// NewReaderWithPacing returns a reader which yields timestamped values from
+// 'r' with the same spacing in time as their timestamps, multiplied by
+// 'scale', e.g 0.5 replays twice as fast. This is intended for replaying
+// recorded traffic realistically, e.g from a journal of values written through
+// NewWriterWithTimestamps (see ReplayJournal). Pacing is relative to the first
+// value, so delays do not accumulate, and values which are late (or out of
+// order) are yielded immediately. Waiting uses the Clock in the ctx (see
+// ClockFrom), and stops with the ctx err if the ctx is done, in which case the
+// value is held back and yielded by the next Read. Nil 'r' returns an empty
+// non-nil Reader; 'scale' <= 0 returns 'r' (no pacing).
+//
+// Example:
+//
+//	// Values recorded 1s apart.
+//	r := NewReaderWithPacing(recorded, 1)
+//
+//	t.Log(r.Read(ctx)) // Immediately.
+//	t.Log(r.Read(ctx)) // After 1s.
+func NewReaderWithPacing[T any](r Reader[Timestamped[T]], scale float64) Reader[Timestamped[T]] {
+	if r == nil {
+		return nilReader[Timestamped[T]]()
+	}
+	if scale <= 0 {
+		return r
+	}
+
+	var base, start time.Time
+	started := false
+	var held *Timestamped[T]
+
+	return ReaderImpl[Timestamped[T]]{
+		Impl: func(ctx context.Context) (val Timestamped[T], err error) {
+			if held != nil {
+				val, held = *held, nil
+			} else if val, err = r.Read(ctx); err != nil {
+				return
+			}
+
+			clock := ClockFrom(ctx)
+			if !started {
+				base, start, started = val.Time, clock.Now(), true
+				return
+			}
+
+			due := start.Add(time.Duration(float64(val.Time.Sub(base)) * scale))
+			wait := due.Sub(clock.Now())
+			if wait <= 0 {
+				return
+			}
+
+			// Holding the value for the next Read if the ctx is done.
+			if err = SleepCtx(ctx, wait); err != nil {
+				held = &val
+				return *new(Timestamped[T]), err
+			}
+
+			return val, nil
+		},
+	}
+}
+
+// NewReaderWithHashDedup returns a reader of values from 'r', except for those
+// with a content hash (computed by 'hash') equal to that of one of the 'window'
+// most recently yielded values. This is intended for streams where upstream
+// retries produce duplicate payloads without stable IDs. Memory usage is
+// bounded by 'window', which defaults to 1024 if <= 0. Nil 'r' returns an
+// empty non-nil Reader; nil 'hash' returns 'r'.
+//
+// Example:
+//
+//	r := NewReaderFrom("a", "b", "a", "c")
+//	r = NewReaderWithHashDedup(r)(
+//		func(v string) uint64 {
+//			h := fnv.New64a()
+//			h.Write([]byte(v))
+//			return h.Sum64()
+//		},
+//		2,
+//	)
+//
+//	t.Log(r.Read(nil)) // "a", nil
+//	t.Log(r.Read(nil)) // "b", nil
+//	t.Log(r.Read(nil)) // "c", nil <--- "a" was seen within the window.
+//	t.Log(r.Read(nil)) // "", io.EOF
+func NewReaderWithHashDedup[T any](r Reader[T]) func(hash func(T) uint64, window int) Reader[T] {
+	return func(hash func(T) uint64, window int) Reader[T] {
+		if r == nil {
+			return nilReader[T]()
+		}
+		if hash == nil {
+			return r
+		}
+
+		if window <= 0 {
+			window = 1024
+		}
+
+		// Ring buffer of recent hashes, with a multiset for lookups.
+		ring := make([]uint64, 0, window)
+		next := 0
+		seen := make(map[uint64]int, window)
+
+		return lenReader[T]{
+			ReaderImpl: ReaderImpl[T]{
+				Wraps: r,
+				Impl: func(ctx context.Context) (val T, err error) {
+					for val, err = r.Read(ctx); err == nil; val, err = r.Read(ctx) {
+						h := hash(val)
+						if seen[h] > 0 {
+							continue
+						}
+
+						if len(ring) < window {
+							ring = append(ring, h)
+						} else {
+							old := ring[next]
+							if seen[old]--; seen[old] <= 0 {
+								delete(seen, old)
+							}
+
+							ring[next] = h
+							next = (next + 1) % window
+						}
+
+						seen[h]++
+						return
+					}
+
+					return
+				},
+			},
+			len: unknownLen,
+		}
+	}
+}
+
+// NewReaderWithDedupFn returns a reader of values from 'r', except for those
+// with a key (as given by 'key') which was already seen. Note that every key
+// is remembered, so memory usage grows with the amount of distinct keys; it is
+// intended for finite streams, see NewReaderWithDedupWindowFn for long-lived
+// ones. Nil 'r' returns an empty non-nil Reader; nil 'key' returns 'r'.
+//
+// Example:
+//
+//	r := NewReaderWithDedupFn[User, int](NewReaderFrom(u1, u2, u1))(
+//		func(v User) int { return v.ID },
+//	)
+//
+//	t.Log(r.Read(nil)) // u1, nil
+//	t.Log(r.Read(nil)) // u2, nil
+//	t.Log(r.Read(nil)) // User{}, io.EOF
+func NewReaderWithDedupFn[T any, K comparable](r Reader[T]) func(key func(T) K) Reader[T] {
+	return func(key func(T) K) Reader[T] {
+		if r == nil {
+			return nilReader[T]()
+		}
+		if key == nil {
+			return r
+		}
+
+		seen := make(map[K]struct{})
+		return NewReaderWithFilterFn(r)(
+			func(v T) bool {
+				k := key(v)
+				if _, ok := seen[k]; ok {
+					return false
+				}
+
+				seen[k] = struct{}{}
+				return true
+			},
+		)
+	}
+}
+
+// DedupCfg is used to configure NewReaderWithDedupWindowFn and
+// NewWriterWithDedupWindowFn.
+type DedupCfg struct {
+	// TTL is how long a key is remembered after its value was passed on, <= 0
+	// means forever. Expiry is checked with the Clock in the ctx given to
+	// Read/Write, see ClockFrom.
+	TTL time.Duration
+	// MaxEntries limits the amount of remembered keys, the least recently
+	// seen one is forgotten when a new key would exceed it. <= 0 means no
+	// limit.
+	MaxEntries int
+}
+
+// NewReaderWithDedupWindowFn is like NewReaderWithDedupFn, except that keys are
+// forgotten as configured by 'cfg', such that memory usage is bounded on
+// long-lived streams: with MaxEntries, by that amount, and with TTL, by the
+// amount of distinct keys passed on within the TTL (expired keys are pruned
+// whenever a key is added). A forgotten key lets its next value through
+// again. Nil 'r' returns an empty non-nil Reader; nil 'key' returns 'r'.
+//
+// Example:
+//
+//	// Drops repeated alerts for the same host within 5 minutes.
+//	r := NewReaderWithDedupWindowFn[Alert, string](alerts)(
+//		func(v Alert) string { return v.Host },
+//		DedupCfg{TTL: time.Minute * 5, MaxEntries: 100_000},
+//	)
+func NewReaderWithDedupWindowFn[T any, K comparable](r Reader[T]) func(key func(T) K, cfg DedupCfg) Reader[T] {
+	return func(key func(T) K, cfg DedupCfg) Reader[T] {
+		if r == nil {
+			return nilReader[T]()
+		}
+		if key == nil {
+			return r
+		}
+
+		seen := newDedupWindow[K](cfg)
+		return NewReaderWithFilterFnCtx(r)(
+			func(ctx context.Context, v T) bool {
+				k := key(v)
+				now := ClockFrom(ctx).Now()
+				if seen.contains(k, now) {
+					return false
+				}
+
+				seen.add(k, now)
+				return true
+			},
+		)
+	}
+}
+
+// dedupWindow is a set of keys which are forgotten as configured by DedupCfg.
+// Keys are kept in two lists: by recency (front is the most recently seen),
+// for evicting with MaxEntries, and by age (front is the oldest), for pruning
+// expired keys. A hit reorders only the recency list, since the age of a key
+// is from when it was added.
+type dedupWindow[K comparable] struct {
+	cfg     DedupCfg
+	lru     *list.List
+	byAge   *list.List
+	entries map[K]*dedupEntry[K]
+}
+
+type dedupEntry[K comparable] struct {
+	key    K
+	seenAt time.Time
+	lruE   *list.Element
+	ageE   *list.Element
+}
+
+func newDedupWindow[K comparable](cfg DedupCfg) *dedupWindow[K] {
+	return &dedupWindow[K]{
+		cfg:     cfg,
+		lru:     list.New(),
+		byAge:   list.New(),
+		entries: make(map[K]*dedupEntry[K]),
+	}
+}
+
+// expired returns true if 'e' is older than the TTL at 'now'.
+func (d *dedupWindow[K]) expired(e *dedupEntry[K], now time.Time) bool {
+	return d.cfg.TTL > 0 && now.Sub(e.seenAt) >= d.cfg.TTL
+}
+
+// remove forgets the key of 'e'.
+func (d *dedupWindow[K]) remove(e *dedupEntry[K]) {
+	delete(d.entries, e.key)
+	d.lru.Remove(e.lruE)
+	d.byAge.Remove(e.ageE)
+}
+
+// contains returns true if 'k' is remembered (and not expired) at 'now'.
+func (d *dedupWindow[K]) contains(k K, now time.Time) bool {
+	e, ok := d.entries[k]
+	if !ok {
+		return false
+	}
+
+	if d.expired(e, now) {
+		d.remove(e)
+		return false
+	}
+
+	d.lru.MoveToFront(e.lruE)
+	return true
+}
+
+// add remembers 'k' as seen at 'now', forgetting other keys if needed.
+func (d *dedupWindow[K]) add(k K, now time.Time) {
+	for f := d.byAge.Front(); f != nil; f = d.byAge.Front() {
+		e := f.Value.(*dedupEntry[K])
+		if !d.expired(e, now) {
+			break
+		}
+
+		d.remove(e)
+	}
+	if d.cfg.MaxEntries > 0 && d.lru.Len() >= d.cfg.MaxEntries {
+		d.remove(d.lru.Back().Value.(*dedupEntry[K]))
+	}
+
+	e := &dedupEntry[K]{key: k, seenAt: now}
+	e.lruE = d.lru.PushFront(e)
+	e.ageE = d.byAge.PushBack(e)
+	d.entries[k] = e
+}
+
+// NewReaderWithDeltaFn returns a reader of differences between consecutive
+// values from 'r', as computed by 'f'. The first value of 'r' only serves as
+// the initial 'prev', so the returned reader yields one value less than 'r'.
+// An empty non-nil Reader is returned if either 'r' or 'f' is nil.
+//
+// Example:
+//
+//	// Counter samples.
+//	r := NewReaderFrom(10, 15, 25)
+//	d := NewReaderWithDeltaFn[int, int](r)(
+//		func(prev, cur int) int {
+//			return cur - prev
+//		},
+//	)
+//
+//	t.Log(d.Read(nil)) // 5, nil
+//	t.Log(d.Read(nil)) // 10, nil
+//	t.Log(d.Read(nil)) // 0, io.EOF
+func NewReaderWithDeltaFn[T, D any](r Reader[T]) func(f func(prev, cur T) D) Reader[D] {
+	return func(f func(prev, cur T) D) Reader[D] {
+		if r == nil || f == nil {
+			return nilReader[D]()
+		}
+
+		var prev T
+		var started bool
+
+		return ReaderImpl[D]{
+			Wraps: r,
+			Impl: func(ctx context.Context) (val D, err error) {
+				if !started {
+					prev, err = r.Read(ctx)
+					if err != nil {
+						return
+					}
+
+					started = true
+				}
+
+				cur, err := r.Read(ctx)
+				if err != nil {
+					return
+				}
+
+				val = f(prev, cur)
+				prev = cur
+				return
+			},
+		}
+	}
+}
+
+// NewReaderWithRunLength returns a reader which collapses runs of consecutive
+// equal values from 'r' into a single Counted value, i.e run-length encoding.
+// This is useful for compressing repetitive streams, e.g of status events,
+// before shipping them. The value which ends a run is held back for the next
+// Read, and a run which is ended by an err is yielded before the err. Nil 'r'
+// returns an empty non-nil Reader.
+//
+// Example:
+//
+//	r := NewReaderWithRunLength(NewReaderFrom("a", "a", "b", "a"))
+//
+//	t.Log(r.Read(nil)) // {a, 2}, nil
+//	t.Log(r.Read(nil)) // {b, 1}, nil
+//	t.Log(r.Read(nil)) // {a, 1}, nil
+//	t.Log(r.Read(nil)) // {"", 0}, io.EOF
+func NewReaderWithRunLength[T comparable](r Reader[T]) Reader[Counted[T]] {
+	if r == nil {
+		return nilReader[Counted[T]]()
+	}
+
+	var run Counted[T]
+	var srcErr error
+
+	return ReaderImpl[Counted[T]]{
+		Impl: func(ctx context.Context) (val Counted[T], err error) {
+			for srcErr == nil {
+				v, err := r.Read(ctx)
+				if err != nil {
+					srcErr = err
+					break
+				}
+
+				switch {
+				case run.N == 0:
+					run = Counted[T]{Value: v, N: 1}
+				case v == run.Value:
+					run.N++
+				default:
+					val, run = run, Counted[T]{Value: v, N: 1}
+					return val, nil
+				}
+			}
+
+			if run.N > 0 {
+				val, run = run, Counted[T]{}
+				return val, nil
+			}
+
+			err, srcErr = srcErr, nil
+			return val, err
+		},
+	}
+}
+
+// NewReaderWithKeyedStateFn returns a reader of values from 'r' mapped with
+// 'f', where 'f' also gets a pointer to a state which is kept per key, as
+// given by 'key'. The state for a new key starts as the zero value of S. If
+// 'maxKeys' is > 0, the least recently used key is evicted (i.e its state is
+// forgotten) when a new key would exceed the limit; otherwise states are kept
+// forever. An empty non-nil Reader is returned if 'r', 'key' or 'f' is nil.
+//
+// Example:
+//
+//	// Running total per user.
+//	r := NewReaderWithKeyedStateFn[Purchase, int, string, int](purchases)(
+//		func(v Purchase) string { return v.User },
+//		func(total *int, v Purchase) int {
+//			*total += v.Amount
+//			return *total
+//		},
+//		10_000,
+//	)
+func NewReaderWithKeyedStateFn[T, U any, K comparable, S any](r Reader[T]) func(key func(T) K, f func(state *S, v T) U, maxKeys int) Reader[U] {
+	return func(key func(T) K, f func(state *S, v T) U, maxKeys int) Reader[U] {
+		if r == nil || key == nil || f == nil {
+			return nilReader[U]()
+		}
+
+		type entry struct {
+			key   K
+			state S
+		}
+
+		// Recency list, front is most recently used.
+		lru := list.New()
+		states := make(map[K]*list.Element)
+
+		return ReaderImpl[U]{
+			Wraps: r,
+			Impl: func(ctx context.Context) (val U, err error) {
+				v, err := r.Read(ctx)
+				if err != nil {
+					return
+				}
+
+				k := key(v)
+				e, ok := states[k]
+				if ok {
+					lru.MoveToFront(e)
+				} else {
+					if maxKeys > 0 && lru.Len() >= maxKeys {
+						oldest := lru.Back()
+						delete(states, oldest.Value.(*entry).key)
+						lru.Remove(oldest)
+					}
+
+					e = lru.PushFront(&entry{key: k})
+					states[k] = e
+				}
+
+				return f(&e.Value.(*entry).state, v), nil
+			},
+		}
+	}
+}
+
+// FairnessCfg is used to configure NewReaderWithFairnessCfg.
+type FairnessCfg struct {
+	// MaxPerKey is the max amount of values buffered per key, <= 0 defaults
+	// to 8.
+	MaxPerKey int
+	// MaxBuffered is the max amount of values buffered in total, <= 0
+	// defaults to MaxPerKey.
+	MaxBuffered int
+}
+
+// NewReaderWithFairness is shorthand for NewReaderWithFairnessCfg with only
+// FairnessCfg.MaxPerKey set, see it for details.
+//
+// Example:
+//
+//	r := NewReaderWithFairness[string, string](NewReaderFrom("a1", "a2", "a3", "b1"))(
+//		func(v string) string { return v[:1] },
+//		2,
+//	)
+//
+//	t.Log(r.Read(nil)) // "a1", nil <--- buffered a1, a2.
+//	t.Log(r.Read(nil)) // "a2", nil <--- buffered a2, a3.
+//	t.Log(r.Read(nil)) // "b1", nil <--- buffered a3, b1 (b1 is new).
+//	t.Log(r.Read(nil)) // "a3", nil
+//	t.Log(r.Read(nil)) // "", io.EOF
+func NewReaderWithFairness[T any, K comparable](r Reader[T]) func(key func(T) K, maxPerKey int) Reader[T] {
+	return func(key func(T) K, maxPerKey int) Reader[T] {
+		return NewReaderWithFairnessCfg[T, K](r)(key, FairnessCfg{MaxPerKey: maxPerKey})
+	}
+}
+
+// NewReaderWithFairnessCfg returns a reader which yields values from 'r' in
+// round-robin order across keys, as given by 'key', such that one hot key can
+// not monopolize downstream processing. Values are read ahead from 'r' and
+// buffered per key until the buffer of some key holds FairnessCfg.MaxPerKey
+// values, or until FairnessCfg.MaxBuffered values are buffered in total (or
+// 'r' fails), after which one value is yielded from the next key in turn.
+// Keys which are new to the buffer get the next turn, and the order of values
+// with the same key is kept.
+//
+// Note that read-ahead means that a value may wait for up to MaxBuffered more
+// reads of 'r', so this suits sources with a backlog (e.g a queue) rather
+// than sparse ones. Errors from 'r' are returned after the buffered values
+// are yielded. An empty non-nil Reader is returned if 'r' or 'key' is nil.
+//
+// Example:
+//
+//	r := NewReaderWithFairnessCfg[Job, string](jobs)(
+//		func(v Job) string { return v.Tenant },
+//		FairnessCfg{MaxPerKey: 8, MaxBuffered: 1024},
+//	)
+func NewReaderWithFairnessCfg[T any, K comparable](r Reader[T]) func(key func(T) K, cfg FairnessCfg) Reader[T] {
+	return func(key func(T) K, cfg FairnessCfg) Reader[T] {
+		if r == nil || key == nil {
+			return nilReader[T]()
+		}
+
+		if cfg.MaxPerKey <= 0 {
+			cfg.MaxPerKey = 8
+		}
+		if cfg.MaxBuffered <= 0 {
+			cfg.MaxBuffered = cfg.MaxPerKey
+		}
+
+		maxPerKey := cfg.MaxPerKey
+		queues := make(map[K][]T)
+		// Keys with buffered values, in round-robin order, and the next turn.
+		// New keys are inserted at the turn, after other keys which are new
+		// since the last yield ('fresh').
+		order := make([]K, 0)
+		turn := 0
+		fresh := 0
+		// Amount of keys with a full buffer, and of buffered values.
+		full := 0
+		buffered := 0
+		var srcErr error
+
+		return ReaderImpl[T]{
+			Impl: func(ctx context.Context) (val T, err error) {
+				for srcErr == nil && full == 0 && buffered < cfg.MaxBuffered {
+					v, err := r.Read(ctx)
+					if err != nil {
+						srcErr = err
+						break
+					}
+
+					k := key(v)
+					q, ok := queues[k]
+					if !ok {
+						order = slices.Insert(order, turn+fresh, k)
+						fresh++
+					}
+
+					queues[k] = append(q, v)
+					buffered++
+					if len(q)+1 == maxPerKey {
+						full++
+					}
+				}
+
+				if len(order) == 0 {
+					err, srcErr = srcErr, nil
+					return val, err
+				}
+
+				fresh = 0
+				k := order[turn]
+				q := queues[k]
+				if len(q) == maxPerKey {
+					full--
+				}
+
+				val, q = q[0], q[1:]
+				buffered--
+				if len(q) > 0 {
+					queues[k] = q
+					turn++
+				} else {
+					delete(queues, k)
+					order = append(order[:turn], order[turn+1:]...)
+				}
+
+				if turn >= len(order) {
+					turn = 0
+				}
+
+				return val, nil
+			},
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Checked variants.
+// -----------------------------------------------------------------------------
+
+// NewReaderWithBatchingChecked is like NewReaderWithBatching, except that it
+// returns an err wrapping ErrInvalidArg if 'r' is nil or 'size' <= 0, instead
+// of silently using an empty Reader or the default size.
+//
+// Example:
+//
+//	r, err := NewReaderWithBatchingChecked[int](nil, 0)
+//	t.Log(r, err) // nil, "iox: invalid argument: nil reader"
+func NewReaderWithBatchingChecked[T any](r Reader[T], size int) (Reader[[]T], error) {
+	if r == nil {
+		return nil, fmt.Errorf("%w: nil reader", ErrInvalidArg)
+	}
+	if size <= 0 {
+		return nil, fmt.Errorf("%w: non-positive batch size %d", ErrInvalidArg, size)
+	}
+
+	return NewReaderWithBatching(r, size), nil
+}
+
+// NewReaderWithUnbatchingChecked is like NewReaderWithUnbatching, except that
+// it returns an err wrapping ErrInvalidArg if 'r' is nil.
+func NewReaderWithUnbatchingChecked[T any](r Reader[[]T]) (Reader[T], error) {
+	if r == nil {
+		return nil, fmt.Errorf("%w: nil reader", ErrInvalidArg)
+	}
+
+	return NewReaderWithUnbatching(r), nil
+}
+
+// NewReaderWithFilterFnChecked is like NewReaderWithFilterFn, except that it
+// returns an err wrapping ErrInvalidArg if 'r' or 'f' is nil.
+func NewReaderWithFilterFnChecked[T any](r Reader[T]) func(f func(v T) bool) (Reader[T], error) {
+	return func(f func(v T) bool) (Reader[T], error) {
+		if r == nil {
+			return nil, fmt.Errorf("%w: nil reader", ErrInvalidArg)
+		}
+		if f == nil {
+			return nil, fmt.Errorf("%w: nil filter func", ErrInvalidArg)
+		}
+
+		return NewReaderWithFilterFn(r)(f), nil
+	}
+}
+
+// NewReaderWithMapperFnChecked is like NewReaderWithMapperFn, except that
+// invalid arguments are reported at construction time with an err wrapping
+// ErrInvalidArg, instead of giving a Reader which is always empty. A nil 'f'
+// is valid if T and U are the same type, in which case 'r' is returned as-is;
+// otherwise it is invalid, as is a nil 'r'. See NewWriterWithMapperFnChecked.
+func NewReaderWithMapperFnChecked[T, U any](r Reader[T]) func(f func(T) U) (Reader[U], error) {
+	return func(f func(T) U) (Reader[U], error) {
+		if r == nil {
+			return nil, fmt.Errorf("%w: nil reader", ErrInvalidArg)
+		}
+
+		if f == nil {
+			if ru, ok := any(r).(Reader[U]); ok {
+				return ru, nil
+			}
+
+			return nil, fmt.Errorf("%w: nil mapper func from %T to %T", ErrInvalidArg, *new(T), *new(U))
+		}
+
+		return NewReaderWithMapperFn[T, U](r)(f), nil
+	}
+}
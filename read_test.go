@@ -4,10 +4,36 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
+	"runtime"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
+// -----------------------------------------------------------------------------
+// Test utils.
+// -----------------------------------------------------------------------------
+
+// newResultReader returns a Reader which yields vs[i], errs[i] pairwise,
+// followed by io.EOF once both are exhausted.
+func newResultReader[T any](vs []T, errs []error) Reader[T] {
+	i := 0
+	return ReaderImpl[T]{
+		Impl: func(ctx context.Context) (v T, err error) {
+			if i >= len(vs) {
+				return v, io.EOF
+			}
+
+			v, err = vs[i], errs[i]
+			i++
+			return
+		},
+	}
+}
+
 // -----------------------------------------------------------------------------
 // Reader impl.
 // -----------------------------------------------------------------------------
@@ -21,6 +47,18 @@ func TestReaderImplReadIdeal(t *testing.T) {
 	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
 }
 
+func TestReaderImplReadWithNilCtx(t *testing.T) {
+	r := ReaderImpl[bool]{
+		Impl: func(ctx context.Context) (bool, error) {
+			return ctx != nil, ctx.Err()
+		},
+	}
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("non-nil", true, val, func(s string) { t.Fatal(s) })
+}
+
 func TestReaderImplReadWithNilImpl(t *testing.T) {
 	r := ReaderImpl[int]{}
 
@@ -88,6 +126,271 @@ func TestNewReaderFromIdeal(t *testing.T) {
 	assertEq("val", 0, val, func(s string) { t.Fatal(s) })
 }
 
+func TestNewReaderFromWithLen(t *testing.T) {
+	r := NewReaderFrom(1, 2)
+
+	l, ok := r.(Lener)
+	assertEq("ok", true, ok, func(s string) { t.Fatal(s) })
+	assertEq("len", 2, l.Len(), func(s string) { t.Fatal(s) })
+
+	r.Read(nil)
+	assertEq("len", 1, l.Len(), func(s string) { t.Fatal(s) })
+
+	r.Read(nil)
+	r.Read(nil)
+	assertEq("len", 0, l.Len(), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderFromWithCancelledCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := NewReaderFrom(1, 2)
+	_, err := r.Read(ctx)
+	assertEq("err", true, err == context.Canceled, func(s string) { t.Fatal(s) })
+
+	// Nothing is consumed.
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderFromEmptyIdeal(t *testing.T) {
+	r := NewReaderFromEmpty[int]()
+
+	val, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("val", 0, val, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderFromErrIdeal(t *testing.T) {
+	r := NewReaderFromErr[int](io.ErrUnexpectedEOF)
+
+	for i := 0; i < 2; i++ {
+		val, err := r.Read(nil)
+		assertEq("err", true, err == io.ErrUnexpectedEOF, func(s string) { t.Fatal(s) })
+		assertEq("val", 0, val, func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestNewReaderFromErrWithNilErr(t *testing.T) {
+	r := NewReaderFromErr[int](nil)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderFromMultiIdeal(t *testing.T) {
+	r := NewReaderFromMulti(NewReaderFrom(1, 2), nil, NewReaderFromEmpty[int](), NewReaderFrom(3))
+
+	for _, want := range []int{1, 2, 3} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderFromMultiWithErr(t *testing.T) {
+	r := NewReaderFromMulti(
+		newResultReader([]int{0, 1}, []error{io.ErrUnexpectedEOF, nil}),
+		NewReaderFrom(2),
+	)
+
+	_, err := r.Read(nil)
+	assertEq("err", io.ErrUnexpectedEOF, err, func(s string) { t.Fatal(s) })
+
+	for _, want := range []int{1, 2} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestNewReaderFromChanIdeal(t *testing.T) {
+	ch := make(chan int, 2)
+	ch <- 1
+	ch <- 2
+	close(ch)
+
+	vals, err := readAll(NewReaderFromChan(ch))
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("vals", []int{1, 2}, vals, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderFromChanWithCancelledCtx(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	_, err := NewReaderFromChan(make(chan int)).Read(ctx)
+	assertEq("err", true, err == context.DeadlineExceeded, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderFromChanWithNilChan(t *testing.T) {
+	_, err := NewReaderFromChan[int](nil).Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderFromPollerIdeal(t *testing.T) {
+	clock := &advancingClock{now: time.Unix(100, 0)}
+	ctx := WithClock(context.Background(), clock)
+
+	n := 0
+	r := NewReaderFromPoller(func() int { n++; return n }, time.Second)
+
+	for want := 1; want <= 2; want++ {
+		val, err := r.Read(ctx)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	// A slow consumer gets the next sample at once.
+	clock.now = clock.now.Add(time.Second * 3)
+	val, err := r.Read(ctx)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 3, val, func(s string) { t.Fatal(s) })
+	assertEq("waits", []time.Duration{time.Second}, clock.waits, func(s string) { t.Fatal(s) })
+
+	assertEq("err", *new(error), r.Close(), func(s string) { t.Fatal(s) })
+	_, err = r.Read(ctx)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderFromPollerWithCloseWhileWaiting(t *testing.T) {
+	r := NewReaderFromPoller(func() int { return 1 }, time.Hour)
+
+	_, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		r.Close()
+	}()
+
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderFromTickerIdeal(t *testing.T) {
+	start := time.Unix(100, 0)
+	clock := &advancingClock{now: start}
+	ctx := WithClock(context.Background(), clock)
+
+	r := NewReaderFromTicker(time.Second)
+	for i := 1; i <= 2; i++ {
+		val, err := r.Read(ctx)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", true, val.Equal(start.Add(time.Second*time.Duration(i))), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("waits", []time.Duration{time.Second, time.Second}, clock.waits, func(s string) { t.Fatal(s) })
+
+	r.Close()
+	_, err := r.Read(ctx)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewCachedReaderFactoryIdeal(t *testing.T) {
+	opened := 0
+	newReader := NewCachedReaderFactory(
+		func(ctx context.Context) (Reader[int], error) {
+			opened++
+			return NewReaderFrom(1, 2), nil
+		},
+	)
+
+	for i := 0; i < 3; i++ {
+		r := newReader()
+
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+
+		val, err = r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", 2, val, func(s string) { t.Fatal(s) })
+
+		val, err = r.Read(nil)
+		assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+		assertEq("val", 0, val, func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("opened", 1, opened, func(s string) { t.Fatal(s) })
+}
+
+func TestNewCachedReaderFactoryWithNilOpen(t *testing.T) {
+	r := NewCachedReaderFactory[int](nil)()
+
+	val, err := r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+	assertEq("val", 0, val, func(s string) { t.Fatal(s) })
+}
+
+func TestNewCachedReaderFactoryWithOpenErr(t *testing.T) {
+	opened := 0
+	newReader := NewCachedReaderFactory(
+		func(ctx context.Context) (Reader[int], error) {
+			opened++
+			if opened == 1 {
+				return nil, io.ErrUnexpectedEOF
+			}
+
+			return NewReaderFrom(1), nil
+		},
+	)
+
+	r := newReader()
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.ErrUnexpectedEOF, func(s string) { t.Fatal(s) })
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+	assertEq("opened", 2, opened, func(s string) { t.Fatal(s) })
+}
+
+func TestNewCachedReaderFactoryWithTTLExpired(t *testing.T) {
+	opened := 0
+	newReader := NewCachedReaderFactoryWithTTL(
+		func(ctx context.Context) (Reader[int], error) {
+			opened++
+			return NewReaderFrom(opened), nil
+		},
+		time.Millisecond,
+	)
+
+	val, _ := newReader().Read(nil)
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+
+	time.Sleep(time.Millisecond * 2)
+
+	val, _ = newReader().Read(nil)
+	assertEq("val", 2, val, func(s string) { t.Fatal(s) })
+	assertEq("opened", 2, opened, func(s string) { t.Fatal(s) })
+}
+
+func TestNewCachedReaderFactoryWithTTLClosesOnErr(t *testing.T) {
+	closed := 0
+	newReader := NewCachedReaderFactoryWithTTL(
+		func(ctx context.Context) (Reader[int], error) {
+			return ReadCloserImpl[int]{
+				ImplC: func() error { closed++; return nil },
+				ImplR: func(ctx context.Context) (int, error) { return 0, io.ErrUnexpectedEOF },
+			}, nil
+		},
+		0,
+	)
+
+	for i := 1; i <= 2; i++ {
+		_, err := newReader().Read(nil)
+		assertEq("err", true, err == io.ErrUnexpectedEOF, func(s string) { t.Fatal(s) })
+		assertEq("closed", i, closed, func(s string) { t.Fatal(s) })
+	}
+}
+
 func TestNewReaderFromBytesIdeal(t *testing.T) {
 	b := bytes.NewBuffer(nil)
 	json.NewEncoder(b).Encode("test1")
@@ -112,6 +415,20 @@ func TestNewReaderFromBytesIdeal(t *testing.T) {
 	assertEq("val", "", val, func(s string) { t.Fatal(s) })
 }
 
+func TestNewReaderFromBytesWithCancelledCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := NewReaderFromBytes[int](bytes.NewBufferString("1 2"))(nil)
+	_, err := r.Read(ctx)
+	assertEq("err", true, err == context.Canceled, func(s string) { t.Fatal(s) })
+
+	// Nothing is consumed.
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+}
+
 func TestNewReaderFromBytesWithNilReader(t *testing.T) {
 	r := NewReaderFromBytes[string](nil)(nil)
 
@@ -249,6 +566,151 @@ func TestNewReaderWithBatchingWithNilReader(t *testing.T) {
 	assertEq("val", *new([]int), s, func(s string) { t.Fatal(s) })
 }
 
+func TestNewReaderWithBatchingCfgWithTransientErr(t *testing.T) {
+	vr := newResultReader(
+		[]int{1, 2, 0, 3},
+		[]error{nil, nil, context.Canceled, nil},
+	)
+
+	sr := NewReaderWithBatchingCfg(vr)(
+		BatchingCfg{
+			Size:      4,
+			Transient: func(err error) bool { return err == context.Canceled },
+		},
+	)
+
+	s, err := sr.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", []int{1, 2}, s, func(s string) { t.Fatal(s) })
+
+	s, err = sr.Read(nil)
+	assertEq("err", context.Canceled, err, func(s string) { t.Fatal(s) })
+	assertEq("val", []int{}, s, func(s string) { t.Fatal(s) })
+
+	s, err = sr.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", []int{3}, s, func(s string) { t.Fatal(s) })
+
+	s, err = sr.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+	assertEq("val", []int{}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithBatchingCfgWithPermanentErr(t *testing.T) {
+	vr := newResultReader(
+		[]int{1, 0, 2},
+		[]error{nil, context.Canceled, nil},
+	)
+
+	sr := NewReaderWithBatchingCfg(vr)(BatchingCfg{Size: 4})
+
+	s, err := sr.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", []int{1}, s, func(s string) { t.Fatal(s) })
+
+	for i := 0; i < 2; i++ {
+		s, err = sr.Read(nil)
+		assertEq("err", true, err == context.Canceled, func(s string) { t.Fatal(s) })
+		assertEq("val", []int{}, s, func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestNewReaderWithBatchingCfgWithStrictShortBatch(t *testing.T) {
+	sr := NewReaderWithBatchingCfg(NewReaderFrom(1, 2, 3))(
+		BatchingCfg{Size: 2, Strict: true},
+	)
+
+	s, err := sr.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", []int{1, 2}, s, func(s string) { t.Fatal(s) })
+
+	s, err = sr.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", []int{3}, s, func(s string) { t.Fatal(s) })
+
+	s, err = sr.Read(nil)
+	assertEq("err", true, err == ErrShortBatch, func(s string) { t.Fatal(s) })
+	assertEq("err", true, errors.Is(err, io.EOF), func(s string) { t.Fatal(s) })
+	assertEq("val", []int{}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithBatchingCfgWithStrictFullBatch(t *testing.T) {
+	sr := NewReaderWithBatchingCfg(NewReaderFrom(1, 2))(
+		BatchingCfg{Size: 2, Strict: true},
+	)
+
+	s, err := sr.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", []int{1, 2}, s, func(s string) { t.Fatal(s) })
+
+	s, err = sr.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("val", []int{}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithBatchingWithLener(t *testing.T) {
+	sr := NewReaderWithBatching(NewReaderFrom(1, 2, 3), 8)
+
+	s, err := sr.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", []int{1, 2, 3}, s, func(s string) { t.Fatal(s) })
+	assertEq("cap", 3, cap(s), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithGapBatchingIdeal(t *testing.T) {
+	secs := NewReaderFrom(0, 1, 10, 11, 12, 30)
+	r := NewReaderWithGapBatching(secs)(
+		GapBatchingCfg[int]{
+			Time: func(v int) time.Time { return time.Unix(int64(v), 0) },
+			Gap:  time.Second * 5,
+		},
+	)
+
+	for _, want := range [][]int{{0, 1}, {10, 11, 12}, {30}} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	val, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("val", []int{}, val, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithGapBatchingWithMaxSize(t *testing.T) {
+	secs := NewReaderFrom(0, 1, 2, 10)
+	r := NewReaderWithGapBatching(secs)(
+		GapBatchingCfg[int]{
+			Time:    func(v int) time.Time { return time.Unix(int64(v), 0) },
+			Gap:     time.Second * 5,
+			MaxSize: 2,
+		},
+	)
+
+	for _, want := range [][]int{{0, 1}, {2}, {10}} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestNewReaderWithGapBatchingWithReadErr(t *testing.T) {
+	src := newResultReader([]int{0, 1, 0}, []error{nil, nil, io.ErrUnexpectedEOF})
+	r := NewReaderWithGapBatching(src)(
+		GapBatchingCfg[int]{
+			Time: func(v int) time.Time { return time.Unix(int64(v), 0) },
+			Gap:  time.Second,
+		},
+	)
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", []int{0, 1}, val, func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", io.ErrUnexpectedEOF, err, func(s string) { t.Fatal(s) })
+}
+
 func TestNewReaderWithUnbatchingIdeal(t *testing.T) {
 	sr := NewReaderWithBatching(NewReaderFrom(1, 3, 2), 2)
 	vr := NewReaderWithUnbatching(sr)
@@ -301,6 +763,84 @@ func TestNewReaderWithUnbatchingWithEmptyBatchAndErr(t *testing.T) {
 	assertEq("val", 0, val, func(s string) { t.Fatal(s) })
 }
 
+func TestNewReaderWithTakeIdeal(t *testing.T) {
+	reads := 0
+	src := ReaderImpl[int]{Impl: func(ctx context.Context) (int, error) { reads++; return reads, nil }}
+	r := NewReaderWithTake[int](src, 2)
+
+	for _, want := range []int{1, 2} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("reads", 2, reads, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTakeWithShortSource(t *testing.T) {
+	r := NewReaderWithTake(NewReaderFrom(1), 2)
+
+	r.Read(nil)
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTakeWithZero(t *testing.T) {
+	r := NewReaderWithTake(NewReaderFrom(1), 0)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTakeWhileFnIdeal(t *testing.T) {
+	reads := 0
+	src := ReaderImpl[int]{Impl: func(ctx context.Context) (int, error) { reads++; return reads, nil }}
+	r := NewReaderWithTakeWhileFn[int](src)(func(v int) bool { return v < 3 })
+
+	for _, want := range []int{1, 2} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := r.Read(nil)
+		assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("reads", 3, reads, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTakeWhileFnWithNilFn(t *testing.T) {
+	r := NewReaderWithTakeWhileFn(NewReaderFrom(1))(nil)
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithSkipWhileFnIdeal(t *testing.T) {
+	r := NewReaderWithSkipWhileFn(NewReaderFrom(1, 2, 3, 1))(func(v int) bool { return v < 3 })
+
+	for _, want := range []int{3, 1} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithSkipWhileFnWithNilReader(t *testing.T) {
+	r := NewReaderWithSkipWhileFn[int](nil)(func(v int) bool { return true })
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
 func TestNewReaderWithFilterFnIdeal(t *testing.T) {
 	r := NewReaderFrom(1, 2, 3)
 	r = NewReaderWithFilterFn(r)(func(v int) bool { return v%2 == 0 })
@@ -341,6 +881,56 @@ func TestNewReaderWithFilterFnWithNilFilter(t *testing.T) {
 	assertEq("val", 0, val, func(s string) { t.Fatal(s) })
 }
 
+func TestNewReaderWithSampleNthIdeal(t *testing.T) {
+	r := NewReaderWithSampleNth(NewReaderFrom(1, 2, 3, 4, 5, 6, 7), 3)
+
+	vs, err := readAll(r)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("vals", []int{1, 4, 7}, vs, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithSampleProbIdeal(t *testing.T) {
+	vs := make([]int, 1000)
+	for i := range vs {
+		vs[i] = i
+	}
+
+	a, _ := readAll(NewReaderWithSampleProb(NewReaderFrom(vs...), 0.1, 42))
+	b, _ := readAll(NewReaderWithSampleProb(NewReaderFrom(vs...), 0.1, 42))
+	assertEq("seeded", a, b, func(s string) { t.Fatal(s) })
+	assertEq("approx", true, len(a) > 50 && len(a) < 150, func(s string) { t.Fatal(s) })
+
+	none, err := readAll(NewReaderWithSampleProb(NewReaderFrom(vs...), 0, 42))
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("none", 0, len(none), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithAssertFnIdeal(t *testing.T) {
+	errNegative := errors.New("negative")
+	r := NewReaderWithAssertFn(NewReaderFrom(1, -1, 2))(
+		func(v int) error {
+			if v < 0 {
+				return errNegative
+			}
+
+			return nil
+		},
+	)
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("contract", true, errors.Is(err, ErrContract), func(s string) { t.Fatal(s) })
+	assertEq("cause", true, errors.Is(err, errNegative), func(s string) { t.Fatal(s) })
+	assertEq("msg", "iox: contract violation: negative: -1", err.Error(), func(s string) { t.Fatal(s) })
+
+	val, err = r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 2, val, func(s string) { t.Fatal(s) })
+}
+
 func TestNewReaderWithMapperFnIdeal(t *testing.T) {
 	r := NewReaderFrom(1, 2)
 	r = NewReaderWithMapperFn[int, int](r)(func(v int) int { return v * -1 })
@@ -383,3 +973,728 @@ func TestNewReaderWithMapperFnWithNilMapper(t *testing.T) {
 	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
 	assertEq("val", 0, val, func(s string) { t.Fatal(s) })
 }
+
+type testCtxKey struct{}
+
+func TestNewReaderWithFilterFnCtxIdeal(t *testing.T) {
+	ctx := context.WithValue(context.Background(), testCtxKey{}, 2)
+	r := NewReaderWithFilterFnCtx(NewReaderFrom(1, 2, 3))(
+		func(ctx context.Context, v int) bool {
+			return v >= ctx.Value(testCtxKey{}).(int)
+		},
+	)
+
+	for _, want := range []int{2, 3} {
+		val, err := r.Read(ctx)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(ctx)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithMapperFnCtxIdeal(t *testing.T) {
+	ctx := context.WithValue(context.Background(), testCtxKey{}, 10)
+	r := NewReaderWithMapperFnCtx[int, int](NewReaderFrom(1, 2))(
+		func(ctx context.Context, v int) int {
+			return v * ctx.Value(testCtxKey{}).(int)
+		},
+	)
+
+	for _, want := range []int{10, 20} {
+		val, err := r.Read(ctx)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestNewReaderWithMapperFnCtxWithNilMapper(t *testing.T) {
+	r := NewReaderWithMapperFnCtx[int, int](NewReaderFrom(1))(nil)
+
+	_, err := r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithMapperFnEIdeal(t *testing.T) {
+	r := NewReaderWithMapperFnE[string, int](NewReaderFrom("1", "x", "3"))(
+		func(ctx context.Context, v string) (int, error) {
+			return strconv.Atoi(v)
+		},
+	)
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", true, errors.Is(err, strconv.ErrSyntax), func(s string) { t.Fatal(s) })
+
+	val, err = r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 3, val, func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithMapperFnEWithNilMapper(t *testing.T) {
+	r := NewReaderWithMapperFnE[int, int](NewReaderFrom(1))(nil)
+
+	_, err := r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithFlatMapFnIdeal(t *testing.T) {
+	r := NewReaderWithFlatMapFn[int, int](NewReaderFrom(1, 0, 2))(
+		func(v int) Reader[int] {
+			if v == 0 {
+				return nil
+			}
+
+			return NewReaderFrom(v, v*10)
+		},
+	)
+
+	for _, want := range []int{1, 10, 2, 20} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithFlatMapFnWithInnerErr(t *testing.T) {
+	r := NewReaderWithFlatMapFn[int, int](NewReaderFrom(1, 2))(
+		func(v int) Reader[int] {
+			if v == 1 {
+				return newResultReader([]int{1, 0}, []error{nil, io.ErrUnexpectedEOF})
+			}
+
+			return NewReaderFrom(v)
+		},
+	)
+
+	for i, want := range []error{nil, io.ErrUnexpectedEOF, nil, io.EOF} {
+		_, err := r.Read(nil)
+		assertEq("err"+strconv.Itoa(i), want, err, func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestNewReaderWithFlatMapSliceFnIdeal(t *testing.T) {
+	r := NewReaderWithFlatMapSliceFn[string, string](NewReaderFrom("a b", "", "c"))(strings.Fields)
+
+	for _, want := range []string{"a", "b", "c"} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithBatchMapperFnIdeal(t *testing.T) {
+	r := NewReaderWithBatchMapperFn[int, string](NewReaderFrom([]int{1, 2}, []int{3}))(
+		func(vs []int) ([]string, error) {
+			return MapSlice(vs, strconv.Itoa), nil
+		},
+	)
+
+	for _, want := range [][]string{{"1", "2"}, {"3"}} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithBatchMapperFnWithMapperErr(t *testing.T) {
+	r := NewReaderWithBatchMapperFn[int, int](NewReaderFrom([]int{1}))(
+		func(vs []int) ([]int, error) {
+			return nil, io.ErrUnexpectedEOF
+		},
+	)
+
+	_, err := r.Read(nil)
+	assertEq("err", io.ErrUnexpectedEOF, err, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithBatchMapperFnWithNilMapper(t *testing.T) {
+	r := NewReaderWithBatchMapperFn[int, int](NewReaderFrom([]int{1}))(nil)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTeeIdeal(t *testing.T) {
+	s := make([]int, 0, 2)
+	r := NewReaderWithTee(NewReaderFrom(1, 2), newSliceWriter(&s))
+
+	for _, want := range []int{1, 2} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+	assertEq("tee", []int{1, 2}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTeeWithWriterErr(t *testing.T) {
+	r := NewReaderWithTee(NewReaderFrom(1), WriterImpl[int]{})
+
+	val, err := r.Read(nil)
+	assertEq("err", io.ErrClosedPipe, err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithOnReadFnIdeal(t *testing.T) {
+	var vs []int
+	var errs []bool
+	r := NewReaderWithOnReadFn(NewReaderFrom(1, 2))(
+		func(v int, err error) {
+			vs = append(vs, v)
+			errs = append(errs, err != nil)
+		},
+	)
+
+	have, err := readAll(r)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("vals", []int{1, 2}, have, func(s string) { t.Fatal(s) })
+	assertEq("seen", []int{1, 2, 0}, vs, func(s string) { t.Fatal(s) })
+	assertEq("errs", []bool{false, false, true}, errs, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithStopSignalIdeal(t *testing.T) {
+	stop := make(chan struct{})
+	r := NewReaderWithStopSignal(NewReaderFrom(1, 2), stop)
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+
+	close(stop)
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithStopSignalWhileReading(t *testing.T) {
+	stop := make(chan struct{})
+	blocking := ReaderImpl[int]{
+		Impl: func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		},
+	}
+
+	r := NewReaderWithStopSignal[int](blocking, stop)
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		close(stop)
+	}()
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithStopSignalWhileReadingWithCtx(t *testing.T) {
+	stop := make(chan struct{})
+	blocking := ReaderImpl[int]{
+		Impl: func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		},
+	}
+
+	r := NewReaderWithStopSignal[int](blocking, stop)
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		close(stop)
+	}()
+
+	_, err := r.Read(context.Background())
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithStopSignalWithManyReads(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	r := NewReaderWithStopSignal(NewReaderFrom(make([]int, 100)...), stop)
+	r.Read(context.Background())
+
+	// The first Read starts the only goroutine.
+	before := runtime.NumGoroutine()
+	for i := 0; i < 99; i++ {
+		r.Read(context.Background())
+	}
+
+	assertEq("goroutines", before, runtime.NumGoroutine(), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTimestampsIdeal(t *testing.T) {
+	before := time.Now()
+	r := NewReaderWithTimestamps(NewReaderFrom(1))
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val.Value, func(s string) { t.Fatal(s) })
+	assertEq("time", true, !val.Time.Before(before), func(s string) { t.Fatal(s) })
+
+	val, err = r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+	assertEq("val", 0, val.Value, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTimestampsWithNilReader(t *testing.T) {
+	r := NewReaderWithTimestamps[int](nil)
+
+	_, err := r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithSequenceCheckIdeal(t *testing.T) {
+	seq := func(n uint64) Sequenced[uint64] { return Sequenced[uint64]{Seq: n, Value: n} }
+	r := NewReaderWithSequenceCheck(NewReaderFrom(seq(5), seq(6), seq(8), seq(9), seq(9)))
+
+	for _, want := range []uint64{5, 6} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", true, errors.Is(err, ErrSequenceGap), func(s string) { t.Fatal(s) })
+
+	for _, want := range []uint64{8, 9} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	// Duplicate.
+	_, err = r.Read(nil)
+	assertEq("err", true, errors.Is(err, ErrSequenceGap), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTTLIdeal(t *testing.T) {
+	now := time.Now()
+	r := NewReaderWithTTL(
+		NewReaderFrom(
+			Timestamped[int]{Value: 1, Time: now.Add(-time.Hour)},
+			Timestamped[int]{Value: 2, Time: now},
+			Timestamped[int]{Value: 3, Time: now.Add(-time.Hour)},
+		),
+		time.Minute,
+	)
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 2, val, func(s string) { t.Fatal(s) })
+
+	val, err = r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+	assertEq("val", 0, val, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTTLWithNilReader(t *testing.T) {
+	r := NewReaderWithTTL[int](nil, time.Minute)
+
+	val, err := r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+	assertEq("val", 0, val, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTTLWithZeroTTL(t *testing.T) {
+	v := Timestamped[int]{Value: 1, Time: time.Now().Add(-time.Hour)}
+	r := NewReaderWithTTL(NewReaderFrom(v), 0)
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithPacingIdeal(t *testing.T) {
+	at := func(s int) Timestamped[int] { return Timestamped[int]{Value: s, Time: time.Unix(int64(s), 0)} }
+	clock := &advancingClock{now: time.Unix(100, 0)}
+	ctx := WithClock(context.Background(), clock)
+
+	r := NewReaderWithPacing(NewReaderFrom(at(0), at(2), at(3), at(1)), 0.5)
+	for _, want := range []int{0, 2, 3, 1} {
+		val, err := r.Read(ctx)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val.Value, func(s string) { t.Fatal(s) })
+	}
+
+	// The last value is late, so it is not waited for.
+	want := []time.Duration{time.Second, time.Millisecond * 500}
+	assertEq("waits", want, clock.waits, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithPacingWithDoneCtx(t *testing.T) {
+	at := func(s int) Timestamped[int] { return Timestamped[int]{Value: s, Time: time.Unix(int64(s), 0)} }
+	ctx, cancel := context.WithCancel(context.Background())
+	r := NewReaderWithPacing(NewReaderFrom(at(0), at(3600)), 1)
+
+	r.Read(ctx)
+	cancel()
+
+	_, err := r.Read(ctx)
+	assertEq("err", context.Canceled, err, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithPacingWithDoneCtxHoldsValue(t *testing.T) {
+	at := func(s int) Timestamped[int] { return Timestamped[int]{Value: s, Time: time.Unix(int64(s), 0)} }
+	clock := &testClock{now: time.Unix(100, 0)}
+	ctx, cancel := context.WithCancel(WithClock(context.Background(), clock))
+	r := NewReaderWithPacing(NewReaderFrom(at(0), at(10), at(20)), 1)
+
+	r.Read(ctx)
+	cancel()
+
+	_, err := r.Read(ctx)
+	assertEq("err", true, err == context.Canceled, func(s string) { t.Fatal(s) })
+
+	// The value which was waited for is yielded once it is due.
+	clock.now = clock.now.Add(time.Second * 20)
+	ctx = WithClock(context.Background(), clock)
+	for _, want := range []int{10, 20} {
+		val, err := r.Read(ctx)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val.Value, func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestNewReaderWithHashDedupIdeal(t *testing.T) {
+	r := NewReaderFrom(1, 2, 1, 3, 1, 2)
+	r = NewReaderWithHashDedup(r)(func(v int) uint64 { return uint64(v) }, 2)
+
+	for _, want := range []int{1, 2, 3, 1, 2} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	val, err := r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+	assertEq("val", 0, val, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithHashDedupWithNilReader(t *testing.T) {
+	r := NewReaderWithHashDedup[int](nil)(func(v int) uint64 { return uint64(v) }, 2)
+
+	val, err := r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+	assertEq("val", 0, val, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithHashDedupWithNilHash(t *testing.T) {
+	r := NewReaderWithHashDedup(NewReaderFrom(1, 1))(nil, 2)
+
+	for i := 0; i < 2; i++ {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestNewReaderWithDedupFnIdeal(t *testing.T) {
+	r := NewReaderWithDedupFn[string, byte](NewReaderFrom("a1", "b1", "a2", "c1", "b2"))(
+		func(v string) byte { return v[0] },
+	)
+
+	vs, err := readAll(r)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("vals", []string{"a1", "b1", "c1"}, vs, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithDedupWindowFnWithTTL(t *testing.T) {
+	clock := &advancingClock{now: time.Unix(100, 0)}
+	ctx := WithClock(context.Background(), clock)
+
+	// Values with the seconds passed before they are read.
+	src := NewReaderWithMapperFn[Timestamped[string], string](NewReaderFrom(
+		Timestamped[string]{Value: "a1", Time: time.Unix(100, 0)},
+		Timestamped[string]{Value: "a2", Time: time.Unix(105, 0)},
+		Timestamped[string]{Value: "a3", Time: time.Unix(110, 0)},
+		Timestamped[string]{Value: "a4", Time: time.Unix(111, 0)},
+	))(func(v Timestamped[string]) string {
+		clock.now = v.Time
+		return v.Value
+	})
+
+	r := NewReaderWithDedupWindowFn[string, byte](src)(
+		func(v string) byte { return v[0] },
+		DedupCfg{TTL: time.Second * 10},
+	)
+
+	// "a2" is within the TTL of "a1", "a3" is not. "a4" is within that of "a3".
+	for _, want := range []string{"a1", "a3"} {
+		val, err := r.Read(ctx)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(ctx)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithDedupWindowFnWithMaxEntries(t *testing.T) {
+	r := NewReaderWithDedupWindowFn[string, byte](NewReaderFrom("a1", "b1", "a2", "c1", "b2", "a3"))(
+		func(v string) byte { return v[0] },
+		DedupCfg{MaxEntries: 2},
+	)
+
+	// "a2" refreshes "a", so "c1" evicts "b"; "b2" then evicts "a".
+	vs, err := readAll(r)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("vals", []string{"a1", "b1", "c1", "b2", "a3"}, vs, func(s string) { t.Fatal(s) })
+}
+
+func TestDedupWindowPrunesByAge(t *testing.T) {
+	d := newDedupWindow[string](DedupCfg{TTL: time.Second * 10})
+	at := func(s int) time.Time { return time.Unix(int64(s), 0) }
+
+	d.add("a", at(0))
+	d.add("b", at(5))
+
+	// A hit makes "a" the most recently seen, but does not make it younger.
+	assertEq("hit", true, d.contains("a", at(6)), func(s string) { t.Fatal(s) })
+
+	d.add("c", at(12))
+	assertEq("len", 2, len(d.entries), func(s string) { t.Fatal(s) })
+	assertEq("a", false, d.contains("a", at(12)), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithDeltaFnIdeal(t *testing.T) {
+	r := NewReaderWithDeltaFn[int, int](NewReaderFrom(10, 15, 25))(
+		func(prev, cur int) int { return cur - prev },
+	)
+
+	for _, want := range []int{5, 10} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	val, err := r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+	assertEq("val", 0, val, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithDeltaFnWithSingleValue(t *testing.T) {
+	r := NewReaderWithDeltaFn[int, int](NewReaderFrom(10))(
+		func(prev, cur int) int { return cur - prev },
+	)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithDeltaFnWithNilReader(t *testing.T) {
+	r := NewReaderWithDeltaFn[int, int](nil)(func(prev, cur int) int { return cur - prev })
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithDeltaFnWithNilFn(t *testing.T) {
+	r := NewReaderWithDeltaFn[int, int](NewReaderFrom(1, 2))(nil)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithRunLengthIdeal(t *testing.T) {
+	r := NewReaderWithRunLength(NewReaderFrom("a", "a", "b", "a", "a", "a"))
+
+	want := []Counted[string]{{"a", 2}, {"b", 1}, {"a", 3}}
+	for _, w := range want {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", w, val, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithRunLengthWithErr(t *testing.T) {
+	r := NewReaderWithRunLength(newResultReader([]int{1, 1, 0, 2}, []error{nil, nil, io.ErrUnexpectedEOF, nil}))
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", Counted[int]{1, 2}, val, func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.ErrUnexpectedEOF, func(s string) { t.Fatal(s) })
+
+	val, err = r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", Counted[int]{2, 1}, val, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithKeyedStateFnIdeal(t *testing.T) {
+	r := NewReaderWithKeyedStateFn[string, int, string, int](NewReaderFrom("a", "b", "a", "a", "b"))(
+		func(v string) string { return v },
+		func(n *int, v string) int { *n++; return *n },
+		0,
+	)
+
+	for _, want := range []int{1, 1, 2, 3, 2} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	val, err := r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+	assertEq("val", 0, val, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithKeyedStateFnWithMaxKeys(t *testing.T) {
+	r := NewReaderWithKeyedStateFn[string, int, string, int](NewReaderFrom("a", "b", "a", "c", "b", "a"))(
+		func(v string) string { return v },
+		func(n *int, v string) int { *n++; return *n },
+		2,
+	)
+
+	// "b" is evicted by "c", then "a" is evicted by "b".
+	for _, want := range []int{1, 1, 2, 1, 1, 1} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestNewReaderWithKeyedStateFnWithNilReader(t *testing.T) {
+	r := NewReaderWithKeyedStateFn[string, int, string, int](nil)(
+		func(v string) string { return v },
+		func(n *int, v string) int { return 0 },
+		0,
+	)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithFairnessIdeal(t *testing.T) {
+	r := NewReaderWithFairness[string, string](NewReaderFrom("a1", "a2", "a3", "b1"))(
+		func(v string) string { return v[:1] },
+		2,
+	)
+
+	for _, want := range []string{"a1", "a2", "b1", "a3"} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithFairnessWithHotKey(t *testing.T) {
+	src := []string{"a1", "a2", "a3", "a4", "a5", "b1", "a6", "c1", "b2", "a7"}
+	r := NewReaderWithFairness[string, string](NewReaderFrom(src...))(
+		func(v string) string { return v[:1] },
+		3,
+	)
+
+	vs := make([]string, 0, len(src))
+	for v, err := r.Read(nil); err == nil; v, err = r.Read(nil) {
+		vs = append(vs, v)
+	}
+
+	want := []string{"a1", "a2", "a3", "b1", "a4", "c1", "b2", "a5", "a6", "a7"}
+	assertEq("vals", want, vs, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithFairnessCfgWithDistinctKeys(t *testing.T) {
+	reads := 0
+	src := NewReaderWithOnReadFn(NewReaderFrom(1, 2, 3, 4, 5, 6))(func(int, error) { reads++ })
+	r := NewReaderWithFairnessCfg[int, int](src)(
+		func(v int) int { return v },
+		FairnessCfg{MaxPerKey: 8, MaxBuffered: 2},
+	)
+
+	// Yields once 2 values are buffered, rather than reading until io.EOF.
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+	assertEq("reads", 2, reads, func(s string) { t.Fatal(s) })
+
+	vs, err := readAll(r)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("len", 5, len(vs), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithFairnessWithErr(t *testing.T) {
+	r := NewReaderWithFairness[int, int](newResultReader([]int{1, 0}, []error{nil, io.ErrUnexpectedEOF}))(
+		func(v int) int { return v },
+		2,
+	)
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", io.ErrUnexpectedEOF, err, func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithBatchingCheckedIdeal(t *testing.T) {
+	r, err := NewReaderWithBatchingChecked(NewReaderFrom(1, 2, 3), 2)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+
+	s, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", []int{1, 2}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithBatchingCheckedWithInvalidArgs(t *testing.T) {
+	_, err := NewReaderWithBatchingChecked[int](nil, 2)
+	assertEq("err", true, errors.Is(err, ErrInvalidArg), func(s string) { t.Fatal(s) })
+
+	_, err = NewReaderWithBatchingChecked(NewReaderFrom(1), 0)
+	assertEq("err", true, errors.Is(err, ErrInvalidArg), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithUnbatchingCheckedWithNilReader(t *testing.T) {
+	_, err := NewReaderWithUnbatchingChecked[int](nil)
+	assertEq("err", true, errors.Is(err, ErrInvalidArg), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithFilterFnCheckedWithInvalidArgs(t *testing.T) {
+	_, err := NewReaderWithFilterFnChecked[int](nil)(func(int) bool { return true })
+	assertEq("err", true, errors.Is(err, ErrInvalidArg), func(s string) { t.Fatal(s) })
+
+	_, err = NewReaderWithFilterFnChecked(NewReaderFrom(1))(nil)
+	assertEq("err", true, errors.Is(err, ErrInvalidArg), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithMapperFnCheckedIdeal(t *testing.T) {
+	r, err := NewReaderWithMapperFnChecked[int, int](NewReaderFrom(1))(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithMapperFnCheckedWithInvalidArgs(t *testing.T) {
+	_, err := NewReaderWithMapperFnChecked[int, string](nil)(func(int) string { return "" })
+	assertEq("err", true, errors.Is(err, ErrInvalidArg), func(s string) { t.Fatal(s) })
+
+	_, err = NewReaderWithMapperFnChecked[int, string](NewReaderFrom(1))(nil)
+	assertEq("err", true, errors.Is(err, ErrInvalidArg), func(s string) { t.Fatal(s) })
+}
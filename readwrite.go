@@ -33,7 +33,7 @@ func (impl ReadWriterImpl[T, U]) Read(ctx context.Context) (r T, err error) {
 		return
 	}
 
-	return impl.ImplR(ctx)
+	return impl.ImplR(orBackground(ctx))
 }
 
 // Write implements the Writer[U] part of ReadWriter[T, U] by deferring logic
@@ -44,7 +44,7 @@ func (impl ReadWriterImpl[T, U]) Write(ctx context.Context, v U) (err error) {
 		return
 	}
 
-	return impl.ImplW(ctx, v)
+	return impl.ImplW(orBackground(ctx), v)
 }
 
 // -----------------------------------------------------------------------------
@@ -87,7 +87,7 @@ func (impl ReadWriteCloserImpl[T, U]) Read(ctx context.Context) (r T, err error)
 		return
 	}
 
-	return impl.ImplR(ctx)
+	return impl.ImplR(orBackground(ctx))
 }
 
 // Write implements Writer[U] by deferring logic to the internal ImplW func.
@@ -98,7 +98,7 @@ func (impl ReadWriteCloserImpl[T, U]) Write(ctx context.Context, v U) (err error
 		return
 	}
 
-	return impl.ImplW(ctx, v)
+	return impl.ImplW(orBackground(ctx), v)
 }
 
 // -----------------------------------------------------------------------------
@@ -129,6 +129,9 @@ func NewReadWriterFrom[T any](vs ...T) ReadWriter[T, T] {
 
 	return ReadWriteCloserImpl[T, T]{
 		ImplR: func(ctx context.Context) (v T, err error) {
+			if err := ctxErr(ctx); err != nil {
+				return v, err
+			}
 			if len(buf) == 0 {
 				return v, io.EOF
 			}
@@ -139,6 +142,10 @@ func NewReadWriterFrom[T any](vs ...T) ReadWriter[T, T] {
 			return
 		},
 		ImplW: func(ctx context.Context, v T) (err error) {
+			if err := ctxErr(ctx); err != nil {
+				return err
+			}
+
 			buf = append(buf, v)
 			return
 		},
@@ -98,6 +98,17 @@ func TestReadWriteCloserImplCloseWithNilImpl(t *testing.T) {
 // Constructors.
 // -----------------------------------------------------------------------------
 
+func TestNewReadWriterFromWithCancelledCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rw := NewReadWriterFrom(1)
+
+	_, err := rw.Read(ctx)
+	assertEq("err", true, err == context.Canceled, func(s string) { t.Fatal(s) })
+	assertEq("err", true, rw.Write(ctx, 2) == context.Canceled, func(s string) { t.Fatal(s) })
+}
+
 func TestNewReadWriterFromIdeal(t *testing.T) {
 	rw := NewReadWriterFrom(1, 2)
 
@@ -0,0 +1,402 @@
+package iox
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+// -----------------------------------------------------------------------------
+// Record framing.
+// -----------------------------------------------------------------------------
+
+// RecordCfg describes how records are framed in a byte stream, it is used by
+// NewWriterFromRecords and NewReaderFromRecords. Each record is written as
+// Prefix + Escape(record) + Sep. The zero RecordCfg gives newline-delimited
+// records without escaping.
+//
+// For example, RFC 7464 (JSON text sequences) is:
+//
+//	RecordCfg{Prefix: []byte{0x1e}, Sep: []byte("\n")}
+type RecordCfg struct {
+	// Prefix is written before each record, and stripped (if present) from
+	// each record that is read. Nil means no prefix.
+	Prefix []byte
+	// Sep is written after each record, and records are split on it when
+	// reading. Nil or empty defaults to "\n".
+	Sep []byte
+	// Escape is applied to each record before writing it, e.g such that it
+	// does not contain Sep. Nil means no escaping.
+	Escape func([]byte) []byte
+	// Unescape is applied to each record after reading it, i.e it should be
+	// the inverse of Escape. Nil means no unescaping.
+	Unescape func([]byte) []byte
+}
+
+func (cfg RecordCfg) sep() []byte {
+	if len(cfg.Sep) == 0 {
+		return []byte("\n")
+	}
+
+	return cfg.Sep
+}
+
+// NewWriterFromRecords creates a Writer (records) which writes into 'w', with
+// each record framed as specified by the given RecordCfg. Each record is
+// written into 'w' with a single Write call. Nil 'w' returns an empty non-nil
+// Writer.
+//
+// Example:
+//
+//	b := bytes.NewBuffer(nil)
+//	w := NewWriterFromRecords(b)(RecordCfg{Prefix: []byte{0x1e}})
+//
+//	w.Write(nil, []byte(`{"a":1}`))
+//	w.Write(nil, []byte(`{"a":2}`))
+//
+//	t.Log(b.String()) // "\x1e{\"a\":1}\n\x1e{\"a\":2}\n"
+func NewWriterFromRecords(w io.Writer) func(cfg RecordCfg) Writer[[]byte] {
+	return func(cfg RecordCfg) Writer[[]byte] {
+		if w == nil {
+			return nilWriter[[]byte]()
+		}
+
+		sep := cfg.sep()
+		b := bytes.NewBuffer(nil)
+
+		return WriterImpl[[]byte]{
+			Impl: func(ctx context.Context, v []byte) error {
+				if err := ctxErr(ctx); err != nil {
+					return err
+				}
+
+				if cfg.Escape != nil {
+					v = cfg.Escape(v)
+				}
+
+				b.Reset()
+				b.Write(cfg.Prefix)
+				b.Write(v)
+				b.Write(sep)
+
+				_, err := b.WriteTo(w)
+				return err
+			},
+		}
+	}
+}
+
+// NewReaderFromRecords creates a Reader (records) which reads from 'r' and
+// splits on the framing specified by the given RecordCfg. The last record
+// is returned even if it is not terminated by RecordCfg.Sep. Returned records
+// are not reused by the Reader. Nil 'r' returns an empty non-nil Reader.
+//
+// Example:
+//
+//	b := bytes.NewBufferString("\x1e{\"a\":1}\n\x1e{\"a\":2}\n")
+//	r := NewReaderFromRecords(b)(RecordCfg{Prefix: []byte{0x1e}})
+//
+//	t.Log(r.Read(nil)) // `{"a":1}`, nil
+//	t.Log(r.Read(nil)) // `{"a":2}`, nil
+//	t.Log(r.Read(nil)) // nil, io.EOF
+func NewReaderFromRecords(r io.Reader) func(cfg RecordCfg) Reader[[]byte] {
+	return func(cfg RecordCfg) Reader[[]byte] {
+		if r == nil {
+			return nilReader[[]byte]()
+		}
+
+		sep := cfg.sep()
+		br := bufio.NewReader(r)
+
+		return ReaderImpl[[]byte]{
+			Impl: func(ctx context.Context) (v []byte, err error) {
+				if err := ctxErr(ctx); err != nil {
+					return v, err
+				}
+
+				// Reading up to the last byte of 'sep' until the whole of it
+				// is found, since bufio only splits on single bytes.
+				for {
+					var p []byte
+					p, err = br.ReadBytes(sep[len(sep)-1])
+					v = append(v, p...)
+					if err != nil || bytes.HasSuffix(v, sep) {
+						break
+					}
+				}
+
+				if err == io.EOF && len(v) > 0 {
+					err = nil
+				}
+				if err != nil {
+					return nil, err
+				}
+
+				v = bytes.TrimSuffix(v, sep)
+				v = bytes.TrimPrefix(v, cfg.Prefix)
+				if cfg.Unescape != nil {
+					v = cfg.Unescape(v)
+				}
+
+				return v, nil
+			},
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Chunking.
+// -----------------------------------------------------------------------------
+
+// ErrChunkCorrupt is returned by NewReaderWithUnchunking when chunks are
+// malformed, missing or out of order.
+var ErrChunkCorrupt = errors.New("iox: corrupt chunk")
+
+// NewWriterWithChunking returns a Writer which splits each value (e.g an
+// encoded record) into chunks of at most 'size' bytes of data, and writes
+// them into 'w' in order, each prefixed by a header of its sequence number and
+// the total amount of chunks, e.g "0/3:". This lets values which are larger
+// than the frame or message limit of a transport be carried by it; see
+// NewReaderWithUnchunking for the reading side. The header is ASCII, so it is
+// safe with e.g newline-delimited records (as long as the data is). Chunks
+// are written from a reused buffer, so 'w' must not retain them. 'size' <= 0
+// writes each value as a single chunk. Nil 'w' returns an empty Writer.
+//
+// Example:
+//
+//	b := bytes.NewBuffer(nil)
+//	w := NewWriterWithChunking(NewWriterFromRecords(b)(RecordCfg{}), 4)
+//
+//	w.Write(nil, []byte("abcdefghij"))
+//
+//	t.Log(b.String()) // "0/3:abcd\n1/3:efgh\n2/3:ij\n"
+func NewWriterWithChunking(w Writer[[]byte], size int) Writer[[]byte] {
+	if w == nil {
+		return nilWriter[[]byte]()
+	}
+
+	chunk := make([]byte, 0, 64)
+	return WriterImpl[[]byte]{
+		Wraps: w,
+		Impl: func(ctx context.Context, v []byte) error {
+			n := 1
+			if size > 0 && len(v) > size {
+				n = (len(v) + size - 1) / size
+			}
+
+			for i := 0; i < n; i++ {
+				data := v
+				if n > 1 {
+					data = v[i*size : min((i+1)*size, len(v))]
+				}
+
+				chunk = strconv.AppendInt(chunk[:0], int64(i), 10)
+				chunk = append(chunk, '/')
+				chunk = strconv.AppendInt(chunk, int64(n), 10)
+				chunk = append(chunk, ':')
+				chunk = append(chunk, data...)
+
+				if err := w.Write(ctx, chunk); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
+
+// NewReaderWithUnchunking returns a Reader which reassembles values from the
+// chunks read from 'r', as written by NewWriterWithChunking. Malformed, missing
+// or out of order chunks give an err wrapping ErrChunkCorrupt, and the value
+// they belong to is dropped; reading continues with the next value. If 'r'
+// ends in the middle of a value, io.ErrUnexpectedEOF is returned. 'maxSize'
+// limits the size of a reassembled value, to protect against unbounded
+// memory use, where a value exceeding it is dropped with an err wrapping
+// ErrChunkCorrupt; <= 0 means no limit. Nil 'r' returns an empty non-nil
+// Reader.
+//
+// Example:
+//
+//	b := bytes.NewBufferString("0/3:abcd\n1/3:efgh\n2/3:ij\n")
+//	r := NewReaderWithUnchunking(NewReaderFromRecords(b)(RecordCfg{}), 0)
+//
+//	t.Log(r.Read(nil)) // "abcdefghij", nil
+//	t.Log(r.Read(nil)) // nil, io.EOF
+func NewReaderWithUnchunking(r Reader[[]byte], maxSize int) Reader[[]byte] {
+	if r == nil {
+		return nilReader[[]byte]()
+	}
+
+	// The value being reassembled, and the sequence and total of the next
+	// expected chunk; total is 0 while between values.
+	var v []byte
+	seq, total := 0, 0
+	// A chunk which starts a new value while another is incomplete, it is
+	// processed by the next Read, after the err for the incomplete value.
+	var held []byte
+
+	return ReaderImpl[[]byte]{
+		Impl: func(ctx context.Context) ([]byte, error) {
+			for {
+				chunk, err := held, error(nil)
+				held = nil
+				if chunk == nil {
+					chunk, err = r.Read(ctx)
+				}
+				if err != nil {
+					if err == io.EOF && total > 0 {
+						v, seq, total = nil, 0, 0
+						err = io.ErrUnexpectedEOF
+					}
+
+					return nil, err
+				}
+
+				i, n, data, ok := parseChunk(chunk)
+				if !ok {
+					v, seq, total = nil, 0, 0
+					return nil, fmt.Errorf("%w: malformed header", ErrChunkCorrupt)
+				}
+
+				if total > 0 && (i != seq || n != total) {
+					if i == 0 {
+						held = chunk
+					}
+
+					err = fmt.Errorf("%w: want chunk %d/%d, got %d/%d", ErrChunkCorrupt, seq, total, i, n)
+					v, seq, total = nil, 0, 0
+					return nil, err
+				}
+				if total == 0 && i != 0 {
+					return nil, fmt.Errorf("%w: want chunk 0, got %d/%d", ErrChunkCorrupt, i, n)
+				}
+
+				if maxSize > 0 && len(v)+len(data) > maxSize {
+					v, seq, total = nil, 0, 0
+					return nil, fmt.Errorf("%w: value exceeds %d bytes", ErrChunkCorrupt, maxSize)
+				}
+
+				v, seq, total = append(v, data...), i+1, n
+				if seq < total {
+					continue
+				}
+
+				// Done, the next value starts in a new slice, since the
+				// returned one is not reused.
+				done := v
+				v, seq, total = nil, 0, 0
+				if done == nil {
+					done = []byte{}
+				}
+
+				return done, nil
+			}
+		},
+	}
+}
+
+// parseChunk splits a chunk written by NewWriterWithChunking into its header
+// and data.
+func parseChunk(chunk []byte) (seq, total int, data []byte, ok bool) {
+	header, data, ok := bytes.Cut(chunk, []byte(":"))
+	if !ok {
+		return
+	}
+
+	s, t, ok := bytes.Cut(header, []byte("/"))
+	if !ok {
+		return
+	}
+
+	seq, err1 := strconv.Atoi(string(s))
+	total, err2 := strconv.Atoi(string(t))
+	if err1 != nil || err2 != nil || seq < 0 || seq >= total {
+		return 0, 0, nil, false
+	}
+
+	return seq, total, data, true
+}
+
+// -----------------------------------------------------------------------------
+// Decoder fallback.
+// -----------------------------------------------------------------------------
+
+// NewDecoderWithFallback returns a decoder func (e.g for NewReaderFromBytes)
+// which splits its stream into frames as specified by 'cfg', and tries to
+// decode each frame with Decoders created by 'fs', in order, until one
+// succeeds. This lets one Reader handle input with mixed versions, e.g a
+// strict Decoder for the current schema followed by one for a legacy schema.
+// The value is reset to its zero value between attempts. If all Decoders fail,
+// the returned err contains the errors of all attempts. Nil funcs in 'fs' (and
+// funcs returning a nil Decoder) are ignored; if there are no others, decoding
+// fails with an err wrapping ErrInvalidArg.
+//
+// Example:
+//
+//	strict := func(r io.Reader) Decoder {
+//		dec := json.NewDecoder(r)
+//		dec.DisallowUnknownFields()
+//		return dec
+//	}
+//	legacy := func(r io.Reader) Decoder {
+//		return DecoderImpl{Impl: func(v any) error { ... }}
+//	}
+//
+//	r := NewReaderFromBytes[User](file)(NewDecoderWithFallback(RecordCfg{}, strict, legacy))
+func NewDecoderWithFallback(cfg RecordCfg, fs ...func(io.Reader) Decoder) func(io.Reader) Decoder {
+	return func(r io.Reader) Decoder {
+		frames := NewReaderFromRecords(r)(cfg)
+
+		return DecoderImpl{
+			Impl: func(v any) error {
+				frame, err := frames.Read(nil)
+				if err != nil {
+					return err
+				}
+
+				var errs []error
+				for _, f := range fs {
+					if f == nil {
+						continue
+					}
+					if len(errs) > 0 {
+						resetValue(v)
+					}
+
+					d := f(bytes.NewReader(frame))
+					if d == nil {
+						continue
+					}
+
+					err := d.Decode(v)
+					if err == nil {
+						return nil
+					}
+
+					errs = append(errs, err)
+				}
+
+				if len(errs) == 0 {
+					return fmt.Errorf("%w: no decoder for fallback", ErrInvalidArg)
+				}
+
+				return fmt.Errorf("iox: no decoder succeeded: %w", errors.Join(errs...))
+			},
+		}
+	}
+}
+
+// resetValue sets the value pointed to by 'v' to its zero value, if 'v' is a
+// non-nil pointer.
+func resetValue(v any) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv.Elem().SetZero()
+	}
+}
@@ -0,0 +1,215 @@
+package iox
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// Record framing.
+// -----------------------------------------------------------------------------
+
+func TestRecordsRoundtripIdeal(t *testing.T) {
+	cfg := RecordCfg{Prefix: []byte{0x1e}}
+	b := bytes.NewBuffer(nil)
+	w := NewWriterFromRecords(b)(cfg)
+
+	assertEq("err", *new(error), w.Write(nil, []byte(`{"a":1}`)), func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), w.Write(nil, []byte(`{"a":2}`)), func(s string) { t.Fatal(s) })
+	assertEq("bytes", "\x1e{\"a\":1}\n\x1e{\"a\":2}\n", b.String(), func(s string) { t.Fatal(s) })
+
+	r := NewReaderFromRecords(b)(cfg)
+	for _, want := range []string{`{"a":1}`, `{"a":2}`} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, string(val), func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestRecordsRoundtripWithEscaping(t *testing.T) {
+	esc := strings.NewReplacer(`\`, `\\`, `|`, `\p`)
+	unesc := strings.NewReplacer(`\\`, `\`, `\p`, `|`)
+	cfg := RecordCfg{
+		Sep:      []byte("||"),
+		Escape:   func(p []byte) []byte { return []byte(esc.Replace(string(p))) },
+		Unescape: func(p []byte) []byte { return []byte(unesc.Replace(string(p))) },
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := NewWriterFromRecords(b)(cfg)
+	w.Write(nil, []byte("a|b"))
+	w.Write(nil, []byte(`c\d`))
+	assertEq("bytes", `a\pb||c\\d||`, b.String(), func(s string) { t.Fatal(s) })
+
+	r := NewReaderFromRecords(b)(cfg)
+	for _, want := range []string{"a|b", `c\d`} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, string(val), func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestNewReaderFromRecordsWithUnterminatedTail(t *testing.T) {
+	r := NewReaderFromRecords(strings.NewReader("a|b||c|"))(RecordCfg{Sep: []byte("||")})
+
+	for _, want := range []string{"a|b", "c|"} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, string(val), func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestRecordsWithNilArgs(t *testing.T) {
+	w := NewWriterFromRecords(nil)(RecordCfg{})
+	assertEq("err", io.ErrClosedPipe, w.Write(nil, []byte("a")), func(s string) { t.Fatal(s) })
+
+	r := NewReaderFromRecords(nil)(RecordCfg{})
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+// -----------------------------------------------------------------------------
+// Chunking.
+// -----------------------------------------------------------------------------
+func TestNewWriterWithChunkingIdeal(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	w := NewWriterWithChunking(NewWriterFromRecords(b)(RecordCfg{}), 4)
+
+	assertEq("err", *new(error), w.Write(nil, []byte("abcdefghij")), func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), w.Write(nil, []byte("")), func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), w.Write(nil, []byte("abcd")), func(s string) { t.Fatal(s) })
+
+	want := "0/3:abcd\n1/3:efgh\n2/3:ij\n0/1:\n0/1:abcd\n"
+	assertEq("val", want, b.String(), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithUnchunkingIdeal(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	w := NewWriterWithChunking(NewWriterFromRecords(b)(RecordCfg{}), 3)
+
+	vs := []string{"abcdefghij", "", "xyz"}
+	for _, v := range vs {
+		assertEq("err", *new(error), w.Write(nil, []byte(v)), func(s string) { t.Fatal(s) })
+	}
+
+	r := NewReaderWithUnchunking(NewReaderFromRecords(b)(RecordCfg{}), 0)
+	for _, want := range vs {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, string(val), func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithUnchunkingWithCorruption(t *testing.T) {
+	chunks := [][]byte{
+		[]byte("0/2:ab"), // Missing 1/2.
+		[]byte("0/1:cd"),
+		[]byte("1/2:ef"), // Orphan.
+		[]byte("junk"),
+		[]byte("0/2:gh"),
+		[]byte("1/2:ij"),
+		[]byte("0/2:kl"), // Truncated.
+	}
+
+	r := NewReaderWithUnchunking(NewReaderFrom(chunks...), 0)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, errors.Is(err, ErrChunkCorrupt), func(s string) { t.Fatal(s) })
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", "cd", string(val), func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", true, errors.Is(err, ErrChunkCorrupt), func(s string) { t.Fatal(s) })
+	_, err = r.Read(nil)
+	assertEq("err", true, errors.Is(err, ErrChunkCorrupt), func(s string) { t.Fatal(s) })
+
+	val, err = r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", "ghij", string(val), func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", io.ErrUnexpectedEOF, err, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithUnchunkingWithMaxSize(t *testing.T) {
+	r := NewReaderWithUnchunking(NewReaderFrom([]byte("0/2:abc"), []byte("1/2:def"), []byte("0/1:gh")), 4)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, errors.Is(err, ErrChunkCorrupt), func(s string) { t.Fatal(s) })
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", "gh", string(val), func(s string) { t.Fatal(s) })
+}
+
+// -----------------------------------------------------------------------------
+// Decoder fallback.
+// -----------------------------------------------------------------------------
+
+func TestNewDecoderWithFallbackIdeal(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+	}
+
+	strict := func(r io.Reader) Decoder {
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+		return dec
+	}
+	// Legacy frames are plain names.
+	legacy := func(r io.Reader) Decoder {
+		return DecoderImpl{
+			Impl: func(v any) error {
+				b, err := io.ReadAll(r)
+				v.(*user).Name = string(b)
+				return err
+			},
+		}
+	}
+
+	src := strings.NewReader("{\"name\":\"a\"}\nb\n")
+	r := NewReaderFromBytes[user](src)(NewDecoderWithFallback(RecordCfg{}, strict, legacy))
+
+	for _, want := range []string{"a", "b"} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val.Name, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewDecoderWithFallbackWithAllFailing(t *testing.T) {
+	f := func(r io.Reader) Decoder { return json.NewDecoder(r) }
+	r := NewReaderFromBytes[int](strings.NewReader("x\n"))(NewDecoderWithFallback(RecordCfg{}, f, f))
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err != nil && err != io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewDecoderWithFallbackWithNoDecoders(t *testing.T) {
+	nilDecoder := func(r io.Reader) Decoder { return nil }
+
+	for _, fs := range [][]func(io.Reader) Decoder{nil, {nil, nilDecoder}} {
+		r := NewReaderFromBytes[int](strings.NewReader("1\n"))(NewDecoderWithFallback(RecordCfg{}, fs...))
+
+		_, err := r.Read(nil)
+		assertEq("err", true, errors.Is(err, ErrInvalidArg), func(s string) { t.Fatal(s) })
+	}
+}
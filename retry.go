@@ -0,0 +1,142 @@
+package iox
+
+import (
+	"context"
+	"io"
+)
+
+// -----------------------------------------------------------------------------
+// Retrying.
+// -----------------------------------------------------------------------------
+
+// RetryCfg is used to configure NewReaderWithRetry and NewWriterWithRetry.
+type RetryCfg struct {
+	// MaxAttempts is the max amount of attempts per call, including the
+	// first one, <= 0 defaults to 3.
+	MaxAttempts int
+	// Backoff gives the delays between attempts, it is copied (and reset)
+	// for every call, such that each call starts with Backoff.Initial.
+	Backoff Backoff
+	// Retryable decides whether an err should be retried. Nil defaults to
+	// IsRetryable, see ClassifyErr.
+	Retryable func(error) bool
+}
+
+// retry calls 'f' until it succeeds, returns an err which is not retryable, or
+// the attempts of 'cfg' are exhausted, waiting between attempts as given by
+// cfg.Backoff. The last err from 'f' is returned. The waits honor 'ctx': if
+// it is done while waiting, the ctx err is returned; if the next attempt
+// would start after the deadline of 'ctx' (measured with the Clock in 'ctx',
+// see ClockFrom), the last err from 'f' is returned without waiting.
+func retry(ctx context.Context, cfg RetryCfg, f func() error) error {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 3
+	}
+	if cfg.Retryable == nil {
+		cfg.Retryable = IsRetryable
+	}
+
+	b := cfg.Backoff
+	b.Reset()
+
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil || attempt >= cfg.MaxAttempts || !cfg.Retryable(err) {
+			return err
+		}
+
+		d := b.Next()
+		if ctx != nil {
+			if deadline, ok := ctx.Deadline(); ok && ClockFrom(ctx).Now().Add(d).After(deadline) {
+				return err
+			}
+		}
+
+		if err := SleepCtx(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+// NewReaderWithRetry returns a reader of values from 'r' which retries Reads
+// that fail with a retryable err (see RetryCfg), with backoff between the
+// attempts, e.g for flaky network-backed readers. Note that 'r' must be safe
+// to read again after an err. io.EOF is never retried. If all attempts fail,
+// the err of the last one is returned. Nil 'r' returns an empty non-nil
+// Reader.
+//
+// Example:
+//
+//	r := NewReaderWithRetry(networkReader)(
+//		RetryCfg{
+//			MaxAttempts: 5,
+//			Backoff:     Backoff{Initial: time.Millisecond * 50, Max: time.Second, Jitter: 0.2},
+//		},
+//	)
+func NewReaderWithRetry[T any](r Reader[T]) func(cfg RetryCfg) Reader[T] {
+	return func(cfg RetryCfg) Reader[T] {
+		if r == nil {
+			return nilReader[T]()
+		}
+
+		retryable := cfg.Retryable
+		if retryable == nil {
+			retryable = IsRetryable
+		}
+
+		cfg.Retryable = func(err error) bool {
+			return err != io.EOF && retryable(err)
+		}
+
+		return ReaderImpl[T]{
+			Wraps: r,
+			Impl: func(ctx context.Context) (val T, err error) {
+				err = retry(ctx, cfg, func() (err error) {
+					val, err = r.Read(ctx)
+					return err
+				})
+
+				return val, err
+			},
+		}
+	}
+}
+
+// NewWriterWithRetry is the Writer analog of NewReaderWithRetry: Writes into
+// 'w' which fail with a retryable err (see RetryCfg) are retried with backoff
+// between the attempts, and the err of the last attempt is returned if all of
+// them fail. The ctx of the Write is honored: no attempt is started after its
+// deadline, see RetryCfg. io.ErrClosedPipe is never retried. Nil 'w' returns
+// an empty Writer.
+//
+// Example:
+//
+//	w := NewWriterWithRetry(httpSink)(
+//		RetryCfg{
+//			MaxAttempts: 4,
+//			Backoff:     Backoff{Initial: time.Millisecond * 100, Factor: 3, Jitter: 0.5},
+//		},
+//	)
+func NewWriterWithRetry[T any](w Writer[T]) func(cfg RetryCfg) Writer[T] {
+	return func(cfg RetryCfg) Writer[T] {
+		if w == nil {
+			return nilWriter[T]()
+		}
+
+		retryable := cfg.Retryable
+		if retryable == nil {
+			retryable = IsRetryable
+		}
+
+		cfg.Retryable = func(err error) bool {
+			return err != io.ErrClosedPipe && retryable(err)
+		}
+
+		return WriterImpl[T]{
+			Wraps: w,
+			Impl: func(ctx context.Context, v T) error {
+				return retry(ctx, cfg, func() error { return w.Write(ctx, v) })
+			},
+		}
+	}
+}
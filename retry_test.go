@@ -0,0 +1,97 @@
+package iox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// Retrying.
+// -----------------------------------------------------------------------------
+
+func TestNewReaderWithRetryIdeal(t *testing.T) {
+	clock := &advancingClock{now: time.Unix(100, 0)}
+	ctx := WithClock(context.Background(), clock)
+
+	errFlaky := WithErrClass(errors.New("flaky"), ErrClassRetryable)
+	src := newResultReader([]int{0, 0, 1}, []error{errFlaky, errFlaky, nil})
+	r := NewReaderWithRetry(src)(RetryCfg{Backoff: Backoff{Initial: time.Second}})
+
+	val, err := r.Read(ctx)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+	assertEq("waits", []time.Duration{time.Second, time.Second * 2}, clock.waits, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithRetryWithExhaustedAttempts(t *testing.T) {
+	clock := &advancingClock{now: time.Unix(100, 0)}
+	ctx := WithClock(context.Background(), clock)
+
+	errFlaky := errors.New("flaky")
+	src := newResultReader([]int{0, 0, 1}, []error{errFlaky, errFlaky, nil})
+	r := NewReaderWithRetry(src)(RetryCfg{
+		MaxAttempts: 2,
+		Retryable:   func(err error) bool { return err == errFlaky },
+	})
+
+	_, err := r.Read(ctx)
+	assertEq("err", true, err == errFlaky, func(s string) { t.Fatal(s) })
+	assertEq("waits", 1, len(clock.waits), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithRetryWithTerminalErr(t *testing.T) {
+	clock := &advancingClock{now: time.Unix(100, 0)}
+	ctx := WithClock(context.Background(), clock)
+
+	r := NewReaderWithRetry(NewReaderFrom[int]())(RetryCfg{Retryable: func(error) bool { return true }})
+
+	_, err := r.Read(ctx)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("waits", 0, len(clock.waits), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithRetryWithDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+
+	errFlaky := WithErrClass(errors.New("flaky"), ErrClassRetryable)
+	src := newResultReader([]int{0, 1}, []error{errFlaky, nil})
+	r := NewReaderWithRetry(src)(RetryCfg{Backoff: Backoff{Initial: time.Hour}})
+
+	// The next attempt would start after the deadline, so it is not waited for.
+	_, err := r.Read(ctx)
+	assertEq("err", true, err == errFlaky, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithRetryIdeal(t *testing.T) {
+	clock := &advancingClock{now: time.Unix(100, 0)}
+	ctx := WithClock(context.Background(), clock)
+
+	attempts := 0
+	w := NewWriterWithRetry[int](WriterImpl[int]{
+		Impl: func(ctx context.Context, v int) error {
+			if attempts++; attempts < 3 {
+				return context.DeadlineExceeded
+			}
+
+			return nil
+		},
+	})(RetryCfg{Backoff: Backoff{Initial: time.Second, Factor: 3}})
+
+	assertEq("err", *new(error), w.Write(ctx, 1), func(s string) { t.Fatal(s) })
+	assertEq("attempts", 3, attempts, func(s string) { t.Fatal(s) })
+	assertEq("waits", []time.Duration{time.Second, time.Second * 3}, clock.waits, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithRetryWithClosedWriter(t *testing.T) {
+	clock := &advancingClock{now: time.Unix(100, 0)}
+	ctx := WithClock(context.Background(), clock)
+
+	w := NewWriterWithRetry(NewWriterFromErr[int](nil))(RetryCfg{Retryable: func(error) bool { return true }})
+
+	assertEq("err", true, w.Write(ctx, 1) == io.ErrClosedPipe, func(s string) { t.Fatal(s) })
+	assertEq("waits", 0, len(clock.waits), func(s string) { t.Fatal(s) })
+}
@@ -0,0 +1,292 @@
+package iox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// Runnable iface + impl.
+// -----------------------------------------------------------------------------
+
+// Runnable is a stage of a pipeline which runs until it is done, fails, or its
+// ctx is cancelled, e.g a loop which moves values from a Reader to a Writer.
+type Runnable interface {
+	Run(context.Context) error
+}
+
+// RunnableImpl lets you implement Runnable with a function. Place it into
+// "Impl" and it will be called by the "Run" method.
+type RunnableImpl struct {
+	Impl func(context.Context) error
+}
+
+// Run implements Runnable by deferring to the internal "Impl" func.
+// If the internal "Impl" is not set, nothing will happen.
+func (impl RunnableImpl) Run(ctx context.Context) (err error) {
+	if impl.Impl == nil {
+		return
+	}
+
+	return impl.Impl(orBackground(ctx))
+}
+
+// NewRunnableWithName returns a Runnable which runs 'r' and has a Name, which
+// is used to tag errors from panics in 'r', see PanicError. Nil 'r' returns a
+// Runnable which does nothing.
+func NewRunnableWithName(r Runnable) func(name string) Runnable {
+	return func(name string) Runnable {
+		if r == nil {
+			r = RunnableImpl{}
+		}
+
+		return namedRunnable{Runnable: r, name: name}
+	}
+}
+
+type namedRunnable struct {
+	Runnable
+	name string
+}
+
+func (r namedRunnable) Name() string {
+	return r.name
+}
+
+// StageTimeout is the time budget of a stage, see WithStageTimeout.
+type StageTimeout struct {
+	// Fraction is the share of the time left until the deadline of the
+	// parent ctx, e.g 0.5 for half. It is ignored if <= 0, or if the parent
+	// ctx has no deadline.
+	Fraction float64
+	// Fixed is a fixed duration, it is ignored if <= 0. If both Fixed and
+	// Fraction apply, the shorter of the two is used.
+	Fixed time.Duration
+}
+
+// WithStageTimeout returns a copy of 'ctx' with a deadline apportioned from
+// the deadline of 'ctx' as given by 't', e.g such that a 30s request budget
+// is split predictably between stages. The deadline of the parent is never
+// extended. If no deadline applies, the returned ctx is only cancellable.
+// Nil 'ctx' is treated as context.Background(). The time left is measured
+// with the Clock in 'ctx' (see ClockFrom), but the deadline itself is kept
+// by package context, i.e the system clock.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+//	defer cancel()
+//
+//	readCtx, cancel := WithStageTimeout(ctx, StageTimeout{Fraction: 0.2}) // 6s.
+//	defer cancel()
+func WithStageTimeout(ctx context.Context, t StageTimeout) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var d time.Duration
+	set := false
+	if t.Fixed > 0 {
+		d, set = t.Fixed, true
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && t.Fraction > 0 {
+		left := deadline.Sub(ClockFrom(ctx).Now())
+		share := time.Duration(float64(left) * min(t.Fraction, 1))
+		if !set || share < d {
+			d, set = share, true
+		}
+	}
+
+	if !set {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, d)
+}
+
+// NewRunnableWithTimeout returns a Runnable which runs 'r' with a ctx derived
+// with WithStageTimeout, such that each stage of a pipeline gets its own share
+// of the time budget. The Name of 'r' is kept (see NewRunnableWithName). Nil
+// 'r' returns a Runnable which does nothing.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+//	defer cancel()
+//
+//	err := RunGroup(ctx,
+//		NewRunnableWithTimeout(ingest)(StageTimeout{Fraction: 0.5}),
+//		NewRunnableWithTimeout(sink)(StageTimeout{Fixed: time.Second * 10}),
+//	)
+func NewRunnableWithTimeout(r Runnable) func(t StageTimeout) Runnable {
+	return func(t StageTimeout) Runnable {
+		if r == nil {
+			return RunnableImpl{}
+		}
+
+		timed := RunnableImpl{
+			Impl: func(ctx context.Context) error {
+				ctx, cancel := WithStageTimeout(ctx, t)
+				defer cancel()
+
+				return r.Run(ctx)
+			},
+		}
+
+		if named, ok := r.(interface{ Name() string }); ok {
+			return namedRunnable{Runnable: timed, name: named.Name()}
+		}
+
+		return timed
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Panics.
+// -----------------------------------------------------------------------------
+
+// PanicError is returned in place of a panic which was recovered from a stage
+// of a pipeline, e.g by RunGroup.
+type PanicError struct {
+	// Stage is the name of the stage which panicked, see NewRunnableWithName.
+	// Unnamed stages are named by their index in the list of stages, e.g "#2".
+	Stage string
+	// Value is the value given to panic.
+	Value any
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+// Error implements error, e.g "iox: panic in stage parse: boom".
+func (err *PanicError) Error() string {
+	return fmt.Sprintf("iox: panic in stage %s: %v", err.Stage, err.Value)
+}
+
+// Unwrap returns the value given to panic, if it is an error.
+func (err *PanicError) Unwrap() error {
+	e, _ := err.Value.(error)
+	return e
+}
+
+// runStage runs 'stage', converting a panic into a *PanicError.
+func runStage(ctx context.Context, i int, stage Runnable) (err error) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+
+		name := "#" + strconv.Itoa(i)
+		if named, ok := stage.(interface{ Name() string }); ok {
+			name = named.Name()
+		}
+
+		err = &PanicError{Stage: name, Value: v, Stack: debug.Stack()}
+	}()
+
+	return stage.Run(ctx)
+}
+
+// -----------------------------------------------------------------------------
+// Group.
+// -----------------------------------------------------------------------------
+
+// Goer runs funcs concurrently, it is satisfied by Group, as well as by e.g
+// *errgroup.Group from golang.org/x/sync.
+type Goer interface {
+	Go(f func() error)
+}
+
+// Group is a dependency-free equivalent of errgroup.Group: it runs funcs in
+// goroutines, cancels its ctx on the first err, and Wait returns that err.
+// io.EOF and io.ErrClosedPipe are treated as a clean stop (i.e nil), since
+// they signal that a source is exhausted or a sink stopped accepting values.
+// A Group must be created with NewGroup.
+type Group struct {
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+
+	once sync.Once
+	err  error
+}
+
+// NewGroup returns a new Group and a ctx derived from 'ctx', which is
+// cancelled when a func of the Group fails or when Wait returns. Nil 'ctx' is
+// treated as context.Background().
+func NewGroup(ctx context.Context) (*Group, context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group{cancel: cancel}, ctx
+}
+
+// Go runs 'f' in a new goroutine.
+func (g *Group) Go(f func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		err := f()
+		if err == nil || err == io.EOF || err == io.ErrClosedPipe {
+			return
+		}
+
+		g.once.Do(func() {
+			g.err = err
+			g.cancel()
+		})
+	}()
+}
+
+// Wait blocks until all funcs of the Group have returned, and returns the
+// first err, if any.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
+
+// GoStages runs each stage with 'ctx' on 'g', e.g a Group or an errgroup.Group.
+// A panic in a stage is recovered and returned as a *PanicError, so that one
+// misbehaving stage fails the run cleanly instead of crashing the process.
+// Nil stages are ignored.
+//
+// Example:
+//
+//	g, ctx := errgroup.WithContext(ctx)
+//	GoStages(ctx, g, ingest, transform, sink)
+//	err := g.Wait()
+func GoStages(ctx context.Context, g Goer, stages ...Runnable) {
+	for i, stage := range stages {
+		if stage == nil {
+			continue
+		}
+
+		g.Go(func() error { return runStage(ctx, i, stage) })
+	}
+}
+
+// RunGroup runs all stages concurrently in a Group, see NewGroup, and returns
+// when all of them have returned. The first err cancels the ctx of the other
+// stages, and is returned. Panics are returned as a *PanicError, see GoStages.
+// Nil stages are ignored.
+//
+// Example:
+//
+//	err := RunGroup(ctx,
+//		RunnableImpl{Impl: func(ctx context.Context) error { ... }},
+//		RunnableImpl{Impl: func(ctx context.Context) error { ... }},
+//	)
+func RunGroup(ctx context.Context, stages ...Runnable) error {
+	g, ctx := NewGroup(ctx)
+	GoStages(ctx, g, stages...)
+	return g.Wait()
+}
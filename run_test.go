@@ -0,0 +1,161 @@
+package iox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// Runnable impl.
+// -----------------------------------------------------------------------------
+
+func TestRunnableImplRunWithNilImpl(t *testing.T) {
+	err := RunnableImpl{}.Run(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+}
+
+func TestWithStageTimeoutIdeal(t *testing.T) {
+	now := time.Now()
+	parent, cancel := context.WithDeadline(context.Background(), now.Add(time.Second*30))
+	defer cancel()
+	parent = WithClock(parent, testClock{now: now})
+
+	for _, c := range []struct {
+		t    StageTimeout
+		want time.Duration
+	}{
+		{StageTimeout{Fraction: 0.2}, time.Second * 6},
+		{StageTimeout{Fixed: time.Second * 10}, time.Second * 10},
+		{StageTimeout{Fraction: 0.5, Fixed: time.Second * 10}, time.Second * 10},
+		{StageTimeout{Fraction: 0.1, Fixed: time.Second * 10}, time.Second * 3},
+		{StageTimeout{Fixed: time.Minute}, time.Second * 30},
+	} {
+		ctx, cancel := WithStageTimeout(parent, c.t)
+		deadline, ok := ctx.Deadline()
+		cancel()
+
+		assertEq("ok", true, ok, func(s string) { t.Fatal(s) })
+
+		// The deadline is kept by package context, so allow for some drift.
+		diff := deadline.Sub(now) - c.want
+		assertEq("deadline", true, diff >= 0 && diff < time.Second, func(s string) { t.Fatalf("%v: %s", c.t, s) })
+	}
+}
+
+func TestWithStageTimeoutWithoutDeadline(t *testing.T) {
+	ctx, cancel := WithStageTimeout(nil, StageTimeout{Fraction: 0.5})
+	defer cancel()
+
+	_, ok := ctx.Deadline()
+	assertEq("ok", false, ok, func(s string) { t.Fatal(s) })
+}
+
+func TestNewRunnableWithTimeoutIdeal(t *testing.T) {
+	stage := NewRunnableWithName(RunnableImpl{
+		Impl: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})("slow")
+
+	r := NewRunnableWithTimeout(stage)(StageTimeout{Fixed: time.Millisecond * 10})
+	assertEq("name", "slow", r.(interface{ Name() string }).Name(), func(s string) { t.Fatal(s) })
+
+	err := RunGroup(context.Background(), r)
+	assertEq("err", true, err == context.DeadlineExceeded, func(s string) { t.Fatal(s) })
+}
+
+// -----------------------------------------------------------------------------
+// Group.
+// -----------------------------------------------------------------------------
+
+func TestRunGroupIdeal(t *testing.T) {
+	ch := make(chan int)
+	var s []int
+
+	producer := RunnableImpl{Impl: func(ctx context.Context) error {
+		defer close(ch)
+		for i := 0; i < 3; i++ {
+			ch <- i
+		}
+
+		return io.EOF
+	}}
+	consumer := RunnableImpl{Impl: func(ctx context.Context) error {
+		for v := range ch {
+			s = append(s, v)
+		}
+
+		return nil
+	}}
+
+	err := RunGroup(nil, producer, nil, consumer)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("s", []int{0, 1, 2}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestRunGroupWithErr(t *testing.T) {
+	errStage := errors.New("stage")
+
+	failing := RunnableImpl{Impl: func(ctx context.Context) error {
+		return errStage
+	}}
+	blocking := RunnableImpl{Impl: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	err := RunGroup(context.Background(), blocking, failing)
+	assertEq("err", errStage, err, func(s string) { t.Fatal(s) })
+}
+
+// goerFunc implements Goer, standing in for e.g errgroup.Group.
+type goerFunc func(f func() error)
+
+func (g goerFunc) Go(f func() error) { g(f) }
+
+func TestGoStagesWithCustomGoer(t *testing.T) {
+	var errs []error
+	g := goerFunc(func(f func() error) { errs = append(errs, f()) })
+
+	stage := RunnableImpl{Impl: func(ctx context.Context) error { return io.ErrUnexpectedEOF }}
+	GoStages(nil, g, stage, stage)
+
+	assertEq("errs", 2, len(errs), func(s string) { t.Fatal(s) })
+	assertEq("err", io.ErrUnexpectedEOF, errs[0], func(s string) { t.Fatal(s) })
+}
+
+// -----------------------------------------------------------------------------
+// Panics.
+// -----------------------------------------------------------------------------
+
+func TestRunGroupWithPanic(t *testing.T) {
+	panicking := RunnableImpl{Impl: func(ctx context.Context) error { panic("boom") }}
+	blocking := RunnableImpl{Impl: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	err := RunGroup(nil, blocking, panicking)
+
+	var perr *PanicError
+	assertEq("ok", true, errors.As(err, &perr), func(s string) { t.Fatal(s) })
+	assertEq("stage", "#1", perr.Stage, func(s string) { t.Fatal(s) })
+	assertEq("value", "boom", perr.Value, func(s string) { t.Fatal(s) })
+	assertEq("err", "iox: panic in stage #1: boom", perr.Error(), func(s string) { t.Fatal(s) })
+}
+
+func TestRunGroupWithNamedPanic(t *testing.T) {
+	errBoom := errors.New("boom")
+	stage := NewRunnableWithName(RunnableImpl{Impl: func(ctx context.Context) error { panic(errBoom) }})("parse")
+
+	err := RunGroup(nil, stage)
+
+	var perr *PanicError
+	assertEq("ok", true, errors.As(err, &perr), func(s string) { t.Fatal(s) })
+	assertEq("stage", "parse", perr.Stage, func(s string) { t.Fatal(s) })
+	assertEq("unwrap", true, errors.Is(err, errBoom), func(s string) { t.Fatal(s) })
+}
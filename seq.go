@@ -0,0 +1,141 @@
+//go:build go1.23
+
+package iox
+
+import (
+	"context"
+	"io"
+	"iter"
+)
+
+// -----------------------------------------------------------------------------
+// Iterators.
+// -----------------------------------------------------------------------------
+
+// NewReaderFromSeq returns a ReadCloser of the values of 'seq', such that
+// standard iterators can be used as sources. Values are pulled from 'seq' one
+// at a time with iter.Pull, so 'seq' is suspended between Reads. Read returns
+// io.EOF once 'seq' is exhausted. Close stops 'seq', after which Read returns
+// io.EOF; it should be called if 'seq' is not read until io.EOF. Nil 'seq'
+// returns an empty non-nil ReadCloser.
+//
+// Example:
+//
+//	r := NewReaderFromSeq(slices.Values([]int{1, 2}))
+//	defer r.Close()
+//
+//	t.Log(r.Read(nil)) // 1, nil
+//	t.Log(r.Read(nil)) // 2, nil
+//	t.Log(r.Read(nil)) // 0, io.EOF
+func NewReaderFromSeq[T any](seq iter.Seq[T]) ReadCloser[T] {
+	if seq == nil {
+		return nilReadCloser[T]()
+	}
+
+	next, stop := iter.Pull(seq)
+	return ReadCloserImpl[T]{
+		ImplC: func() error {
+			stop()
+			return nil
+		},
+		ImplR: func(ctx context.Context) (val T, err error) {
+			if err := ctxErr(ctx); err != nil {
+				return val, err
+			}
+
+			val, ok := next()
+			if !ok {
+				return val, io.EOF
+			}
+
+			return val, nil
+		},
+	}
+}
+
+// Seq returns an iterator over the values of 'r', such that it can be
+// consumed with a range loop. Each value is read from 'r' with 'ctx'. The
+// iteration ends on the first err from 'r', including io.EOF; see Seq2 for
+// observing the err. Nil 'r' yields nothing.
+//
+// Example:
+//
+//	for v := range Seq(ctx, NewReaderFrom(1, 2)) {
+//		t.Log(v) // 1, then 2.
+//	}
+func Seq[T any](ctx context.Context, r Reader[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if r == nil {
+			return
+		}
+
+		for {
+			v, err := r.Read(ctx)
+			if err != nil || !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// NewReaderFromSeq2 is like NewReaderFromSeq, but for iterators which carry
+// an err with each value, e.g as returned by Seq2. A pair with a non-nil err
+// is returned as-is by Read, and the next Read continues with the next pair;
+// an io.EOF err is therefore treated as any other. Nil 'seq' returns an empty
+// non-nil ReadCloser.
+func NewReaderFromSeq2[T any](seq iter.Seq2[T, error]) ReadCloser[T] {
+	if seq == nil {
+		return nilReadCloser[T]()
+	}
+
+	next, stop := iter.Pull2(seq)
+	return ReadCloserImpl[T]{
+		ImplC: func() error {
+			stop()
+			return nil
+		},
+		ImplR: func(ctx context.Context) (val T, err error) {
+			if err := ctxErr(ctx); err != nil {
+				return val, err
+			}
+
+			val, err, ok := next()
+			if !ok {
+				return val, io.EOF
+			}
+
+			return val, err
+		},
+	}
+}
+
+// Seq2 is like Seq, but the iterator also yields errors, such that a range
+// loop can tell an exhausted stream from a failed one. A non-EOF err from 'r'
+// is yielded (with the value returned along with it) and ends the iteration;
+// io.EOF ends it without being yielded. Nil 'r' yields nothing.
+//
+// Example:
+//
+//	for v, err := range Seq2(ctx, r) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func Seq2[T any](ctx context.Context, r Reader[T]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		if r == nil {
+			return
+		}
+
+		for {
+			v, err := r.Read(ctx)
+			if err == io.EOF {
+				return
+			}
+			if !yield(v, err) || err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+//go:build go1.23
+
+package iox
+
+import (
+	"io"
+	"slices"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// Iterators.
+// -----------------------------------------------------------------------------
+
+func TestNewReaderFromSeqIdeal(t *testing.T) {
+	r := NewReaderFromSeq(slices.Values([]int{1, 2}))
+	defer r.Close()
+
+	vs, err := readAll[int](r)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("vals", []int{1, 2}, vs, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderFromSeqWithClose(t *testing.T) {
+	r := NewReaderFromSeq(slices.Values([]int{1, 2}))
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+
+	r.Close()
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestSeqIdeal(t *testing.T) {
+	vs := slices.Collect(Seq(nil, NewReaderFrom(1, 2, 3)))
+	assertEq("vals", []int{1, 2, 3}, vs, func(s string) { t.Fatal(s) })
+}
+
+func TestSeqWithBreak(t *testing.T) {
+	r := NewReaderFrom(1, 2, 3)
+	for v := range Seq(nil, r) {
+		if v == 2 {
+			break
+		}
+	}
+
+	// The value after the break is left in 'r'.
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 3, val, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderFromSeq2Ideal(t *testing.T) {
+	seq := func(yield func(int, error) bool) {
+		_ = yield(1, nil) && yield(0, io.ErrUnexpectedEOF) && yield(2, nil)
+	}
+
+	r := NewReaderFromSeq2(seq)
+	defer r.Close()
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.ErrUnexpectedEOF, func(s string) { t.Fatal(s) })
+
+	val, err = r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 2, val, func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestSeq2Ideal(t *testing.T) {
+	var vs []int
+	for v, err := range Seq2(nil, NewReaderFrom(1, 2)) {
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		vs = append(vs, v)
+	}
+
+	assertEq("vals", []int{1, 2}, vs, func(s string) { t.Fatal(s) })
+}
+
+func TestSeq2WithReadErr(t *testing.T) {
+	r := newResultReader([]int{1, 0, 3}, []error{nil, io.ErrUnexpectedEOF, nil})
+
+	var errs []error
+	for _, err := range Seq2(nil, r) {
+		errs = append(errs, err)
+	}
+
+	assertEq("n", 2, len(errs), func(s string) { t.Fatal(s) })
+	assertEq("err", true, errs[1] == io.ErrUnexpectedEOF, func(s string) { t.Fatal(s) })
+}
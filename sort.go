@@ -0,0 +1,455 @@
+package iox
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// K-way merge.
+// -----------------------------------------------------------------------------
+
+// mergeHead is the next value of one of the readers in a k-way merge.
+type mergeHead[T any] struct {
+	v T
+	i int // Index of the reader which yielded v, used as a tiebreaker.
+}
+
+// mergeHeap is a min-heap of mergeHeads, implementing heap.Interface.
+type mergeHeap[T any] struct {
+	heads []mergeHead[T]
+	less  func(a, b T) bool
+}
+
+func (h *mergeHeap[T]) Len() int { return len(h.heads) }
+func (h *mergeHeap[T]) Swap(i, j int) {
+	h.heads[i], h.heads[j] = h.heads[j], h.heads[i]
+}
+
+func (h *mergeHeap[T]) Less(i, j int) bool {
+	a, b := h.heads[i], h.heads[j]
+	if h.less(a.v, b.v) {
+		return true
+	}
+	if h.less(b.v, a.v) {
+		return false
+	}
+
+	return a.i < b.i
+}
+
+func (h *mergeHeap[T]) Push(x any) { h.heads = append(h.heads, x.(mergeHead[T])) }
+func (h *mergeHeap[T]) Pop() any {
+	x := h.heads[len(h.heads)-1]
+	h.heads = h.heads[:len(h.heads)-1]
+	return x
+}
+
+// NewReaderWithSortedMerge returns a reader which merges the given readers,
+// each of which must already be sorted by 'less', into one sorted stream,
+// e.g for merging time-ordered log segments. It is a heap-based k-way merge,
+// so only one value per reader is held in memory. Ties are yielded in the
+// order of 'rs'. The first Read reads one value from every reader; a non-EOF
+// err from any of them is returned on every Read. Nil readers are skipped;
+// nil 'less' concatenates 'rs', see NewReaderFromMulti.
+//
+// Example:
+//
+//	r := NewReaderWithSortedMerge(
+//		func(a, b int) bool { return a < b },
+//		NewReaderFrom(1, 4),
+//		NewReaderFrom(2, 3),
+//	)
+//
+//	t.Log(r.Read(nil)) // 1, nil
+//	t.Log(r.Read(nil)) // 2, nil
+//	t.Log(r.Read(nil)) // 3, nil
+//	t.Log(r.Read(nil)) // 4, nil
+//	t.Log(r.Read(nil)) // 0, io.EOF
+func NewReaderWithSortedMerge[T any](less func(a, b T) bool, rs ...Reader[T]) Reader[T] {
+	if less == nil {
+		return NewReaderFromMulti(rs...)
+	}
+
+	nonNil := make([]Reader[T], 0, len(rs))
+	for _, r := range rs {
+		if r != nil {
+			nonNil = append(nonNil, r)
+		}
+	}
+
+	return newSortedMergeReader(less, nonNil)
+}
+
+// newSortedMergeReader returns a reader which merges the pre-sorted 'rs' into
+// one sorted stream, using a heap. Ties are yielded in the order of 'rs'.
+func newSortedMergeReader[T any](less func(a, b T) bool, rs []Reader[T]) Reader[T] {
+	h := &mergeHeap[T]{heads: make([]mergeHead[T], 0, len(rs)), less: less}
+	initialized := false
+	var errCache error
+
+	// advance reads the next value of rs[i] into the heap.
+	advance := func(ctx context.Context, i int) error {
+		v, err := rs[i].Read(ctx)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		heap.Push(h, mergeHead[T]{v: v, i: i})
+		return nil
+	}
+
+	return ReaderImpl[T]{
+		Impl: func(ctx context.Context) (val T, err error) {
+			if errCache != nil {
+				return val, errCache
+			}
+
+			if !initialized {
+				for i := range rs {
+					if errCache = advance(ctx, i); errCache != nil {
+						return val, errCache
+					}
+				}
+
+				initialized = true
+			}
+
+			if h.Len() == 0 {
+				return val, io.EOF
+			}
+
+			head := heap.Pop(h).(mergeHead[T])
+			if errCache = advance(ctx, head.i); errCache != nil {
+				return val, errCache
+			}
+
+			return head.v, nil
+		},
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Time alignment.
+// -----------------------------------------------------------------------------
+
+// NewReaderWithTimeAlign returns a reader which merges 'a' and 'b' into one
+// stream in global timestamp order, e.g to correlate requests and responses
+// which are logged separately. Each source may be out of order by up to
+// 'maxSkew': values are buffered until both sources have progressed beyond
+// them by more than 'maxSkew', such that a value is only yielded when no
+// earlier value can arrive anymore. A source which is further ahead is not
+// read until the other catches up, which bounds the buffer. Ties are yielded
+// with values from 'a' first. io.EOF is returned once both sources are
+// exhausted and the buffer is drained; other errors are returned as-is, and
+// the next Read retries. Nil sources are treated as empty.
+//
+// Example:
+//
+//	// Requests at 1s, 3s. Responses at 2s, 4s.
+//	r := NewReaderWithTimeAlign(requests, responses)(time.Second)
+//
+//	t.Log(r.Read(nil)) // Request at 1s, nil
+//	t.Log(r.Read(nil)) // Response at 2s, nil
+//	t.Log(r.Read(nil)) // Request at 3s, nil
+//	t.Log(r.Read(nil)) // Response at 4s, nil
+func NewReaderWithTimeAlign[T any](a, b Reader[Timestamped[T]]) func(maxSkew time.Duration) Reader[Timestamped[T]] {
+	return func(maxSkew time.Duration) Reader[Timestamped[T]] {
+		type source struct {
+			r    Reader[Timestamped[T]]
+			last time.Time
+			seen bool
+			done bool
+		}
+
+		srcs := [2]*source{{r: a, done: a == nil}, {r: b, done: b == nil}}
+		h := &mergeHeap[Timestamped[T]]{
+			less: func(x, y Timestamped[T]) bool { return x.Time.Before(y.Time) },
+		}
+
+		// ready reports whether the head of the buffer can no longer be
+		// preceded by a value from a source which is not done.
+		ready := func() bool {
+			for _, src := range srcs {
+				if src.done {
+					continue
+				}
+				if !src.seen || !h.heads[0].v.Time.Before(src.last.Add(-maxSkew)) {
+					return false
+				}
+			}
+
+			return true
+		}
+
+		return ReaderImpl[Timestamped[T]]{
+			Impl: func(ctx context.Context) (val Timestamped[T], err error) {
+				for {
+					if h.Len() > 0 && ready() {
+						return heap.Pop(h).(mergeHead[Timestamped[T]]).v, nil
+					}
+
+					// Reads from the source which is furthest behind.
+					i := -1
+					for j, src := range srcs {
+						if src.done {
+							continue
+						}
+						if i < 0 || !src.seen || (srcs[i].seen && src.last.Before(srcs[i].last)) {
+							i = j
+						}
+						if !src.seen {
+							break
+						}
+					}
+
+					if i < 0 {
+						return val, io.EOF
+					}
+
+					v, err := srcs[i].r.Read(ctx)
+					if err == io.EOF {
+						srcs[i].done = true
+						continue
+					}
+					if err != nil {
+						return val, err
+					}
+
+					if !srcs[i].seen || v.Time.After(srcs[i].last) {
+						srcs[i].last = v.Time
+					}
+
+					srcs[i].seen = true
+					heap.Push(h, mergeHead[Timestamped[T]]{v: v, i: i})
+				}
+			},
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Modifiers.
+// -----------------------------------------------------------------------------
+
+// NewReaderWithSort returns a reader which yields the values of 'r' sorted by
+// 'less' (stable, i.e equal values keep their order). It drains 'r' into
+// memory on the first Read, so memory usage grows with the amount of values
+// in 'r', and nothing is yielded until 'r' is exhausted; it is intended for
+// finite streams which fit in memory, see NewReaderWithExternalSort for those
+// which do not. A non-EOF err from 'r' is returned on every Read, and the
+// values read before it are dropped. Nil 'r' returns an empty non-nil Reader;
+// nil 'less' returns 'r'.
+//
+// Example:
+//
+//	r := NewReaderWithSort(NewReaderFrom(3, 1, 2), func(a, b int) bool { return a < b })
+//
+//	t.Log(r.Read(nil)) // 1, nil
+//	t.Log(r.Read(nil)) // 2, nil
+//	t.Log(r.Read(nil)) // 3, nil
+//	t.Log(r.Read(nil)) // 0, io.EOF
+func NewReaderWithSort[T any](r Reader[T], less func(a, b T) bool) Reader[T] {
+	if r == nil {
+		return nilReader[T]()
+	}
+	if less == nil {
+		return r
+	}
+
+	var sorted Reader[T]
+	var errCache error
+
+	return ReaderImpl[T]{
+		Impl: func(ctx context.Context) (val T, err error) {
+			if errCache != nil {
+				return val, errCache
+			}
+
+			if sorted == nil {
+				vs := make([]T, 0, lenHint(r, 64, 0))
+				for {
+					v, err := r.Read(ctx)
+					if err == io.EOF {
+						break
+					}
+					if err != nil {
+						errCache = err
+						return val, errCache
+					}
+
+					vs = append(vs, v)
+				}
+
+				sort.SliceStable(vs, func(i, j int) bool { return less(vs[i], vs[j]) })
+				sorted = NewReaderFrom(vs...)
+			}
+
+			return sorted.Read(ctx)
+		},
+	}
+}
+
+// NewReaderWithExternalSort returns a reader which yields the values of 'r'
+// sorted by 'less', without holding more than 'memLimit' values in memory.
+// It drains 'r' on the first Read: values are collected into runs of at most
+// 'memLimit' values, each run is sorted and (if there are several) spilled to
+// a temporary file in 'tmpDir', and the runs are then streamed through a k-way
+// merge. Values are encoded into the temporary files with the Encoder created
+// by 'fe', and decoded with the Decoder created by 'fd', so they must
+// roundtrip through them; nil 'fe' or 'fd' uses encoding/json (which keeps
+// e.g only exported fields).
+//
+// Close removes all temporary files, so it should always be called. Nil 'r'
+// returns an empty non-nil ReadCloser; nil 'less' returns 'r' as-is (with a
+// noop Close); 'memLimit' <= 0 defaults to 65536; empty 'tmpDir' uses
+// os.TempDir. If the ctx carries a Budget (see WithBudget), a run is also
+// spilled as soon as it exceeds the Budget.
+//
+// Example:
+//
+//	r := NewReaderWithExternalSort(hugeReader, func(a, b int) bool { return a < b }, 1e6, "")(
+//		func(w io.Writer) Encoder { return gob.NewEncoder(w) },
+//		func(r io.Reader) Decoder { return gob.NewDecoder(r) },
+//	)
+//
+//	defer r.Close()
+//
+//	t.Log(r.Read(nil)) // Smallest value, nil
+func NewReaderWithExternalSort[T any](r Reader[T], less func(a, b T) bool, memLimit int, tmpDir string) func(fe encoderFn, fd decoderFn) ReadCloser[T] {
+	return func(fe encoderFn, fd decoderFn) ReadCloser[T] {
+		if r == nil {
+			return nilReadCloser[T]()
+		}
+		if less == nil {
+			return ReadCloserImpl[T]{ImplR: r.Read}
+		}
+
+		if memLimit <= 0 {
+			memLimit = 1 << 16
+		}
+
+		var files []*os.File
+		var sorted Reader[T]
+		var errCache error
+		var hold budgetHold
+
+		cleanup := func() (err error) {
+			for _, f := range files {
+				err = errors.Join(err, f.Close(), os.Remove(f.Name()))
+			}
+
+			files = nil
+			hold.release()
+			return err
+		}
+
+		spill := func(run []T) (Reader[T], error) {
+			f, err := os.CreateTemp(tmpDir, "iox-sort-*")
+			if err != nil {
+				return nil, err
+			}
+
+			files = append(files, f)
+
+			bw := bufio.NewWriter(f)
+			enc := Encoder(json.NewEncoder(bw))
+			if fe != nil {
+				if _enc := fe(bw); _enc != nil {
+					enc = _enc
+				}
+			}
+
+			for _, v := range run {
+				if err := enc.Encode(v); err != nil {
+					return nil, err
+				}
+			}
+
+			if err := bw.Flush(); err != nil {
+				return nil, err
+			}
+
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return nil, err
+			}
+
+			return NewReaderFromBytes[T](bufio.NewReader(f))(fd), nil
+		}
+
+		load := func(ctx context.Context) (Reader[T], error) {
+			var runs []Reader[T]
+			buf := make([]T, 0, lenHint(r, memLimit, memLimit))
+
+			for {
+				v, err := r.Read(ctx)
+				if err != nil && err != io.EOF {
+					return nil, err
+				}
+
+				if err == nil {
+					buf = append(buf, v)
+					overBudget := reserveHold(ctx, &hold, v) != nil
+					if len(buf) < memLimit && !overBudget {
+						continue
+					}
+				}
+
+				sort.SliceStable(buf, func(i, j int) bool { return less(buf[i], buf[j]) })
+
+				// Everything fit in memory, no need for spilling or merging.
+				if err == io.EOF && len(runs) == 0 {
+					return NewReaderFrom(buf...), nil
+				}
+
+				// The last run stays in memory.
+				if err == io.EOF {
+					runs = append(runs, NewReaderFrom(buf...))
+					return newSortedMergeReader(less, runs), nil
+				}
+
+				run, err := spill(buf)
+				if err != nil {
+					return nil, err
+				}
+
+				runs = append(runs, run)
+				buf = buf[:0]
+				hold.release()
+			}
+		}
+
+		return ReadCloserImpl[T]{
+			ImplC: cleanup,
+			ImplR: func(ctx context.Context) (val T, err error) {
+				if errCache != nil {
+					return val, errCache
+				}
+
+				if sorted == nil {
+					sorted, err = load(ctx)
+					if err != nil {
+						if cerr := cleanup(); cerr != nil {
+							err = errors.Join(err, cerr)
+						}
+
+						errCache = err
+						return val, errCache
+					}
+				}
+
+				return sorted.Read(ctx)
+			},
+		}
+	}
+}
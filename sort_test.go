@@ -0,0 +1,183 @@
+package iox
+
+import (
+	"encoding/gob"
+	"io"
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNewReaderWithSortedMergeIdeal(t *testing.T) {
+	less := func(a, b string) bool { return a[0] < b[0] }
+	r := NewReaderWithSortedMerge(less,
+		NewReaderFrom("a0", "c0", "e0"),
+		nil,
+		NewReaderFrom("b1", "c1"),
+		NewReaderFrom[string](),
+		NewReaderFrom("a2", "f2"),
+	)
+
+	vs, err := readAll(r)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("vals", []string{"a0", "a2", "b1", "c0", "c1", "e0", "f2"}, vs, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithSortedMergeWithReadErr(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+	r := NewReaderWithSortedMerge(less,
+		NewReaderFrom(1, 3),
+		newResultReader([]int{2, 0}, []error{nil, io.ErrUnexpectedEOF}),
+	)
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.ErrUnexpectedEOF, func(s string) { t.Fatal(s) })
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.ErrUnexpectedEOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithSortIdeal(t *testing.T) {
+	type pair struct{ K, V int }
+	r := NewReaderWithSort(
+		NewReaderFrom(pair{2, 0}, pair{1, 0}, pair{2, 1}, pair{0, 0}),
+		func(a, b pair) bool { return a.K < b.K },
+	)
+
+	vs, err := readAll(r)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("vals", []pair{{0, 0}, {1, 0}, {2, 0}, {2, 1}}, vs, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithSortWithReadErr(t *testing.T) {
+	src := newResultReader([]int{3, 2, 1}, []error{nil, nil, io.ErrUnexpectedEOF})
+	r := NewReaderWithSort(src, func(a, b int) bool { return a < b })
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.ErrUnexpectedEOF, func(s string) { t.Fatal(s) })
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.ErrUnexpectedEOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithExternalSortIdeal(t *testing.T) {
+	dir := t.TempDir()
+	less := func(a, b int) bool { return a < b }
+	r := NewReaderWithExternalSort(NewReaderFrom(5, 3, 9, 1, 4, 8, 2, 7, 6), less, 2, dir)(nil, nil)
+
+	for want := 1; want <= 9; want++ {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+
+	entries, _ := os.ReadDir(dir)
+	assertEq("spilled", 4, len(entries), func(s string) { t.Fatal(s) })
+
+	assertEq("err", *new(error), r.Close(), func(s string) { t.Fatal(s) })
+	entries, _ = os.ReadDir(dir)
+	assertEq("spilled", 0, len(entries), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithExternalSortWithCodec(t *testing.T) {
+	// complex128 does not roundtrip through encoding/json, but does with gob.
+	less := func(a, b complex128) bool { return real(a) < real(b) }
+	r := NewReaderWithExternalSort(NewReaderFrom(3+1i, 1+2i, 2+3i), less, 1, t.TempDir())(
+		func(w io.Writer) Encoder { return gob.NewEncoder(w) },
+		func(r io.Reader) Decoder { return gob.NewDecoder(r) },
+	)
+
+	defer r.Close()
+
+	vs, err := readAll(r)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("vals", true, slices.Equal([]complex128{1 + 2i, 2 + 3i, 3 + 1i}, vs), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithExternalSortInMemory(t *testing.T) {
+	dir := t.TempDir()
+	less := func(a, b string) bool { return a < b }
+	r := NewReaderWithExternalSort(NewReaderFrom("b", "c", "a"), less, 0, dir)(nil, nil)
+
+	for _, want := range []string{"a", "b", "c"} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	entries, _ := os.ReadDir(dir)
+	assertEq("spilled", 0, len(entries), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithExternalSortWithReadErr(t *testing.T) {
+	dir := t.TempDir()
+	less := func(a, b int) bool { return a < b }
+	src := newResultReader([]int{3, 2, 1}, []error{nil, nil, io.ErrUnexpectedEOF})
+	r := NewReaderWithExternalSort(src, less, 1, dir)(nil, nil)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.ErrUnexpectedEOF, func(s string) { t.Fatal(s) })
+
+	entries, _ := os.ReadDir(dir)
+	assertEq("spilled", 0, len(entries), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithExternalSortWithNilReader(t *testing.T) {
+	r := NewReaderWithExternalSort[int](nil, func(a, b int) bool { return a < b }, 0, "")(nil, nil)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), r.Close(), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTimeAlignIdeal(t *testing.T) {
+	at := func(s int, v string) Timestamped[string] {
+		return Timestamped[string]{Value: v, Time: time.Unix(int64(s), 0)}
+	}
+
+	// 'a' is out of order by 1s, within the skew.
+	a := NewReaderFrom(at(1, "a1"), at(4, "a4"), at(3, "a3"), at(6, "a6"))
+	b := NewReaderFrom(at(2, "b2"), at(3, "b3"), at(5, "b5"))
+	r := NewReaderWithTimeAlign(a, b)(time.Second)
+
+	for _, want := range []string{"a1", "b2", "a3", "b3", "a4", "b5", "a6"} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val.Value, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTimeAlignWithNilSource(t *testing.T) {
+	a := NewReaderFrom(Timestamped[int]{Value: 1}, Timestamped[int]{Value: 2})
+	r := NewReaderWithTimeAlign(a, nil)(0)
+
+	for _, want := range []int{1, 2} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val.Value, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTimeAlignWithReadErr(t *testing.T) {
+	a := newResultReader([]Timestamped[int]{{}}, []error{io.ErrUnexpectedEOF})
+	r := NewReaderWithTimeAlign(a, NewReaderFrom(Timestamped[int]{Value: 1}))(0)
+
+	_, err := r.Read(nil)
+	assertEq("err", io.ErrUnexpectedEOF, err, func(s string) { t.Fatal(s) })
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val.Value, func(s string) { t.Fatal(s) })
+}
@@ -0,0 +1,112 @@
+package iox
+
+import (
+	"context"
+	"io"
+)
+
+// -----------------------------------------------------------------------------
+// StatefulWriter iface + impl.
+// -----------------------------------------------------------------------------
+
+// StatefulWriter is a Writer with an explicit state, which may be snapshot
+// with State, e.g for storing it alongside a checkpoint.
+type StatefulWriter[T, S any] interface {
+	Writer[T]
+	State() S
+}
+
+// StatefulWriterImpl lets you implement StatefulWriter with functions. This
+// is similar to WriterImpl but lets you implement State as well.
+type StatefulWriterImpl[T, S any] struct {
+	ImplW func(context.Context, T) error
+	ImplS func() S
+}
+
+// Write implements Writer by deferring to the internal "ImplW" func.
+// If the internal "ImplW" is not set, an io.ErrClosedPipe will be returned.
+func (impl StatefulWriterImpl[T, S]) Write(ctx context.Context, v T) (err error) {
+	if impl.ImplW == nil {
+		err = io.ErrClosedPipe
+		return
+	}
+
+	return impl.ImplW(orBackground(ctx), v)
+}
+
+// State implements StatefulWriter by deferring to the internal "ImplS" func.
+// If the internal "ImplS" is not set, the zero value of S is returned.
+func (impl StatefulWriterImpl[T, S]) State() (s S) {
+	if impl.ImplS == nil {
+		return
+	}
+
+	return impl.ImplS()
+}
+
+// -----------------------------------------------------------------------------
+// Constructors.
+// -----------------------------------------------------------------------------
+
+// NewStatefulReader returns a Reader which produces values with 'f', where 'f'
+// gets a pointer to a state which starts as 'initial'. This formalizes the
+// pattern of a ReaderImpl closing over mutable variables. The Position of the
+// returned PositionedReader is a (shallow) copy of the state, so it may be
+// checkpointed with Handoff and given back as 'initial' on resume. Nil 'f'
+// returns an empty Reader, whose Position is 'initial'.
+//
+// Example:
+//
+//	// Counts down from the initial state.
+//	r := NewStatefulReader(3, func(ctx context.Context, n *int) (int, error) {
+//		if *n == 0 {
+//			return 0, io.EOF
+//		}
+//
+//		*n--
+//		return *n + 1, nil
+//	})
+//
+//	t.Log(r.Read(nil)) // 3, nil
+//	t.Log(r.Position()) // 2
+func NewStatefulReader[S, T any](initial S, f func(ctx context.Context, state *S) (T, error)) PositionedReader[T, S] {
+	state := initial
+
+	impl := PositionedReaderImpl[T, S]{
+		ImplP: func() S { return state },
+	}
+	if f != nil {
+		impl.ImplR = func(ctx context.Context) (T, error) { return f(ctx, &state) }
+	}
+
+	return impl
+}
+
+// NewStatefulWriter is the Writer analog of NewStatefulReader: each value is
+// given to 'f' together with a pointer to a state which starts as 'initial',
+// and State returns a (shallow) copy of it. Nil 'f' returns an empty Writer,
+// whose State is 'initial'.
+//
+// Example:
+//
+//	// Sums written values.
+//	w := NewStatefulWriter(0, func(ctx context.Context, sum *int, v int) error {
+//		*sum += v
+//		return nil
+//	})
+//
+//	w.Write(nil, 1)
+//	w.Write(nil, 2)
+//	t.Log(w.State()) // 3
+func NewStatefulWriter[S, T any](initial S, f func(ctx context.Context, state *S, v T) error) StatefulWriter[T, S] {
+	state := initial
+
+	impl := StatefulWriterImpl[T, S]{
+		ImplS: func() S { return state },
+	}
+	if f != nil {
+		impl.ImplW = func(ctx context.Context, v T) error { return f(ctx, &state, v) }
+	}
+
+	return impl
+}
@@ -0,0 +1,93 @@
+package iox
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// StatefulWriter impl.
+// -----------------------------------------------------------------------------
+
+func TestStatefulWriterImplWithNilFuncs(t *testing.T) {
+	w := StatefulWriterImpl[int, int]{}
+
+	assertEq("err", io.ErrClosedPipe, w.Write(nil, 1), func(s string) { t.Fatal(s) })
+	assertEq("state", 0, w.State(), func(s string) { t.Fatal(s) })
+}
+
+// -----------------------------------------------------------------------------
+// Constructors.
+// -----------------------------------------------------------------------------
+
+func TestNewStatefulReaderIdeal(t *testing.T) {
+	r := NewStatefulReader(2, func(ctx context.Context, n *int) (int, error) {
+		if *n == 0 {
+			return 0, io.EOF
+		}
+
+		*n--
+		return *n + 1, nil
+	})
+
+	for _, want := range []int{2, 1} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+		assertEq("pos", want-1, r.Position(), func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewStatefulReaderWithHandoff(t *testing.T) {
+	r := NewStatefulReader(0, func(ctx context.Context, n *int) (int, error) {
+		if *n == 3 {
+			return 0, io.EOF
+		}
+
+		*n++
+		return *n, nil
+	})
+
+	var committed, checkpoints []int
+	cfg := HandoffCfg[int]{
+		BatchSize: 2,
+		Checkpoint: func(ctx context.Context, p int) error {
+			checkpoints = append(checkpoints, p)
+			return nil
+		},
+	}
+
+	_, err := Handoff(nil, r, newTestCommitWriter(&committed, nil), cfg)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("checkpoints", []int{2, 3}, checkpoints, func(s string) { t.Fatal(s) })
+}
+
+func TestNewStatefulReaderWithNilFunc(t *testing.T) {
+	r := NewStatefulReader[int, int](5, nil)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("pos", 5, r.Position(), func(s string) { t.Fatal(s) })
+}
+
+func TestNewStatefulWriterIdeal(t *testing.T) {
+	w := NewStatefulWriter(0, func(ctx context.Context, sum *int, v int) error {
+		*sum += v
+		return nil
+	})
+
+	w.Write(nil, 1)
+	w.Write(nil, 2)
+	assertEq("state", 3, w.State(), func(s string) { t.Fatal(s) })
+}
+
+func TestNewStatefulWriterWithNilFunc(t *testing.T) {
+	w := NewStatefulWriter[int, int](5, nil)
+
+	assertEq("err", io.ErrClosedPipe, w.Write(nil, 1), func(s string) { t.Fatal(s) })
+	assertEq("state", 5, w.State(), func(s string) { t.Fatal(s) })
+}
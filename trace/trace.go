@@ -0,0 +1,147 @@
+// trace provides structured events for debugging live iox pipelines. Stages
+// (e.g those wrapped with iox.NewReaderWithTrace) emit an Event per operation
+// to a Sink, which is either carried by the ctx (per pipeline, see WithSink)
+// or process-wide (see SetDefault). Tracing is off by default, and may be
+// toggled at runtime with SetEnabled, or at startup by setting the IOXTRACE
+// environment variable to a non-empty value, which also makes the process-wide
+// Sink write events to os.Stderr.
+package trace
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event describes one operation of a pipeline stage.
+type Event struct {
+	// Stage is the name given to the traced stage.
+	Stage string
+	// Op is the operation, e.g "read" or "write".
+	Op string
+	// Time is when the operation started.
+	Time time.Time
+	// Duration is how long the operation took.
+	Duration time.Duration
+	// Value is a summary of the value read or written, if any.
+	Value string
+	// Err is the err returned by the operation, if any.
+	Err error
+}
+
+// String implements fmt.Stringer, e.g:
+// "2024-01-01T00:00:00Z stage=parse op=read dur=1.2ms value=42 err=<nil>"
+func (e Event) String() string {
+	return fmt.Sprintf(
+		"%s stage=%s op=%s dur=%s value=%s err=%v",
+		e.Time.Format(time.RFC3339Nano), e.Stage, e.Op, e.Duration, e.Value, e.Err,
+	)
+}
+
+// Sink receives Events. Sinks must be safe for concurrent use.
+type Sink interface {
+	Emit(Event)
+}
+
+// SinkFunc lets you implement Sink with a function.
+type SinkFunc func(Event)
+
+// Emit implements Sink by calling the func.
+func (f SinkFunc) Emit(e Event) {
+	f(e)
+}
+
+// NewWriterSink returns a Sink which writes each Event as a line into 'w',
+// see Event.String. Writes are serialized, and write errors are ignored.
+func NewWriterSink(w io.Writer) Sink {
+	var mx sync.Mutex
+	return SinkFunc(func(e Event) {
+		mx.Lock()
+		defer mx.Unlock()
+
+		fmt.Fprintln(w, e)
+	})
+}
+
+// -----------------------------------------------------------------------------
+// Process-wide state.
+// -----------------------------------------------------------------------------
+
+var enabled atomic.Bool
+
+// sinkBox lets a Sink interface be stored in an atomic.Pointer.
+type sinkBox struct{ s Sink }
+
+var defaultSink atomic.Pointer[sinkBox]
+
+func init() {
+	if os.Getenv("IOXTRACE") != "" {
+		SetDefault(NewWriterSink(os.Stderr))
+		SetEnabled(true)
+	}
+}
+
+// SetEnabled turns tracing on or off for the whole process.
+func SetEnabled(on bool) {
+	enabled.Store(on)
+}
+
+// SetDefault sets the process-wide Sink, used when the ctx does not carry
+// one. Nil means no process-wide Sink.
+func SetDefault(s Sink) {
+	defaultSink.Store(&sinkBox{s})
+}
+
+// -----------------------------------------------------------------------------
+// Ctx.
+// -----------------------------------------------------------------------------
+
+type sinkCtxKey struct{}
+
+// WithSink returns a copy of 'ctx' which carries the given Sink, taking
+// precedence over the process-wide one. Nil 'ctx' is treated as
+// context.Background().
+func WithSink(ctx context.Context, s Sink) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return context.WithValue(ctx, sinkCtxKey{}, s)
+}
+
+// SinkFrom returns the Sink carried by 'ctx' (see WithSink), or else the
+// process-wide Sink (see SetDefault), or nil. Nil 'ctx' is allowed.
+func SinkFrom(ctx context.Context) Sink {
+	if ctx != nil {
+		if s, ok := ctx.Value(sinkCtxKey{}).(Sink); ok && s != nil {
+			return s
+		}
+	}
+
+	if box := defaultSink.Load(); box != nil {
+		return box.s
+	}
+
+	return nil
+}
+
+// Enabled reports whether tracing is on and there is a Sink for 'ctx'. It is
+// meant to be checked before doing any tracing work, e.g summarizing values.
+func Enabled(ctx context.Context) bool {
+	return enabled.Load() && SinkFrom(ctx) != nil
+}
+
+// Emit sends 'e' to the Sink for 'ctx', if tracing is enabled.
+func Emit(ctx context.Context, e Event) {
+	if !enabled.Load() {
+		return
+	}
+
+	if s := SinkFrom(ctx); s != nil {
+		s.Emit(e)
+	}
+}
@@ -0,0 +1,83 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func assertEq[T any](subject string, a T, b T, f func(string)) {
+	if f == nil {
+		return
+	}
+
+	ab, _ := json.Marshal(a)
+	bb, _ := json.Marshal(b)
+
+	as := string(ab)
+	bs := string(bb)
+
+	if as == bs {
+		return
+	}
+
+	s := "unexpected '%v':\n\twant: '%v'\n\thave: '%v'\n"
+	f(fmt.Sprintf(s, subject, as, bs))
+}
+
+func TestEmitWithCtxSink(t *testing.T) {
+	SetEnabled(true)
+	defer SetEnabled(false)
+
+	var stages []string
+	ctx := WithSink(context.Background(), SinkFunc(func(e Event) { stages = append(stages, e.Stage) }))
+
+	Emit(ctx, Event{Stage: "a", Op: "read"})
+	assertEq("stages", []string{"a"}, stages, func(s string) { t.Fatal(s) })
+}
+
+func TestEmitWhenDisabled(t *testing.T) {
+	SetEnabled(false)
+
+	n := 0
+	ctx := WithSink(nil, SinkFunc(func(e Event) { n++ }))
+
+	Emit(ctx, Event{})
+	assertEq("n", 0, n, func(s string) { t.Fatal(s) })
+	assertEq("enabled", false, Enabled(ctx), func(s string) { t.Fatal(s) })
+}
+
+func TestSinkFromWithDefault(t *testing.T) {
+	SetEnabled(true)
+	defer SetEnabled(false)
+
+	n := 0
+	SetDefault(SinkFunc(func(e Event) { n++ }))
+	defer SetDefault(nil)
+
+	assertEq("enabled", true, Enabled(nil), func(s string) { t.Fatal(s) })
+
+	Emit(nil, Event{})
+	assertEq("n", 1, n, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterSink(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	s := NewWriterSink(b)
+
+	s.Emit(Event{
+		Stage:    "parse",
+		Op:       "read",
+		Time:     time.Unix(0, 0).UTC(),
+		Duration: time.Millisecond,
+		Value:    "42",
+		Err:      errors.New("bad"),
+	})
+
+	want := "1970-01-01T00:00:00Z stage=parse op=read dur=1ms value=42 err=bad\n"
+	assertEq("line", want, b.String(), func(s string) { t.Fatal(s) })
+}
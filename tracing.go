@@ -0,0 +1,86 @@
+package iox
+
+import (
+	"context"
+
+	"github.com/crunchypi/iox/trace"
+)
+
+// -----------------------------------------------------------------------------
+// Tracing.
+// -----------------------------------------------------------------------------
+
+// NewReaderWithTrace returns a reader which emits a trace.Event for each Read
+// of 'r', named by 'stage' and summarized with 'summary', see package trace.
+// Timing uses the Clock in the ctx, see ClockFrom. When tracing is disabled,
+// reads go straight to 'r' without any tracing work. Nil 'r' returns an empty
+// non-nil Reader; nil 'summary' leaves Event.Value empty.
+//
+// Example:
+//
+//	r := NewReaderWithTrace(parsed)("parse", func(v Row) string { return v.ID })
+//
+//	trace.SetDefault(trace.NewWriterSink(os.Stderr))
+//	trace.SetEnabled(true)
+//	r.Read(ctx) // Logs e.g "... stage=parse op=read dur=12µs value=row-1 err=<nil>"
+func NewReaderWithTrace[T any](r Reader[T]) func(stage string, summary func(T) string) Reader[T] {
+	return func(stage string, summary func(T) string) Reader[T] {
+		if r == nil {
+			return nilReader[T]()
+		}
+
+		return ReaderImpl[T]{
+			Wraps: r,
+			Impl: func(ctx context.Context) (val T, err error) {
+				if !trace.Enabled(ctx) {
+					return r.Read(ctx)
+				}
+
+				clock := ClockFrom(ctx)
+				start := clock.Now()
+				val, err = r.Read(ctx)
+
+				e := trace.Event{Stage: stage, Op: "read", Time: start, Err: err}
+				e.Duration = clock.Now().Sub(start)
+				if summary != nil && err == nil {
+					e.Value = summary(val)
+				}
+
+				trace.Emit(ctx, e)
+				return val, err
+			},
+		}
+	}
+}
+
+// NewWriterWithTrace returns a writer which emits a trace.Event for each Write
+// into 'w', see NewReaderWithTrace. Nil 'w' returns an empty Writer.
+func NewWriterWithTrace[T any](w Writer[T]) func(stage string, summary func(T) string) Writer[T] {
+	return func(stage string, summary func(T) string) Writer[T] {
+		if w == nil {
+			return nilWriter[T]()
+		}
+
+		return WriterImpl[T]{
+			Wraps: w,
+			Impl: func(ctx context.Context, v T) error {
+				if !trace.Enabled(ctx) {
+					return w.Write(ctx, v)
+				}
+
+				clock := ClockFrom(ctx)
+				start := clock.Now()
+				err := w.Write(ctx, v)
+
+				e := trace.Event{Stage: stage, Op: "write", Time: start, Err: err}
+				e.Duration = clock.Now().Sub(start)
+				if summary != nil {
+					e.Value = summary(v)
+				}
+
+				trace.Emit(ctx, e)
+				return err
+			},
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package iox
+
+import (
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/crunchypi/iox/trace"
+)
+
+// -----------------------------------------------------------------------------
+// Tracing.
+// -----------------------------------------------------------------------------
+
+func TestNewReaderWithTraceIdeal(t *testing.T) {
+	trace.SetEnabled(true)
+	defer trace.SetEnabled(false)
+
+	var events []trace.Event
+	ctx := trace.WithSink(nil, trace.SinkFunc(func(e trace.Event) { events = append(events, e) }))
+
+	r := NewReaderWithTrace(NewReaderFrom(1))("src", strconv.Itoa)
+	r.Read(ctx)
+	r.Read(ctx)
+
+	assertEq("events", 2, len(events), func(s string) { t.Fatal(s) })
+	assertEq("stage", "src", events[0].Stage, func(s string) { t.Fatal(s) })
+	assertEq("op", "read", events[0].Op, func(s string) { t.Fatal(s) })
+	assertEq("value", "1", events[0].Value, func(s string) { t.Fatal(s) })
+	assertEq("err", true, events[1].Err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTraceWhenDisabled(t *testing.T) {
+	n := 0
+	ctx := trace.WithSink(nil, trace.SinkFunc(func(e trace.Event) { n++ }))
+
+	r := NewReaderWithTrace(NewReaderFrom(1))("src", nil)
+	val, err := r.Read(ctx)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", 1, val, func(s string) { t.Fatal(s) })
+	assertEq("n", 0, n, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithTraceIdeal(t *testing.T) {
+	trace.SetEnabled(true)
+	defer trace.SetEnabled(false)
+
+	var events []trace.Event
+	ctx := trace.WithSink(nil, trace.SinkFunc(func(e trace.Event) { events = append(events, e) }))
+
+	s := make([]int, 0, 1)
+	w := NewWriterWithTrace(newSliceWriter(&s))("sink", strconv.Itoa)
+	w.Write(ctx, 1)
+
+	assertEq("s", []int{1}, s, func(s string) { t.Fatal(s) })
+	assertEq("events", 1, len(events), func(s string) { t.Fatal(s) })
+	assertEq("op", "write", events[0].Op, func(s string) { t.Fatal(s) })
+	assertEq("value", "1", events[0].Value, func(s string) { t.Fatal(s) })
+}
@@ -0,0 +1,79 @@
+package iox
+
+// -----------------------------------------------------------------------------
+// Transform shorthands.
+// -----------------------------------------------------------------------------
+
+// MapReader is shorthand for NewReaderWithMapperFn[T, U](r)(f). It exists
+// alongside MapSlice, such that streams and materialized slices can be
+// transformed the same way.
+//
+// Example:
+//
+//	r := MapReader(NewReaderFrom(1, 2, 3), strconv.Itoa)
+//	t.Log(r.Read(nil)) // "1", nil
+func MapReader[T, U any](r Reader[T], f func(T) U) Reader[U] {
+	return NewReaderWithMapperFn[T, U](r)(f)
+}
+
+// FilterReader is shorthand for NewReaderWithFilterFn(r)(f), see MapReader.
+//
+// Example:
+//
+//	r := FilterReader(NewReaderFrom(1, 2, 3), func(v int) bool { return v > 1 })
+//	t.Log(r.Read(nil)) // 2, nil
+func FilterReader[T any](r Reader[T], f func(T) bool) Reader[T] {
+	return NewReaderWithFilterFn(r)(f)
+}
+
+// MapWriter is shorthand for NewWriterWithMapperFn[T, U](w)(f), see MapReader.
+func MapWriter[T, U any](w Writer[U], f func(T) U) Writer[T] {
+	return NewWriterWithMapperFn[T, U](w)(f)
+}
+
+// FilterWriter is shorthand for NewWriterWithFilterFn(w)(f), see MapReader.
+func FilterWriter[T any](w Writer[T], f func(T) bool) Writer[T] {
+	return NewWriterWithFilterFn(w)(f)
+}
+
+// MapSlice returns a new slice with each value of 'vs' mapped with 'f'. It is
+// the slice counterpart of MapReader. Nil 'vs' or nil 'f' returns nil, the
+// latter same as MapReader (which then returns an empty Reader).
+//
+// Example:
+//
+//	t.Log(MapSlice([]int{1, 2, 3}, strconv.Itoa)) // ["1", "2", "3"]
+func MapSlice[T, U any](vs []T, f func(T) U) []U {
+	if vs == nil || f == nil {
+		return nil
+	}
+
+	us := make([]U, len(vs))
+	for i, v := range vs {
+		us[i] = f(v)
+	}
+
+	return us
+}
+
+// FilterSlice returns a new slice with the values of 'vs' which satisfy 'f'.
+// It is the slice counterpart of FilterReader. Nil 'vs' returns nil; nil 'f'
+// keeps all values, same as FilterReader.
+//
+// Example:
+//
+//	t.Log(FilterSlice([]int{1, 2, 3}, func(v int) bool { return v > 1 })) // [2, 3]
+func FilterSlice[T any](vs []T, f func(T) bool) []T {
+	if vs == nil {
+		return nil
+	}
+
+	out := make([]T, 0, len(vs))
+	for _, v := range vs {
+		if f == nil || f(v) {
+			out = append(out, v)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,69 @@
+package iox
+
+import (
+	"io"
+	"strconv"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// Transform shorthands.
+// -----------------------------------------------------------------------------
+
+func TestMapReaderIdeal(t *testing.T) {
+	r := MapReader(NewReaderFrom(1, 2), strconv.Itoa)
+
+	for _, want := range []string{"1", "2"} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestFilterReaderIdeal(t *testing.T) {
+	r := FilterReader(NewReaderFrom(1, 2, 3), func(v int) bool { return v%2 != 0 })
+
+	for _, want := range []int{1, 3} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestMapWriterIdeal(t *testing.T) {
+	s := make([]string, 0, 2)
+	w := MapWriter(newSliceWriter(&s), strconv.Itoa)
+
+	w.Write(nil, 1)
+	w.Write(nil, 2)
+	assertEq("val", []string{"1", "2"}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestFilterWriterIdeal(t *testing.T) {
+	s := make([]int, 0, 2)
+	w := FilterWriter(newSliceWriter(&s), func(v int) bool { return v%2 != 0 })
+
+	w.Write(nil, 1)
+	w.Write(nil, 2)
+	w.Write(nil, 3)
+	assertEq("val", []int{1, 3}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestMapSliceIdeal(t *testing.T) {
+	assertEq("val", []string{"1", "2"}, MapSlice([]int{1, 2}, strconv.Itoa), func(s string) { t.Fatal(s) })
+	assertEq("nil", []string(nil), MapSlice(nil, strconv.Itoa), func(s string) { t.Fatal(s) })
+}
+
+func TestMapSliceWithNilFn(t *testing.T) {
+	us := MapSlice[int, string]([]int{1, 2}, nil)
+	assertEq("nil f", true, us == nil, func(s string) { t.Fatal(s) })
+}
+
+func TestFilterSliceIdeal(t *testing.T) {
+	f := func(v int) bool { return v%2 != 0 }
+	assertEq("val", []int{1, 3}, FilterSlice([]int{1, 2, 3}, f), func(s string) { t.Fatal(s) })
+	assertEq("nil f", []int{1, 2}, FilterSlice([]int{1, 2}, nil), func(s string) { t.Fatal(s) })
+}
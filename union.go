@@ -0,0 +1,264 @@
+package iox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// -----------------------------------------------------------------------------
+// Type registry.
+// -----------------------------------------------------------------------------
+
+// ErrUnregisteredType is returned when a value of a type which is not known
+// to a TypeRegistry is written or read through one.
+var ErrUnregisteredType = errors.New("iox: unregistered type")
+
+// TypeRegistry maps names to concrete types, such that values of different
+// types can share a stream as tagged Envelopes, i.e a tagged union. The zero
+// value is ready to use, and it is safe for concurrent use.
+type TypeRegistry struct {
+	mx    sync.RWMutex
+	names map[reflect.Type]string
+	types map[string]reflect.Type
+}
+
+// Register associates 'name' with the concrete type of 'v', similar to how
+// gob.RegisterName works. Registering a name or type twice overwrites the
+// previous association. Nil 'v' is ignored, as it has no concrete type.
+func (reg *TypeRegistry) Register(name string, v any) {
+	if v == nil {
+		return
+	}
+
+	reg.mx.Lock()
+	defer reg.mx.Unlock()
+
+	if reg.names == nil {
+		reg.names = make(map[reflect.Type]string)
+		reg.types = make(map[string]reflect.Type)
+	}
+
+	t := reflect.TypeOf(v)
+	reg.names[t] = name
+	reg.types[name] = t
+}
+
+func (reg *TypeRegistry) nameOf(v any) (string, bool) {
+	reg.mx.RLock()
+	defer reg.mx.RUnlock()
+
+	name, ok := reg.names[reflect.TypeOf(v)]
+	return name, ok
+}
+
+func (reg *TypeRegistry) typeOf(name string) (reflect.Type, bool) {
+	reg.mx.RLock()
+	defer reg.mx.RUnlock()
+
+	t, ok := reg.types[name]
+	return t, ok
+}
+
+// -----------------------------------------------------------------------------
+// Modifiers.
+// -----------------------------------------------------------------------------
+
+// NewWriterWithTypeTag returns a writer of heterogeneous values, which are
+// encoded with 'f' (e.g json.Marshal) and wrapped in an Envelope tagged with
+// the name registered for their type in 'reg', before being written to 'w'.
+// Values of unregistered types result in an err wrapping ErrUnregisteredType.
+// Nil 'w', 'reg' or 'f' returns an empty Writer.
+//
+// Example:
+//
+//	reg := &TypeRegistry{}
+//	reg.Register("click", Click{})
+//	reg.Register("view", View{})
+//
+//	w := NewWriterWithTypeTag(NewWriterFromValues[Envelope](b)(nil))(reg, json.Marshal)
+//	w.Write(nil, Click{})
+//	w.Write(nil, View{})
+func NewWriterWithTypeTag(w Writer[Envelope]) func(reg *TypeRegistry, f func(any) ([]byte, error)) Writer[any] {
+	return func(reg *TypeRegistry, f func(any) ([]byte, error)) Writer[any] {
+		if w == nil || reg == nil || f == nil {
+			return nilWriter[any]()
+		}
+
+		return WriterImpl[any]{
+			Wraps: w,
+			Impl: func(ctx context.Context, v any) error {
+				name, ok := reg.nameOf(v)
+				if !ok {
+					return fmt.Errorf("%w: %T", ErrUnregisteredType, v)
+				}
+
+				b, err := f(v)
+				if err != nil {
+					return err
+				}
+
+				return w.Write(ctx, Envelope{Type: name, Data: b})
+			},
+		}
+	}
+}
+
+// NewReaderWithTypeTag is the counterpart of NewWriterWithTypeTag. It reads
+// envelopes from 'r', looks up the concrete type registered in 'reg' for the
+// Envelope.Type, and decodes the data into a new value of that type with 'f'
+// (e.g json.Unmarshal). The yielded values have the registered concrete type,
+// so they can be used with a type switch or NewReaderWithTypeSwitch. Envelopes
+// of unregistered types result in an err wrapping ErrUnregisteredType.
+// Nil 'r', 'reg' or 'f' returns an empty non-nil Reader.
+//
+// Example:
+//
+//	r := NewReaderWithTypeTag(NewReaderFromBytes[Envelope](b)(nil))(reg, json.Unmarshal)
+//
+//	t.Log(r.Read(nil)) // Click{}, nil
+//	t.Log(r.Read(nil)) // View{}, nil
+func NewReaderWithTypeTag(r Reader[Envelope]) func(reg *TypeRegistry, f func([]byte, any) error) Reader[any] {
+	return func(reg *TypeRegistry, f func([]byte, any) error) Reader[any] {
+		if r == nil || reg == nil || f == nil {
+			return nilReader[any]()
+		}
+
+		return ReaderImpl[any]{
+			Wraps: r,
+			Impl: func(ctx context.Context) (val any, err error) {
+				e, err := r.Read(ctx)
+				if err != nil {
+					return nil, err
+				}
+
+				t, ok := reg.typeOf(e.Type)
+				if !ok {
+					return nil, fmt.Errorf("%w: %q", ErrUnregisteredType, e.Type)
+				}
+
+				ptr := reflect.New(t)
+				err = f(e.Data, ptr.Interface())
+				if err != nil {
+					return nil, err
+				}
+
+				return ptr.Elem().Interface(), nil
+			},
+		}
+	}
+}
+
+// TypeCase is a handler for values of a single concrete type, used with
+// NewReaderWithTypeSwitch. Create it with NewTypeCase.
+type TypeCase struct {
+	impl func(context.Context, any) (bool, error)
+}
+
+// NewTypeCase returns a TypeCase which handles values of type T with 'f'.
+// Nil 'f' matches nothing, i.e values of type T are yielded as unhandled.
+func NewTypeCase[T any](f func(context.Context, T) error) TypeCase {
+	return TypeCase{
+		impl: func(ctx context.Context, v any) (bool, error) {
+			tv, ok := v.(T)
+			if !ok || f == nil {
+				return false, nil
+			}
+
+			return true, f(ctx, tv)
+		},
+	}
+}
+
+// NewReaderWithTypeSwitch returns a reader which routes each value from 'r'
+// to the first TypeCase matching its type. Values which are handled are not
+// yielded by the returned Reader, values without a matching TypeCase are.
+// An err from a handler is returned by Read. Nil 'r' returns an empty non-nil
+// Reader; no cases returns 'r'.
+//
+// Example:
+//
+//	r := NewReaderWithTypeSwitch(NewReaderFrom[any](1, "a", 2.0))(
+//		NewTypeCase(func(ctx context.Context, v int) error { ...; return nil }),
+//		NewTypeCase(func(ctx context.Context, v string) error { ...; return nil }),
+//	)
+//
+//	t.Log(r.Read(nil)) // 2.0, nil <--- Ints and strings were handled.
+//	t.Log(r.Read(nil)) // nil, io.EOF
+func NewReaderWithTypeSwitch(r Reader[any]) func(cases ...TypeCase) Reader[any] {
+	return func(cases ...TypeCase) Reader[any] {
+		if r == nil {
+			return nilReader[any]()
+		}
+		if len(cases) == 0 {
+			return r
+		}
+
+		return lenReader[any]{
+			ReaderImpl: ReaderImpl[any]{
+				Wraps: r,
+				Impl: func(ctx context.Context) (val any, err error) {
+				outer:
+					for val, err = r.Read(ctx); err == nil; val, err = r.Read(ctx) {
+						for _, c := range cases {
+							if c.impl == nil {
+								continue
+							}
+
+							ok, err := c.impl(ctx, val)
+							if err != nil {
+								return nil, err
+							}
+							if ok {
+								continue outer
+							}
+						}
+
+						return
+					}
+
+					return
+				},
+			},
+			len: unknownLen,
+		}
+	}
+}
+
+// NewReaderWithTypeFilter returns a typed reader of the values from 'r' which
+// have type T, all other values are skipped. Nil 'r' returns an empty non-nil
+// Reader.
+//
+// Example:
+//
+//	r := NewReaderWithTypeFilter[int](NewReaderFrom[any](1, "a", 2))
+//
+//	t.Log(r.Read(nil)) // 1, nil
+//	t.Log(r.Read(nil)) // 2, nil
+//	t.Log(r.Read(nil)) // 0, io.EOF
+func NewReaderWithTypeFilter[T any](r Reader[any]) Reader[T] {
+	if r == nil {
+		return nilReader[T]()
+	}
+
+	return lenReader[T]{
+		ReaderImpl: ReaderImpl[T]{
+			Wraps: r,
+			Impl: func(ctx context.Context) (val T, err error) {
+				for {
+					v, err := r.Read(ctx)
+					if err != nil {
+						return val, err
+					}
+
+					if tv, ok := v.(T); ok {
+						return tv, nil
+					}
+				}
+			},
+		},
+		len: unknownLen,
+	}
+}
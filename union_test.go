@@ -0,0 +1,152 @@
+package iox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// Test utils.
+// -----------------------------------------------------------------------------
+
+type testClick struct{ X int }
+type testView struct{ Page string }
+
+func newTestRegistry() *TypeRegistry {
+	reg := &TypeRegistry{}
+	reg.Register("click", testClick{})
+	reg.Register("view", testView{})
+	return reg
+}
+
+// -----------------------------------------------------------------------------
+// Modifiers.
+// -----------------------------------------------------------------------------
+
+func TestTypeTagRoundtripIdeal(t *testing.T) {
+	reg := newTestRegistry()
+	b := bytes.NewBuffer(nil)
+
+	w := NewWriterWithTypeTag(NewWriterFromValues[Envelope](b)(nil))(reg, json.Marshal)
+	assertEq("err", *new(error), w.Write(nil, testClick{X: 1}), func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), w.Write(nil, testView{Page: "a"}), func(s string) { t.Fatal(s) })
+
+	r := NewReaderWithTypeTag(NewReaderFromBytes[Envelope](b)(nil))(reg, json.Unmarshal)
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", true, val == any(testClick{X: 1}), func(s string) { t.Fatal(s) })
+
+	val, err = r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", true, val == any(testView{Page: "a"}), func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithTypeTagWithUnregisteredType(t *testing.T) {
+	w := NewWriterWithTypeTag(WriterImpl[Envelope]{})(newTestRegistry(), json.Marshal)
+
+	err := w.Write(nil, 1)
+	assertEq("err", true, errors.Is(err, ErrUnregisteredType), func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithTypeTagWithNilWriter(t *testing.T) {
+	w := NewWriterWithTypeTag(nil)(newTestRegistry(), json.Marshal)
+
+	err := w.Write(nil, testClick{})
+	assertEq("err", true, err == io.ErrClosedPipe, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTypeTagWithUnregisteredType(t *testing.T) {
+	r := NewReaderWithTypeTag(NewReaderFrom(Envelope{Type: "x"}))(newTestRegistry(), json.Unmarshal)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, errors.Is(err, ErrUnregisteredType), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTypeTagWithNilReader(t *testing.T) {
+	r := NewReaderWithTypeTag(nil)(newTestRegistry(), json.Unmarshal)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTypeTagWithNilRegisteredValue(t *testing.T) {
+	reg := newTestRegistry()
+	reg.Register("x", nil)
+	r := NewReaderWithTypeTag(NewReaderFrom(Envelope{Type: "x"}))(reg, json.Unmarshal)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, errors.Is(err, ErrUnregisteredType), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTypeSwitchIdeal(t *testing.T) {
+	ints := []int{}
+	strs := []string{}
+
+	r := NewReaderWithTypeSwitch(NewReaderFrom[any](1, "a", 2.5, 2))(
+		NewTypeCase(func(ctx context.Context, v int) error { ints = append(ints, v); return nil }),
+		NewTypeCase(func(ctx context.Context, v string) error { strs = append(strs, v); return nil }),
+	)
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", true, val == any(2.5), func(s string) { t.Fatal(s) })
+
+	_, err = r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+	assertEq("ints", []int{1, 2}, ints, func(s string) { t.Fatal(s) })
+	assertEq("strs", []string{"a"}, strs, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTypeSwitchWithHandlerErr(t *testing.T) {
+	r := NewReaderWithTypeSwitch(NewReaderFrom[any](1))(
+		NewTypeCase(func(ctx context.Context, v int) error { return io.ErrUnexpectedEOF }),
+	)
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.ErrUnexpectedEOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTypeSwitchWithNilHandler(t *testing.T) {
+	r := NewReaderWithTypeSwitch(NewReaderFrom[any](1))(NewTypeCase[int](nil))
+
+	val, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("val", true, val == any(1), func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTypeSwitchWithNilReader(t *testing.T) {
+	r := NewReaderWithTypeSwitch(nil)()
+
+	_, err := r.Read(nil)
+	assertEq("err", true, err == io.EOF, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTypeFilterIdeal(t *testing.T) {
+	r := NewReaderWithTypeFilter[int](NewReaderFrom[any](1, "a", 2))
+
+	for _, want := range []int{1, 2} {
+		val, err := r.Read(nil)
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+		assertEq("val", want, val, func(s string) { t.Fatal(s) })
+	}
+
+	val, err := r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+	assertEq("val", 0, val, func(s string) { t.Fatal(s) })
+}
+
+func TestNewReaderWithTypeFilterWithNilReader(t *testing.T) {
+	r := NewReaderWithTypeFilter[int](nil)
+
+	val, err := r.Read(nil)
+	assertEq("err", io.EOF, err, func(s string) { t.Fatal(s) })
+	assertEq("val", 0, val, func(s string) { t.Fatal(s) })
+}
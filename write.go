@@ -4,7 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // -----------------------------------------------------------------------------
@@ -12,7 +18,9 @@ import (
 // -----------------------------------------------------------------------------
 
 // Writer writes T, it is intended as a generic variant of io.Writer.
-// Use io.ErrClosedPipe as a signal for when writing should stop.
+// Use io.ErrClosedPipe as a signal for when writing should stop. Writers of
+// this package return the ctx err when the ctx given to Write is done; a nil
+// ctx is allowed and never done.
 type Writer[T any] interface {
 	Write(context.Context, T) error
 }
@@ -34,6 +42,8 @@ type Writer[T any] interface {
 //	}
 type WriterImpl[T any] struct {
 	Impl func(context.Context, T) error
+	// Wraps is optionally the component this one wraps, see Wrapper.
+	Wraps any
 }
 
 // Write implements Writer by deferring to the internal "Impl" func.
@@ -44,7 +54,12 @@ func (impl WriterImpl[T]) Write(ctx context.Context, v T) (err error) {
 		return
 	}
 
-	return impl.Impl(ctx, v)
+	return impl.Impl(orBackground(ctx), v)
+}
+
+// Unwrap implements Wrapper by returning the internal "Wraps" value.
+func (impl WriterImpl[T]) Unwrap() any {
+	return impl.Wraps
 }
 
 // -----------------------------------------------------------------------------
@@ -65,6 +80,8 @@ type WriteCloser[T any] interface {
 type WriteCloserImpl[T any] struct {
 	ImplC func() error
 	ImplW func(context.Context, T) error
+	// Wraps is optionally the component this one wraps, see Wrapper.
+	Wraps any
 }
 
 // Close implements io.Closer by deferring to the internal ImplC func.
@@ -85,13 +102,180 @@ func (impl WriteCloserImpl[T]) Write(ctx context.Context, v T) (err error) {
 		return
 	}
 
-	return impl.ImplW(ctx, v)
+	return impl.ImplW(orBackground(ctx), v)
+}
+
+// Unwrap implements Wrapper by returning the internal "Wraps" value.
+func (impl WriteCloserImpl[T]) Unwrap() any {
+	return impl.Wraps
 }
 
 // -----------------------------------------------------------------------------
 // Constructors.
 // -----------------------------------------------------------------------------
 
+// NewWriterFromDiscard returns a Writer which accepts every value and does
+// nothing with it, similar to io.Discard.
+func NewWriterFromDiscard[T any]() Writer[T] {
+	return WriterImpl[T]{
+		Impl: func(ctx context.Context, v T) error {
+			return ctxErr(ctx)
+		},
+	}
+}
+
+// NewWriterDiscardCounting is like NewWriterFromDiscard but also counts the
+// values written. The returned counter is updated atomically and may be read
+// with atomic.LoadInt64 while the Writer is in use.
+//
+// Example:
+//
+//	w, n := NewWriterDiscardCounting[int]()
+//	w.Write(nil, 1)
+//	w.Write(nil, 2)
+//	w.Write(nil, 3)
+//
+//	fmt.Println(atomic.LoadInt64(n)) // 3
+func NewWriterDiscardCounting[T any]() (Writer[T], *int64) {
+	n := new(int64)
+	w := WriterImpl[T]{
+		Impl: func(ctx context.Context, v T) error {
+			if err := ctxErr(ctx); err != nil {
+				return err
+			}
+
+			atomic.AddInt64(n, 1)
+			return nil
+		},
+	}
+
+	return w, n
+}
+
+// NewWriterFromErr returns a Writer which returns 'err' on every Write, e.g as
+// a test double or a fail-fast placeholder. Nil 'err' is treated as
+// io.ErrClosedPipe, since a Writer which never fails is NewWriterFromDiscard.
+func NewWriterFromErr[T any](err error) Writer[T] {
+	if err == nil {
+		err = io.ErrClosedPipe
+	}
+
+	return WriterImpl[T]{
+		Impl: func(ctx context.Context, v T) error {
+			if err := ctxErr(ctx); err != nil {
+				return err
+			}
+
+			return err
+		},
+	}
+}
+
+// MultiErrPolicy decides what a Writer of multiple targets does when a target
+// fails, see MultiBroadcastCfg.
+type MultiErrPolicy int
+
+const (
+	// MultiFailFast stops at the first failing target, i.e the value is not
+	// written into the targets after it, and returns its err.
+	MultiFailFast MultiErrPolicy = iota
+	// MultiBestEffort writes into every target regardless of failures, and
+	// returns the errors of all failing targets combined with errors.Join.
+	MultiBestEffort
+)
+
+// MultiBroadcastCfg is used to configure NewWriterFromMultiBroadcastCfg.
+type MultiBroadcastCfg struct {
+	// Policy decides what happens when a target fails.
+	Policy MultiErrPolicy
+}
+
+// NewWriterFromMultiBroadcast returns a Writer which writes every value into
+// all of 'ws' in order, similar to io.MultiWriter, e.g for dual-writing
+// during a migration. It stops at the first failing Writer, see
+// NewWriterFromMultiBroadcastCfg for other error policies. Nil writers are
+// skipped; no (non-nil) writers returns an empty Writer.
+//
+// Example:
+//
+//	w := NewWriterFromMultiBroadcast(oldStore, newStore)
+//	err := w.Write(ctx, record) // Written into both, or the first err.
+func NewWriterFromMultiBroadcast[T any](ws ...Writer[T]) Writer[T] {
+	return NewWriterFromMultiBroadcastCfg(ws...)(MultiBroadcastCfg{})
+}
+
+// NewWriterFromMultiBroadcastCfg is like NewWriterFromMultiBroadcast, but
+// failing writers are handled as configured by the given MultiBroadcastCfg.
+//
+// Example:
+//
+//	// The new store is written into even if the old one fails.
+//	w := NewWriterFromMultiBroadcastCfg(oldStore, newStore)(
+//		MultiBroadcastCfg{Policy: MultiBestEffort},
+//	)
+func NewWriterFromMultiBroadcastCfg[T any](ws ...Writer[T]) func(cfg MultiBroadcastCfg) Writer[T] {
+	// Copying, so the caller can not modify the slice while it is in use.
+	ws = slices.DeleteFunc(slices.Clone(ws), func(w Writer[T]) bool { return w == nil })
+
+	return func(cfg MultiBroadcastCfg) Writer[T] {
+		if len(ws) == 0 {
+			return nilWriter[T]()
+		}
+
+		return WriterImpl[T]{
+			Impl: func(ctx context.Context, v T) error {
+				var errs []error
+				for _, w := range ws {
+					err := w.Write(ctx, v)
+					if err == nil {
+						continue
+					}
+					if cfg.Policy == MultiFailFast {
+						return err
+					}
+
+					errs = append(errs, err)
+				}
+
+				return errors.Join(errs...)
+			},
+		}
+	}
+}
+
+// NewWriterToChan returns a Writer which sends values on 'ch'. Write blocks
+// until the value is received (or buffered), and returns the ctx err if the
+// ctx is done first. 'ch' must not be closed while the Writer is in use, as
+// sending on a closed chan panics. Nil 'ch' returns an empty non-nil Writer.
+//
+// Example:
+//
+//	ch := make(chan int, 1)
+//	w := NewWriterToChan(ch)
+//
+//	w.Write(nil, 1)
+//	t.Log(<-ch) // 1
+func NewWriterToChan[T any](ch chan<- T) Writer[T] {
+	if ch == nil {
+		return nilWriter[T]()
+	}
+
+	return WriterImpl[T]{
+		Impl: func(ctx context.Context, v T) error {
+			if err := ctxErr(ctx); err != nil {
+				return err
+			}
+
+			select {
+			case ch <- v:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
+
 // NewWriterFromValues creates a Writer (vals) which writes into 'w'.
 // Nil 'w' returns an empty non-nil Writer; nil 'f' uses json.NewEncoder.
 //
@@ -117,7 +301,7 @@ func (impl WriteCloserImpl[T]) Write(ctx context.Context, v T) (err error) {
 func NewWriterFromValues[T any](w io.Writer) func(f encoderFn) Writer[T] {
 	return func(f func(io.Writer) Encoder) Writer[T] {
 		if w == nil {
-			return WriterImpl[T]{}
+			return nilWriter[T]()
 		}
 
 		b := bytes.NewBuffer(nil)
@@ -131,6 +315,10 @@ func NewWriterFromValues[T any](w io.Writer) func(f encoderFn) Writer[T] {
 
 		return WriterImpl[T]{
 			Impl: func(ctx context.Context, v T) error {
+				if err := ctxErr(ctx); err != nil {
+					return err
+				}
+
 				err := e.Encode(v)
 				if err != nil {
 					return err
@@ -146,6 +334,9 @@ func NewWriterFromValues[T any](w io.Writer) func(f encoderFn) Writer[T] {
 // NewWriterFromBytes creates an io.Writer (bytes) which writes into 'w'.
 // Nil 'w' returns an empty non-nil Writer; nil 'f' uses json.NewDecoder.
 //
+// The io.Writer also implements io.StringWriter and io.ReaderFrom, where the
+// latter (used by io.Copy) decodes straight from the source until io.EOF.
+//
 // Example (interactive):
 //   - https://go.dev/play/p/yhaEWVIMoxw
 //
@@ -174,13 +365,27 @@ func NewWriterFromBytes[T any](w Writer[T]) func(f decoderFn) io.Writer {
 			return readWriteCloserImpl{}
 		}
 
+		newDecoder := func(r io.Reader) Decoder {
+			if f != nil {
+				if d := f(r); d != nil {
+					return d
+				}
+			}
+
+			return json.NewDecoder(r)
+		}
+
 		b := bytes.NewBuffer(nil)
-		d := func(r io.Reader) Decoder { return json.NewDecoder(r) }(b)
+		fr := &fallbackReader{b: b}
+		d := newDecoder(fr)
 
-		if f != nil {
-			if _d := f(b); _d != nil {
-				d = _d
+		writeNext := func() error {
+			var v T
+			if err := d.Decode(&v); err != nil {
+				return err
 			}
+
+			return w.Write(nil, v)
 		}
 
 		return readWriteCloserImpl{
@@ -190,19 +395,146 @@ func NewWriterFromBytes[T any](w Writer[T]) func(f decoderFn) io.Writer {
 					return
 				}
 
-				var v T
-				err = d.Decode(&v)
-
+				err = writeNext()
+				return
+			},
+			// Avoids converting 's' into a temporary []byte.
+			ImplWS: func(s string) (n int, err error) {
+				n, err = b.WriteString(s)
 				if err != nil {
 					return
 				}
 
-				err = w.Write(nil, v)
-				if err != nil {
-					return
+				err = writeNext()
+				return
+			},
+			// Decodes straight from 'r' (after anything already buffered,
+			// also by 'd'), rather than going through intermediate Write
+			// calls.
+			ImplRF: func(r io.Reader) (n int64, err error) {
+				cr := &countingReader{r: r}
+				fr.r = cr
+				defer func() { fr.r = nil }()
+
+				for {
+					var v T
+					if err = d.Decode(&v); err != nil {
+						break
+					}
+					if err = w.Write(nil, v); err != nil {
+						break
+					}
 				}
 
-				return
+				if err == io.EOF {
+					// Decoders may keep io.EOF, and nothing is left over
+					// after it, so a new one is used for following writes.
+					d, err = newDecoder(fr), nil
+				}
+
+				return cr.n, err
+			},
+		}
+	}
+}
+
+// NewWriterFromBytesBuffered is like NewWriterFromBytes, except that bytes are
+// buffered across writes and only complete frames are decoded, such that it
+// may be used behind io.Copy from arbitrarily chunked sources. Frames are
+// split as specified by 'cfg' (newline-delimited by default, which matches
+// json.Encoder) and each frame is decoded with a fresh Decoder from 'f'.
+// Empty frames are skipped. Close decodes a trailing frame which is not
+// terminated by a separator, if any. Like NewWriterFromBytes, it implements
+// io.StringWriter and io.ReaderFrom. Nil 'w' returns an empty non-nil
+// io.WriteCloser; nil 'f' uses json.NewDecoder.
+//
+// Example:
+//
+//	// Writes simply logs values.
+//	vw := WriterImpl[int]{
+//		Impl: func(ctx context.Context, v int) error {
+//			t.Log(v)
+//			return nil
+//		},
+//	}
+//
+//	bw := NewWriterFromBytesBuffered(vw)(RecordCfg{}, nil)
+//	bw.Write([]byte("1"))   // Nothing yet.
+//	bw.Write([]byte("2\n")) // Logs "12"
+//	bw.Close()
+func NewWriterFromBytesBuffered[T any](w Writer[T]) func(cfg RecordCfg, f decoderFn) io.WriteCloser {
+	return func(cfg RecordCfg, f decoderFn) io.WriteCloser {
+		if w == nil {
+			return readWriteCloserImpl{}
+		}
+
+		if f == nil {
+			f = func(r io.Reader) Decoder { return json.NewDecoder(r) }
+		}
+
+		sep := cfg.sep()
+		buf := bytes.NewBuffer(nil)
+
+		writeFrame := func(frame []byte) error {
+			frame = bytes.TrimPrefix(frame, cfg.Prefix)
+			if cfg.Unescape != nil {
+				frame = cfg.Unescape(frame)
+			}
+			if len(frame) == 0 {
+				return nil
+			}
+
+			var v T
+			if err := f(bytes.NewReader(frame)).Decode(&v); err != nil {
+				return err
+			}
+
+			return w.Write(nil, v)
+		}
+
+		writeFrames := func() error {
+			for {
+				i := bytes.Index(buf.Bytes(), sep)
+				if i < 0 {
+					return nil
+				}
+
+				frame := buf.Next(i + len(sep))[:i]
+				if err := writeFrame(frame); err != nil {
+					return err
+				}
+			}
+		}
+
+		return readWriteCloserImpl{
+			ImplC: func() error {
+				frame := buf.Bytes()
+				buf.Reset()
+
+				return writeFrame(frame)
+			},
+			ImplW: func(p []byte) (n int, err error) {
+				n, _ = buf.Write(p)
+				return n, writeFrames()
+			},
+			ImplWS: func(s string) (n int, err error) {
+				n, _ = buf.WriteString(s)
+				return n, writeFrames()
+			},
+			// Reads straight into the internal buffer in chunks, rather than
+			// through an intermediate buffer as io.Copy would.
+			ImplRF: func(r io.Reader) (n int64, err error) {
+				for {
+					m, err := buf.ReadFrom(io.LimitReader(r, 32<<10))
+					n += m
+					if err != nil || m == 0 {
+						return n, err
+					}
+
+					if err := writeFrames(); err != nil {
+						return n, err
+					}
+				}
 			},
 		}
 	}
@@ -216,7 +548,10 @@ func NewWriterFromBytes[T any](w Writer[T]) func(f decoderFn) io.Writer {
 // size. When the buffer is full, it is written into 'w'. Note that this should
 // be used with caution due to the internal buffer, as there may be value loss
 // if the process exits before the buffer is filled and written to 'w', e.g
-// if 'size' is 10 but the process exits after only writing 9 times.
+// if 'size' is 10 but the process exits after only writing 9 times. If the
+// ctx carries a Budget (see WithBudget), the buffer is written early when a
+// value would exceed it. The returned Writer implements Flusher, which writes
+// the buffer into 'w' (and flushes 'w' too, see AsFlusher).
 //
 // Example (interactive):
 //   - https://go.dev/play/p/sbOaajf3Jt8
@@ -233,134 +568,1123 @@ func NewWriterFromBytes[T any](w Writer[T]) func(f decoderFn) io.Writer {
 //	w.Write(nil, 3)
 func NewWriterWithBatching[T any](w Writer[[]T], size int) Writer[T] {
 	if w == nil {
-		return WriterImpl[T]{}
+		return nilWriter[T]()
 
 	}
 
+	var hold budgetHold
 	buf := make([]T, 0, size)
-	return WriterImpl[T]{
-		Impl: func(ctx context.Context, val T) (err error) {
-			buf = append(buf, val)
-
-			if len(buf) >= size {
-				err = w.Write(ctx, buf)
-				buf = make([]T, 0, size)
-			}
 
-			return err
-		},
+	flush := func(ctx context.Context) error {
+		err := w.Write(ctx, buf)
+		buf = make([]T, 0, size)
+		hold.release()
+		return err
 	}
-}
 
-// NewWriterWithUnbatching returns a Writer which accepts []T on a Write call,
-// then iterates through the slice and writes each value to 'w'.
-//
-// Example (interactive):
-//   - https://go.dev/play/p/E-qP0CE8wV3
-//
-// Example:
-//
-//	// Writes which logs values through 't.Log'.
-//	logWriter := WriterImpl[int]{}
-//	logWriter.Impl = func(_ context.Context, v int) error { t.Log(v); return nil }
-//
-//	w := NewWriterWithUnbatching(logWriter)
-//	w.Write(nil, []int{1, 2})
-//	// ^ logWriter logs the following lines:
-//	//  1
-//	//  2
-func NewWriterWithUnbatching[T any](w Writer[T]) Writer[[]T] {
-	if w == nil {
-		return WriterImpl[[]T]{}
-	}
+	return flushWriter[T]{
+		WriterImpl: WriterImpl[T]{
+			Impl: func(ctx context.Context, val T) (err error) {
+				if err := ctxErr(ctx); err != nil {
+					return err
+				}
 
-	return WriterImpl[[]T]{
-		Impl: func(ctx context.Context, vs []T) (err error) {
-			for _, v := range vs {
-				err = w.Write(ctx, v)
-				if err != nil {
-					return
+				// Makes room by flushing early if the Budget is exceeded.
+				if err := reserveHold(ctx, &hold, val); err != nil {
+					if len(buf) == 0 {
+						return err
+					}
+					if err := flush(ctx); err != nil {
+						return err
+					}
+					if err := reserveHold(ctx, &hold, val); err != nil {
+						return err
+					}
+				}
+
+				buf = append(buf, val)
+
+				if len(buf) >= size {
+					err = flush(ctx)
+				}
+
+				return err
+			},
+		},
+		flush: func(ctx context.Context) error {
+			if len(buf) > 0 {
+				if err := flush(ctx); err != nil {
+					return err
 				}
 			}
 
-			return
+			return flushInner(ctx, w)
 		},
 	}
 }
 
-// NewWriterWithFilterFn returns a writer which writes values into 'w', except
-// those filtered by 'f'. Nil 'w' returns an empty Writer; nil 'f' returns 'w'.
-//
-// Example (interactive):
-//   - https://go.dev/play/p/BgKAgGVvJ7b
+// AdaptiveBatchingCfg is used to configure NewWriterWithAdaptiveBatching.
+type AdaptiveBatchingCfg struct {
+	// MinSize is the smallest batch size, <= 0 defaults to 1.
+	MinSize int
+	// MaxSize is the largest batch size, <= 0 defaults to 1024. It is raised
+	// to MinSize if it is smaller.
+	MaxSize int
+	// TargetLatency is the desired duration of a single batch write into the
+	// underlying Writer. <= 0 defaults to 100ms.
+	TargetLatency time.Duration
+}
+
+// NewWriterWithAdaptiveBatching returns a WriteCloser which, similar to
+// NewWriterWithBatching, writes values into a buffer which is written into
+// 'w' when full. The difference is that the buffer size is not static: the
+// time each batch write takes is measured (with the Clock in the ctx, see
+// ClockFrom), and the size is adjusted (within the bounds of the given
+// AdaptiveBatchingCfg) to approach the target latency. The size starts at
+// MinSize, and at most doubles or halves per batch. Close writes any buffered
+// values into 'w', so it should always be called. Like NewWriterWithBatching,
+// the buffer is written early when a value would exceed the Budget carried by
+// the ctx, if any, and the returned WriteCloser implements Flusher. Nil 'w'
+// returns an empty WriteCloser.
 //
 // Example:
 //
-//	// Writes which logs values through 't.Log'.
-//	logWriter := WriterImpl[int]{}
-//	logWriter.Impl = func(_ context.Context, v int) error { t.Log(v); return nil }
-//
-//	w := NewWriterWithFilterFn(logWriter)(
-//		func(v int) bool {
-//			return v > 1
+//	w := NewWriterWithAdaptiveBatching(bulkInsertWriter)(
+//		AdaptiveBatchingCfg{
+//			MinSize:       10,
+//			MaxSize:       10_000,
+//			TargetLatency: time.Millisecond * 50,
 //		},
 //	)
 //
-//	w.Write(nil, 1) // Logs: nothing
-//	w.Write(nil, 2) // Logs: 2
-//	w.Write(nil, 3) // Logs: 3
-func NewWriterWithFilterFn[T any](w Writer[T]) func(f func(T) bool) Writer[T] {
-	return func(f func(T) bool) Writer[T] {
+//	defer w.Close()
+func NewWriterWithAdaptiveBatching[T any](w Writer[[]T]) func(cfg AdaptiveBatchingCfg) WriteCloser[T] {
+	return func(cfg AdaptiveBatchingCfg) WriteCloser[T] {
 		if w == nil {
-			return WriterImpl[T]{}
+			return nilWriteCloser[T]()
 		}
-		if f == nil {
-			return w
+
+		if cfg.MinSize <= 0 {
+			cfg.MinSize = 1
+		}
+		if cfg.MaxSize <= 0 {
+			cfg.MaxSize = 1024
+		}
+		if cfg.MaxSize < cfg.MinSize {
+			cfg.MaxSize = cfg.MinSize
+		}
+		if cfg.TargetLatency <= 0 {
+			cfg.TargetLatency = time.Millisecond * 100
 		}
 
-		return WriterImpl[T]{
-			Impl: func(ctx context.Context, v T) error {
-				if !f(v) {
-					return nil
+		var hold budgetHold
+		size := cfg.MinSize
+		buf := make([]T, 0, size)
+
+		// Smoothed (exponentially weighted) write latency per value.
+		var perValue float64
+
+		flush := func(ctx context.Context) error {
+			if len(buf) == 0 {
+				return nil
+			}
+
+			n := len(buf)
+			clock := ClockFrom(ctx)
+			start := clock.Now()
+			err := w.Write(ctx, buf)
+			d := float64(clock.Now().Sub(start)) / float64(n)
+			hold.release()
+
+			if perValue == 0 {
+				perValue = d
+			} else {
+				perValue = perValue*0.8 + d*0.2
+			}
+
+			next := size * 2
+			if perValue > 0 {
+				next = int(float64(cfg.TargetLatency) / perValue)
+			}
+
+			next = min(max(next, size/2, cfg.MinSize), size*2, cfg.MaxSize)
+			size = next
+			buf = make([]T, 0, size)
+			return err
+		}
+
+		return flushWriteCloser[T]{
+			WriteCloserImpl: WriteCloserImpl[T]{
+				ImplC: func() error {
+					return flush(context.Background())
+				},
+				ImplW: func(ctx context.Context, v T) error {
+					if err := ctxErr(ctx); err != nil {
+						return err
+					}
+
+					// Makes room by flushing early if the Budget is exceeded.
+					if err := reserveHold(ctx, &hold, v); err != nil {
+						if len(buf) == 0 {
+							return err
+						}
+						if err := flush(ctx); err != nil {
+							return err
+						}
+						if err := reserveHold(ctx, &hold, v); err != nil {
+							return err
+						}
+					}
+
+					buf = append(buf, v)
+					if len(buf) < size {
+						return nil
+					}
+
+					return flush(ctx)
+				},
+			},
+			flush: func(ctx context.Context) error {
+				if err := flush(ctx); err != nil {
+					return err
 				}
 
-				return w.Write(ctx, v)
+				return flushInner(ctx, w)
 			},
 		}
 	}
 }
 
-// NewWriterWithMapperFn returns a writer which writes values into 'w' after
-// being transformed with 'f'. Nil 'w' or 'f' returns an empty Writer.
-//
-// Example (interactive):
-//   - https://go.dev/play/p/V3OvYkJS-mC
+// NewWriterWithCoalescing returns a WriteCloser which, similar to
+// NewWriterWithBatching, writes values into a buffer which is written into 'w'
+// when full. The difference is that values with the same key (as given by
+// 'key') are coalesced while buffered: a value with the key of a buffered one
+// replaces it with merge(buffered, new), such that bursts of updates to the
+// same key (e.g of a key-value store) end up as a single write. Nil 'merge'
+// keeps the newest value. The buffer is full when it holds 'size' distinct
+// keys, and is written in the order each key was first buffered. Close writes
+// any buffered values into 'w', so it should always be called; Flush (see
+// Flusher) does so too, without closing. Like NewWriterWithBatching, the
+// buffer is written early when a new key would exceed the Budget carried by
+// the ctx, if any. Nil 'w' returns an empty WriteCloser; nil 'key' or 'size'
+// <= 0 make every value distinct, i.e the behavior is then like
+// NewWriterWithBatching.
 //
 // Example:
 //
 //	// Writes which logs values through 't.Log'.
-//	logWriter := WriterImpl[int]{}
-//	logWriter.Impl = func(_ context.Context, v int) error { t.Log(v); return nil }
+//	logWriter := WriterImpl[[]Counter]{}
+//	logWriter.Impl = func(_ context.Context, v []Counter) error { t.Log(v); return nil }
 //
-//	w := NewWriterWithMapperFn[int](logWriter)(
-//		func(v int) int {
-//			return v + 1
-//		},
+//	w := NewWriterWithCoalescing[Counter, string](logWriter)(
+//		func(v Counter) string { return v.Name },
+//		func(old, new Counter) Counter { return Counter{old.Name, old.N + new.N} },
+//		2,
 //	)
 //
-//	w.Write(nil, 1) // Logs: 2
-//	w.Write(nil, 2) // Logs: 3
-//	w.Write(nil, 3) // Logs: 4
-func NewWriterWithMapperFn[T, U any](w Writer[U]) func(f func(T) U) Writer[T] {
+//	w.Write(nil, Counter{"a", 1})
+//	w.Write(nil, Counter{"a", 2})
+//	w.Write(nil, Counter{"b", 1}) // Logs: [{a 3} {b 1}]
+func NewWriterWithCoalescing[T any, K comparable](w Writer[[]T]) func(key func(T) K, merge func(old, new T) T, size int) WriteCloser[T] {
+	return func(key func(T) K, merge func(old, new T) T, size int) WriteCloser[T] {
+		if w == nil {
+			return nilWriteCloser[T]()
+		}
+
+		if size <= 0 {
+			size = 1
+		}
+
+		var hold budgetHold
+		buf := make([]T, 0, size)
+		// Index into buf by key.
+		index := make(map[K]int, size)
+
+		flush := func(ctx context.Context) error {
+			if len(buf) == 0 {
+				return nil
+			}
+
+			err := w.Write(ctx, buf)
+			buf = make([]T, 0, size)
+			clear(index)
+			hold.release()
+			return err
+		}
+
+		return flushWriteCloser[T]{
+			WriteCloserImpl: WriteCloserImpl[T]{
+				ImplC: func() error {
+					return flush(context.Background())
+				},
+				ImplW: func(ctx context.Context, v T) error {
+					if err := ctxErr(ctx); err != nil {
+						return err
+					}
+
+					var k K
+					if key != nil {
+						k = key(v)
+						if i, ok := index[k]; ok {
+							if merge != nil {
+								v = merge(buf[i], v)
+							}
+
+							buf[i] = v
+							return nil
+						}
+					}
+
+					// Makes room by flushing early if the Budget is exceeded.
+					// A coalesced value keeps the reservation of the first.
+					if err := reserveHold(ctx, &hold, v); err != nil {
+						if len(buf) == 0 {
+							return err
+						}
+						if err := flush(ctx); err != nil {
+							return err
+						}
+						if err := reserveHold(ctx, &hold, v); err != nil {
+							return err
+						}
+					}
+
+					if key != nil {
+						index[k] = len(buf)
+					}
+
+					buf = append(buf, v)
+					if len(buf) < size {
+						return nil
+					}
+
+					return flush(ctx)
+				},
+			},
+			flush: func(ctx context.Context) error {
+				if err := flush(ctx); err != nil {
+					return err
+				}
+
+				return flushInner(ctx, w)
+			},
+		}
+	}
+}
+
+// NewWriterWithRunLength is the Writer analog of NewReaderWithRunLength: it
+// collapses runs of consecutive equal values into a single Counted value,
+// which is written into 'w' when the run ends, i.e when a different value is
+// written. Close writes the last run into 'w', so it should always be called.
+// Flush (see Flusher) does so as well, i.e a run which continues after it is
+// written as two. If writing a run into 'w' fails, the run is kept and the err
+// is returned, without accepting the value which ended the run; a later Write,
+// Flush or Close tries again. Nil 'w' returns an empty WriteCloser.
+//
+// Example:
+//
+//	// Writes which logs values through 't.Log'.
+//	logWriter := WriterImpl[Counted[string]]{}
+//	logWriter.Impl = func(_ context.Context, v Counted[string]) error { t.Log(v); return nil }
+//
+//	w := NewWriterWithRunLength(logWriter)
+//	w.Write(nil, "a")
+//	w.Write(nil, "a")
+//	w.Write(nil, "b") // Logs: {a 2}
+//	w.Close()         // Logs: {b 1}
+func NewWriterWithRunLength[T comparable](w Writer[Counted[T]]) WriteCloser[T] {
+	if w == nil {
+		return nilWriteCloser[T]()
+	}
+
+	var run Counted[T]
+	flush := func(ctx context.Context) error {
+		if run.N == 0 {
+			return nil
+		}
+
+		if err := w.Write(ctx, run); err != nil {
+			return err
+		}
+
+		run = Counted[T]{}
+		return nil
+	}
+
+	return flushWriteCloser[T]{
+		WriteCloserImpl: WriteCloserImpl[T]{
+			ImplC: func() error {
+				return flush(context.Background())
+			},
+			ImplW: func(ctx context.Context, v T) error {
+				if run.N > 0 && v == run.Value {
+					run.N++
+					return nil
+				}
+
+				if err := flush(ctx); err != nil {
+					return err
+				}
+
+				run = Counted[T]{Value: v, N: 1}
+				return nil
+			},
+		},
+		flush: func(ctx context.Context) error {
+			if err := flush(ctx); err != nil {
+				return err
+			}
+
+			return flushInner(ctx, w)
+		},
+	}
+}
+
+// NewWriterWithUnbatching returns a Writer which accepts []T on a Write call,
+// then iterates through the slice and writes each value to 'w'.
+//
+// Example (interactive):
+//   - https://go.dev/play/p/E-qP0CE8wV3
+//
+// Example:
+//
+//	// Writes which logs values through 't.Log'.
+//	logWriter := WriterImpl[int]{}
+//	logWriter.Impl = func(_ context.Context, v int) error { t.Log(v); return nil }
+//
+//	w := NewWriterWithUnbatching(logWriter)
+//	w.Write(nil, []int{1, 2})
+//	// ^ logWriter logs the following lines:
+//	//  1
+//	//  2
+func NewWriterWithUnbatching[T any](w Writer[T]) Writer[[]T] {
+	if w == nil {
+		return nilWriter[[]T]()
+	}
+
+	return WriterImpl[[]T]{
+		Wraps: w,
+		Impl: func(ctx context.Context, vs []T) (err error) {
+			for _, v := range vs {
+				if err = ctxErr(ctx); err != nil {
+					return
+				}
+
+				err = w.Write(ctx, v)
+				if err != nil {
+					return
+				}
+			}
+
+			return
+		},
+	}
+}
+
+// NewWriterWithFilterFn returns a writer which writes values into 'w', except
+// those filtered by 'f'. Nil 'w' returns an empty Writer; nil 'f' returns 'w'.
+//
+// Example (interactive):
+//   - https://go.dev/play/p/BgKAgGVvJ7b
+//
+// Example:
+//
+//	// Writes which logs values through 't.Log'.
+//	logWriter := WriterImpl[int]{}
+//	logWriter.Impl = func(_ context.Context, v int) error { t.Log(v); return nil }
+//
+//	w := NewWriterWithFilterFn(logWriter)(
+//		func(v int) bool {
+//			return v > 1
+//		},
+//	)
+//
+//	w.Write(nil, 1) // Logs: nothing
+//	w.Write(nil, 2) // Logs: 2
+//	w.Write(nil, 3) // Logs: 3
+func NewWriterWithFilterFn[T any](w Writer[T]) func(f func(T) bool) Writer[T] {
+	return func(f func(T) bool) Writer[T] {
+		if w == nil {
+			return nilWriter[T]()
+		}
+		if f == nil {
+			return w
+		}
+
+		return WriterImpl[T]{
+			Wraps: w,
+			Impl: func(ctx context.Context, v T) error {
+				if !f(v) {
+					return nil
+				}
+
+				return w.Write(ctx, v)
+			},
+		}
+	}
+}
+
+// NewWriterWithFilterFnCtx is like NewWriterWithFilterFn, except that 'f' gets
+// the ctx given to Write, e.g to honor deadlines or use request-scoped values.
+// Nil 'w' returns an empty Writer; nil 'f' returns 'w'.
+func NewWriterWithFilterFnCtx[T any](w Writer[T]) func(f func(ctx context.Context, v T) bool) Writer[T] {
+	return func(f func(ctx context.Context, v T) bool) Writer[T] {
+		if w == nil {
+			return nilWriter[T]()
+		}
+		if f == nil {
+			return w
+		}
+
+		return WriterImpl[T]{
+			Wraps: w,
+			Impl: func(ctx context.Context, v T) error {
+				if !f(ctx, v) {
+					return nil
+				}
+
+				return w.Write(ctx, v)
+			},
+		}
+	}
+}
+
+// NewWriterWithSampleNth is the Writer analog of NewReaderWithSampleNth: every
+// n-th value, starting with the first, is written into 'w', and the others
+// are dropped. Nil 'w' returns an empty Writer; 'n' <= 1 returns 'w'.
+func NewWriterWithSampleNth[T any](w Writer[T], n int) Writer[T] {
+	if w == nil {
+		return nilWriter[T]()
+	}
+	if n <= 1 {
+		return w
+	}
+
+	return NewWriterWithFilterFn(w)(newSampleNthFn[T](n))
+}
+
+// NewWriterWithSampleProb is the Writer analog of NewReaderWithSampleProb:
+// each value is written into 'w' with probability 'p', and dropped otherwise.
+// Nil 'w' returns an empty Writer; 'p' >= 1 returns 'w', and 'p' <= 0 drops
+// all values.
+func NewWriterWithSampleProb[T any](w Writer[T], p float64, seed uint64) Writer[T] {
+	if w == nil {
+		return nilWriter[T]()
+	}
+	if p >= 1 {
+		return w
+	}
+
+	return NewWriterWithFilterFn(w)(newSampleProbFn[T](p, seed))
+}
+
+// NewWriterWithDedupFn is the Writer analog of NewReaderWithDedupFn: values
+// with a key which was already written into 'w' are dropped, e.g to make a
+// sink idempotent. A key is only remembered once its value was written into
+// 'w' without an err, such that failed writes can be retried. Memory usage
+// grows with the amount of distinct keys. Nil 'w' returns an empty Writer;
+// nil 'key' returns 'w'.
+func NewWriterWithDedupFn[T any, K comparable](w Writer[T]) func(key func(T) K) Writer[T] {
+	return func(key func(T) K) Writer[T] {
+		if w == nil {
+			return nilWriter[T]()
+		}
+		if key == nil {
+			return w
+		}
+
+		seen := make(map[K]struct{})
+		return WriterImpl[T]{
+			Wraps: w,
+			Impl: func(ctx context.Context, v T) error {
+				k := key(v)
+				if _, ok := seen[k]; ok {
+					return nil
+				}
+
+				if err := w.Write(ctx, v); err != nil {
+					return err
+				}
+
+				seen[k] = struct{}{}
+				return nil
+			},
+		}
+	}
+}
+
+// NewWriterWithDedupWindowFn is like NewWriterWithDedupFn, except that keys are
+// forgotten as configured by 'cfg', see NewReaderWithDedupWindowFn. Nil 'w'
+// returns an empty Writer; nil 'key' returns 'w'.
+func NewWriterWithDedupWindowFn[T any, K comparable](w Writer[T]) func(key func(T) K, cfg DedupCfg) Writer[T] {
+	return func(key func(T) K, cfg DedupCfg) Writer[T] {
+		if w == nil {
+			return nilWriter[T]()
+		}
+		if key == nil {
+			return w
+		}
+
+		seen := newDedupWindow[K](cfg)
+		return WriterImpl[T]{
+			Wraps: w,
+			Impl: func(ctx context.Context, v T) error {
+				k := key(v)
+				now := ClockFrom(ctx).Now()
+				if seen.contains(k, now) {
+					return nil
+				}
+
+				if err := w.Write(ctx, v); err != nil {
+					return err
+				}
+
+				seen.add(k, now)
+				return nil
+			},
+		}
+	}
+}
+
+// NewWriterWithIfFn returns a writer which writes values into 'then' if they
+// satisfy 'pred', and into 'els' otherwise. A nil 'then' or 'els' drops the
+// values which would go to it, such that NewWriterWithIfFn(pred, w, nil) is
+// the same as a filter writer. Nil 'pred' sends all values to 'then'.
+//
+// Example:
+//
+//	// Writes which logs values through 't.Log'.
+//	logWriter := func(prefix string) Writer[int] {
+//		return WriterImpl[int]{
+//			Impl: func(_ context.Context, v int) error { t.Log(prefix, v); return nil },
+//		}
+//	}
+//
+//	w := NewWriterWithIfFn(
+//		func(v int) bool { return v%2 == 0 },
+//		logWriter("even"),
+//		logWriter("odd"),
+//	)
+//
+//	w.Write(nil, 1) // Logs: odd 1
+//	w.Write(nil, 2) // Logs: even 2
+func NewWriterWithIfFn[T any](pred func(T) bool, then, els Writer[T]) Writer[T] {
+	if then == nil {
+		then = NewWriterFromDiscard[T]()
+	}
+	if els == nil {
+		els = NewWriterFromDiscard[T]()
+	}
+	if pred == nil {
+		return then
+	}
+
+	return WriterImpl[T]{
+		Impl: func(ctx context.Context, v T) error {
+			if pred(v) {
+				return then.Write(ctx, v)
+			}
+
+			return els.Write(ctx, v)
+		},
+	}
+}
+
+// NewWriterWithMapperFn returns a writer which writes values into 'w' after
+// being transformed with 'f'. Nil 'w' or 'f' returns an empty Writer.
+//
+// Example (interactive):
+//   - https://go.dev/play/p/V3OvYkJS-mC
+//
+// Example:
+//
+//	// Writes which logs values through 't.Log'.
+//	logWriter := WriterImpl[int]{}
+//	logWriter.Impl = func(_ context.Context, v int) error { t.Log(v); return nil }
+//
+//	w := NewWriterWithMapperFn[int](logWriter)(
+//		func(v int) int {
+//			return v + 1
+//		},
+//	)
+//
+//	w.Write(nil, 1) // Logs: 2
+//	w.Write(nil, 2) // Logs: 3
+//	w.Write(nil, 3) // Logs: 4
+//
+// Note that a nil 'f' gives a Writer which always fails, unlike the nil filter
+// of NewWriterWithFilterFn which passes values through. See
+// NewWriterWithMapperFnChecked for a variant which reports this up front.
+func NewWriterWithMapperFn[T, U any](w Writer[U]) func(f func(T) U) Writer[T] {
 	return func(f func(T) U) Writer[T] {
 		if w == nil || f == nil {
-			return WriterImpl[T]{}
+			return nilWriter[T]()
 		}
 
 		return WriterImpl[T]{
+			Wraps: w,
 			Impl: func(ctx context.Context, v T) error {
 				return w.Write(ctx, f(v))
 			},
 		}
 	}
 }
+
+// NewWriterWithMapperFnCtx is like NewWriterWithMapperFn, except that 'f' gets
+// the ctx given to Write. See NewWriterWithMapperFnE for a mapper which may
+// also fail. Nil 'w' or 'f' returns an empty Writer.
+func NewWriterWithMapperFnCtx[T, U any](w Writer[U]) func(f func(ctx context.Context, v T) U) Writer[T] {
+	return func(f func(ctx context.Context, v T) U) Writer[T] {
+		if f == nil {
+			return nilWriter[T]()
+		}
+
+		return NewWriterWithMapperFnE[T, U](w)(
+			func(ctx context.Context, v T) (U, error) {
+				return f(ctx, v), nil
+			},
+		)
+	}
+}
+
+// NewWriterWithMapperFnE is like NewWriterWithMapperFn, except that 'f' gets
+// the ctx given to Write and may fail, e.g when parsing or validating values.
+// An err from 'f' is returned by Write as-is, and nothing is written into 'w'.
+// Nil 'w' or 'f' returns an empty Writer.
+//
+// Example:
+//
+//	w := NewWriterWithMapperFnE[string, int](logWriter)(
+//		func(ctx context.Context, v string) (int, error) {
+//			return strconv.Atoi(v)
+//		},
+//	)
+//
+//	w.Write(nil, "1") // Logs: 1
+//	w.Write(nil, "x") // Returns: strconv.Atoi: parsing "x": invalid syntax
+func NewWriterWithMapperFnE[T, U any](w Writer[U]) func(f func(context.Context, T) (U, error)) Writer[T] {
+	return func(f func(context.Context, T) (U, error)) Writer[T] {
+		if w == nil || f == nil {
+			return nilWriter[T]()
+		}
+
+		return WriterImpl[T]{
+			Wraps: w,
+			Impl: func(ctx context.Context, v T) error {
+				u, err := f(ctx, v)
+				if err != nil {
+					return err
+				}
+
+				return w.Write(ctx, u)
+			},
+		}
+	}
+}
+
+// NewWriterWithTimestamps is the Writer analog of NewReaderWithTimestamps: it
+// pairs each value with the time it was written, according to the Clock in
+// the ctx (see ClockFrom), before writing it into 'w'. Nil 'w' returns an
+// empty Writer.
+func NewWriterWithTimestamps[T any](w Writer[Timestamped[T]]) Writer[T] {
+	if w == nil {
+		return nilWriter[T]()
+	}
+
+	return WriterImpl[T]{
+		Wraps: w,
+		Impl: func(ctx context.Context, v T) error {
+			return w.Write(ctx, Timestamped[T]{Value: v, Time: ClockFrom(ctx).Now()})
+		},
+	}
+}
+
+// NewWriterWithSequence returns a Writer which stamps each value with a
+// monotonically increasing sequence number, starting at 0, before writing it
+// into 'w'. With a Writer of batches (or frames), this lets the receiving side
+// detect lost batches with NewReaderWithSequenceCheck. The sequence number is
+// only used for values which were written into 'w' without an err, so
+// retrying a failed Write does not cause a gap. Nil 'w' returns an empty
+// Writer.
+//
+// Example:
+//
+//	w := NewWriterWithSequence(NewWriterFromValues[Sequenced[[]Event]](conn)(nil))
+//	w.Write(ctx, batch) // Writes {"Seq": 0, "Value": [...]}
+//	w.Write(ctx, batch) // Writes {"Seq": 1, "Value": [...]}
+func NewWriterWithSequence[T any](w Writer[Sequenced[T]]) Writer[T] {
+	if w == nil {
+		return nilWriter[T]()
+	}
+
+	var seq uint64
+	return WriterImpl[T]{
+		Wraps: w,
+		Impl: func(ctx context.Context, v T) error {
+			err := w.Write(ctx, Sequenced[T]{Seq: seq, Value: v})
+			if err == nil {
+				seq++
+			}
+
+			return err
+		},
+	}
+}
+
+// NewWriterWithExpiry returns a Writer which drops values that have already
+// expired at write time, and writes the rest into 'w'. The expiry of each value
+// is given by 'expiry', where the zero time means that it never expires; the
+// current time is given by the Clock in the ctx (see ClockFrom). This protects
+// e.g caches from ingesting stale values after long queueing delays. Dropped
+// values are counted in the returned counter, which is updated atomically and
+// may be read with atomic.LoadInt64 while the Writer is in use. Nil 'w'
+// returns an empty Writer; nil 'expiry' returns 'w'.
+//
+// Example:
+//
+//	w, dropped := NewWriterWithExpiry(sessionStore)(
+//		func(v Session) time.Time {
+//			return v.LastSeen.Add(time.Minute * 30)
+//		},
+//	)
+//
+//	w.Write(ctx, fresh) // Written into sessionStore.
+//	w.Write(ctx, stale) // Dropped, returns nil.
+//
+//	fmt.Println(atomic.LoadInt64(dropped)) // 1
+func NewWriterWithExpiry[T any](w Writer[T]) func(expiry func(T) time.Time) (Writer[T], *int64) {
+	return func(expiry func(T) time.Time) (Writer[T], *int64) {
+		n := new(int64)
+		if w == nil {
+			return nilWriter[T](), n
+		}
+		if expiry == nil {
+			return w, n
+		}
+
+		return WriterImpl[T]{
+			Wraps: w,
+			Impl: func(ctx context.Context, v T) error {
+				exp := expiry(v)
+				if !exp.IsZero() && !ClockFrom(ctx).Now().Before(exp) {
+					atomic.AddInt64(n, 1)
+					return nil
+				}
+
+				return w.Write(ctx, v)
+			},
+		}, n
+	}
+}
+
+// NewWriterWithTee returns a writer which writes each value into 'audit'
+// before writing it into 'w'. An err from 'audit' is returned immediately,
+// without writing into 'w'. This is shorthand for NewWriterWithTeeCfg with
+// a zero TeeCfg, see it for other audit policies. Nil 'w' returns an empty
+// Writer; nil 'audit' returns 'w'.
+//
+// Example:
+//
+//	// Writes which logs values through 't.Log'.
+//	logWriter := WriterImpl[int]{}
+//	logWriter.Impl = func(_ context.Context, v int) error { t.Log(v); return nil }
+//
+//	w := NewWriterWithTee(primary, logWriter)
+//	w.Write(nil, 1) // Logs: 1, then writes 1 into primary.
+func NewWriterWithTee[T any](w Writer[T], audit Writer[T]) Writer[T] {
+	return NewWriterWithTeeCfg(w, audit)(TeeCfg{})
+}
+
+// TeeCfg is used to configure NewWriterWithTeeCfg.
+type TeeCfg struct {
+	// Concurrent makes writes into the audit and primary Writer happen
+	// concurrently, as opposed to sequentially with the audit Writer first.
+	Concurrent bool
+	// OnAuditErr is called with errors from the audit Writer. The err it
+	// returns is used in place of the audit err, e.g returning nil ignores
+	// audit failures. Nil means that audit errors are returned as-is.
+	OnAuditErr func(error) error
+}
+
+// NewWriterWithTeeCfg returns a writer which writes each value into both 'w'
+// and 'audit', as configured by the given TeeCfg. When sequential (default),
+// an audit err (after TeeCfg.OnAuditErr) stops the value from being written
+// into 'w'. When concurrent, both writes always happen, and errors from both
+// are combined with errors.Join. Nil 'w' returns an empty Writer; nil 'audit'
+// returns 'w'.
+func NewWriterWithTeeCfg[T any](w Writer[T], audit Writer[T]) func(cfg TeeCfg) Writer[T] {
+	return func(cfg TeeCfg) Writer[T] {
+		if w == nil {
+			return nilWriter[T]()
+		}
+		if audit == nil {
+			return w
+		}
+
+		writeAudit := func(ctx context.Context, v T) error {
+			err := audit.Write(ctx, v)
+			if err != nil && cfg.OnAuditErr != nil {
+				err = cfg.OnAuditErr(err)
+			}
+
+			return err
+		}
+
+		return WriterImpl[T]{
+			Impl: func(ctx context.Context, v T) error {
+				if !cfg.Concurrent {
+					if err := writeAudit(ctx, v); err != nil {
+						return err
+					}
+
+					return w.Write(ctx, v)
+				}
+
+				errCh := make(chan error, 1)
+				go func() { errCh <- writeAudit(ctx, v) }()
+
+				err := w.Write(ctx, v)
+				return errors.Join(err, <-errCh)
+			},
+		}
+	}
+}
+
+// NewWriterWithOnWriteFn is the Writer analog of NewReaderWithOnReadFn: 'f' is
+// called with every value written into 'w' and the err of that Write, which
+// is returned as-is. Nil 'w' returns an empty Writer; nil 'f' returns 'w'.
+//
+// Example:
+//
+//	w := NewWriterWithOnWriteFn(sink)(
+//		func(v int, err error) {
+//			if err != nil {
+//				log.Printf("write of %v failed: %v", v, err)
+//			}
+//		},
+//	)
+func NewWriterWithOnWriteFn[T any](w Writer[T]) func(f func(T, error)) Writer[T] {
+	return func(f func(T, error)) Writer[T] {
+		if w == nil {
+			return nilWriter[T]()
+		}
+		if f == nil {
+			return w
+		}
+
+		return WriterImpl[T]{
+			Wraps: w,
+			Impl: func(ctx context.Context, v T) error {
+				err := w.Write(ctx, v)
+				f(v, err)
+				return err
+			},
+		}
+	}
+}
+
+// NewWriterWithStopSignal is the Writer analog of NewReaderWithStopSignal:
+// after 'stop' is closed, Write returns io.ErrClosedPipe without writing into
+// 'w'. A Write into 'w' which is ongoing when 'stop' is closed gets its ctx
+// cancelled, and returns io.ErrClosedPipe if it fails. Nil 'w' returns an
+// empty Writer; nil 'stop' returns 'w'.
+func NewWriterWithStopSignal[T any](w Writer[T], stop <-chan struct{}) Writer[T] {
+	if w == nil {
+		return nilWriter[T]()
+	}
+	if stop == nil {
+		return w
+	}
+
+	signal := &stopSignal{stop: stop}
+	return WriterImpl[T]{
+		Wraps: w,
+		Impl: func(ctx context.Context, v T) error {
+			if isClosed(stop) {
+				return io.ErrClosedPipe
+			}
+
+			ctx, done := signal.with(ctx)
+			defer done()
+
+			err := w.Write(ctx, v)
+			if err != nil && isClosed(stop) {
+				return io.ErrClosedPipe
+			}
+
+			return err
+		},
+	}
+}
+
+// RoundRobinCfg is used to configure NewWriterWithRoundRobinCfg.
+type RoundRobinCfg struct {
+	// Cooldown is the time a Writer is skipped for after it returned an err,
+	// such that writes go to the healthy ones. Ctx errors do not count, as
+	// they are not the fault of the Writer. If all are cooling down, the
+	// rotation continues as if none were. The Clock in the ctx is used, see
+	// ClockFrom. <= 0 means that failing writers are not skipped.
+	Cooldown time.Duration
+}
+
+// NewWriterWithRoundRobin returns a Writer which writes each value into one of
+// 'ws', rotating through them in order, e.g to spread load over multiple
+// connections. Errors are returned as-is, i.e the value is not written into
+// another Writer. The returned Writer is safe for concurrent use if all of
+// 'ws' are. Nil writers are skipped; no (non-nil) writers returns an empty
+// Writer. See NewWriterWithRoundRobinCfg for skipping failing writers.
+//
+// Example:
+//
+//	w := NewWriterWithRoundRobin(conn1, conn2, conn3)
+//
+//	w.Write(ctx, 1) // Into conn1.
+//	w.Write(ctx, 2) // Into conn2.
+//	w.Write(ctx, 3) // Into conn3.
+//	w.Write(ctx, 4) // Into conn1.
+func NewWriterWithRoundRobin[T any](ws ...Writer[T]) Writer[T] {
+	return NewWriterWithRoundRobinCfg(ws...)(RoundRobinCfg{})
+}
+
+// NewWriterWithRoundRobinCfg is like NewWriterWithRoundRobin, but writers
+// which recently failed are skipped as configured by the given RoundRobinCfg.
+//
+// Example:
+//
+//	w := NewWriterWithRoundRobinCfg(conn1, conn2, conn3)(
+//		RoundRobinCfg{Cooldown: time.Second * 5},
+//	)
+func NewWriterWithRoundRobinCfg[T any](ws ...Writer[T]) func(cfg RoundRobinCfg) Writer[T] {
+	// Copying, so the caller can not modify the slice while it is in use.
+	ws = slices.DeleteFunc(slices.Clone(ws), func(w Writer[T]) bool { return w == nil })
+
+	return func(cfg RoundRobinCfg) Writer[T] {
+		if len(ws) == 0 {
+			return nilWriter[T]()
+		}
+
+		var mx sync.Mutex
+		next := 0
+		// The time until which each Writer is skipped.
+		cooldowns := make([]time.Time, len(ws))
+
+		pick := func(now time.Time) int {
+			mx.Lock()
+			defer mx.Unlock()
+
+			i := next
+			for j := 0; j < len(ws); j++ {
+				k := (next + j) % len(ws)
+				if !now.Before(cooldowns[k]) {
+					i = k
+					break
+				}
+			}
+
+			next = (i + 1) % len(ws)
+			return i
+		}
+
+		return WriterImpl[T]{
+			Impl: func(ctx context.Context, v T) error {
+				var now time.Time
+				if cfg.Cooldown > 0 {
+					now = ClockFrom(ctx).Now()
+				}
+
+				i := pick(now)
+				err := ws[i].Write(ctx, v)
+				if err == nil || cfg.Cooldown <= 0 {
+					return err
+				}
+
+				// The ctx failed rather than the Writer.
+				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+					return err
+				}
+
+				// The write may have taken a while, so the cooldown starts now.
+				until := ClockFrom(ctx).Now().Add(cfg.Cooldown)
+				mx.Lock()
+				cooldowns[i] = until
+				mx.Unlock()
+
+				return err
+			},
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Checked variants.
+// -----------------------------------------------------------------------------
+
+// NewWriterWithBatchingChecked is like NewWriterWithBatching, except that it
+// returns an err wrapping ErrInvalidArg if 'w' is nil or 'size' <= 0.
+func NewWriterWithBatchingChecked[T any](w Writer[[]T], size int) (Writer[T], error) {
+	if w == nil {
+		return nil, fmt.Errorf("%w: nil writer", ErrInvalidArg)
+	}
+	if size <= 0 {
+		return nil, fmt.Errorf("%w: non-positive batch size %d", ErrInvalidArg, size)
+	}
+
+	return NewWriterWithBatching(w, size), nil
+}
+
+// NewWriterWithUnbatchingChecked is like NewWriterWithUnbatching, except that
+// it returns an err wrapping ErrInvalidArg if 'w' is nil.
+func NewWriterWithUnbatchingChecked[T any](w Writer[T]) (Writer[[]T], error) {
+	if w == nil {
+		return nil, fmt.Errorf("%w: nil writer", ErrInvalidArg)
+	}
+
+	return NewWriterWithUnbatching(w), nil
+}
+
+// NewWriterWithFilterFnChecked is like NewWriterWithFilterFn, except that it
+// returns an err wrapping ErrInvalidArg if 'w' or 'f' is nil.
+func NewWriterWithFilterFnChecked[T any](w Writer[T]) func(f func(T) bool) (Writer[T], error) {
+	return func(f func(T) bool) (Writer[T], error) {
+		if w == nil {
+			return nil, fmt.Errorf("%w: nil writer", ErrInvalidArg)
+		}
+		if f == nil {
+			return nil, fmt.Errorf("%w: nil filter func", ErrInvalidArg)
+		}
+
+		return NewWriterWithFilterFn(w)(f), nil
+	}
+}
+
+// NewWriterWithMapperFnChecked is like NewWriterWithMapperFn, except that
+// invalid arguments are reported at construction time with an err wrapping
+// ErrInvalidArg, instead of giving a Writer which always fails. A nil 'f' is
+// valid if T and U are the same type, in which case 'w' is returned as-is
+// (consistent with NewWriterWithFilterFn); otherwise it is invalid, as is a
+// nil 'w'.
+//
+// Example:
+//
+//	w, err := NewWriterWithMapperFnChecked[int, string](sw)(nil)
+//	t.Log(err) // "iox: invalid argument: nil mapper func from int to string"
+//
+//	w, err = NewWriterWithMapperFnChecked[int, int](iw)(nil)
+//	t.Log(w == iw, err) // true, nil
+func NewWriterWithMapperFnChecked[T, U any](w Writer[U]) func(f func(T) U) (Writer[T], error) {
+	return func(f func(T) U) (Writer[T], error) {
+		if w == nil {
+			return nil, fmt.Errorf("%w: nil writer", ErrInvalidArg)
+		}
+
+		if f == nil {
+			if wt, ok := any(w).(Writer[T]); ok {
+				return wt, nil
+			}
+
+			return nil, fmt.Errorf("%w: nil mapper func from %T to %T", ErrInvalidArg, *new(T), *new(U))
+		}
+
+		return NewWriterWithMapperFn[T, U](w)(f), nil
+	}
+}
@@ -4,8 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
+	"strconv"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // -----------------------------------------------------------------------------
@@ -37,6 +41,16 @@ func TestWriterImplWriteIdeal(t *testing.T) {
 	assertEq("val", 2, val, func(s string) { t.Fatal(s) })
 }
 
+func TestWriterImplWriteWithNilCtx(t *testing.T) {
+	w := WriterImpl[int]{
+		Impl: func(ctx context.Context, v int) error {
+			return ctx.Err()
+		},
+	}
+
+	assertEq("err", *new(error), w.Write(nil, 1), func(s string) { t.Fatal(s) })
+}
+
 func TestWriterImplWriteWithNilImpl(t *testing.T) {
 	err := *new(error)
 	val := 0
@@ -94,6 +108,101 @@ func TestWriteCloserImplCloseWithNilImpl(t *testing.T) {
 // Constructors.
 // -----------------------------------------------------------------------------
 
+func TestNewWriterFromDiscardIdeal(t *testing.T) {
+	w := NewWriterFromDiscard[int]()
+
+	assertEq("err", true, w.Write(nil, 1) == nil, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterDiscardCountingIdeal(t *testing.T) {
+	w, n := NewWriterDiscardCounting[int]()
+
+	for i := 0; i < 3; i++ {
+		assertEq("err", true, w.Write(nil, i) == nil, func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("n", int64(3), *n, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterFromErrIdeal(t *testing.T) {
+	w := NewWriterFromErr[int](io.ErrShortWrite)
+
+	for i := 0; i < 2; i++ {
+		assertEq("err", true, w.Write(nil, i) == io.ErrShortWrite, func(s string) { t.Fatal(s) })
+	}
+}
+
+func TestNewWriterFromErrWithNilErr(t *testing.T) {
+	w := NewWriterFromErr[int](nil)
+
+	assertEq("err", true, w.Write(nil, 1) == io.ErrClosedPipe, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterFromMultiBroadcastIdeal(t *testing.T) {
+	a := make([]int, 0, 2)
+	b := make([]int, 0, 2)
+	w := NewWriterFromMultiBroadcast(newSliceWriter(&a), nil, newSliceWriter(&b))
+
+	for i := 1; i <= 2; i++ {
+		assertEq("err", *new(error), w.Write(nil, i), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("a", []int{1, 2}, a, func(s string) { t.Fatal(s) })
+	assertEq("b", []int{1, 2}, b, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterFromMultiBroadcastWithErr(t *testing.T) {
+	b := make([]int, 0, 1)
+	w := NewWriterFromMultiBroadcast(NewWriterFromErr[int](io.ErrShortWrite), newSliceWriter(&b))
+
+	assertEq("err", true, w.Write(nil, 1) == io.ErrShortWrite, func(s string) { t.Fatal(s) })
+	assertEq("b", []int{}, b, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterFromMultiBroadcastCfgWithBestEffort(t *testing.T) {
+	b := make([]int, 0, 1)
+	w := NewWriterFromMultiBroadcastCfg(
+		NewWriterFromErr[int](io.ErrShortWrite),
+		newSliceWriter(&b),
+		NewWriterFromErr[int](io.ErrClosedPipe),
+	)(MultiBroadcastCfg{Policy: MultiBestEffort})
+
+	err := w.Write(nil, 1)
+	assertEq("short", true, errors.Is(err, io.ErrShortWrite), func(s string) { t.Fatal(s) })
+	assertEq("closed", true, errors.Is(err, io.ErrClosedPipe), func(s string) { t.Fatal(s) })
+	assertEq("b", []int{1}, b, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterFromMultiBroadcastWithNoWriters(t *testing.T) {
+	w := NewWriterFromMultiBroadcast[int](nil)
+	assertEq("err", true, w.Write(nil, 1) == io.ErrClosedPipe, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterToChanIdeal(t *testing.T) {
+	ch := make(chan int, 2)
+	w := NewWriterToChan(ch)
+
+	for i := 1; i <= 2; i++ {
+		assertEq("err", *new(error), w.Write(nil, i), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("val", 1, <-ch, func(s string) { t.Fatal(s) })
+	assertEq("val", 2, <-ch, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterToChanWithCancelledCtx(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	err := NewWriterToChan(make(chan int)).Write(ctx, 1)
+	assertEq("err", true, err == context.DeadlineExceeded, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterToChanWithNilChan(t *testing.T) {
+	err := NewWriterToChan[int](nil).Write(nil, 1)
+	assertEq("err", true, err == io.ErrClosedPipe, func(s string) { t.Fatal(s) })
+}
+
 func TestNewWriterFromValuesIdeal(t *testing.T) {
 	b := bytes.NewBuffer(nil)
 	f := func(w io.Writer) Encoder { return json.NewEncoder(w) }
@@ -213,6 +322,104 @@ func TestNewWriterFromBytesWithWriteErr(t *testing.T) {
 	assertEq("err", want, have, func(s string) { t.Fatal(s) })
 }
 
+func TestNewWriterFromBytesWithWriteString(t *testing.T) {
+	s := make([]int, 0, 2)
+	w := NewWriterFromBytes(newSliceWriter(&s))(nil)
+
+	io.WriteString(w, "2\n")
+	io.WriteString(w, "3\n")
+
+	assertEq("s", []int{2, 3}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterFromBytesWithReadFrom(t *testing.T) {
+	s := make([]int, 0, 3)
+	w := NewWriterFromBytes(newSliceWriter(&s))(nil)
+
+	// Hides bytes.Buffer.WriteTo, which io.Copy would prefer.
+	src := struct{ io.Reader }{bytes.NewBufferString("1\n2\n3\n")}
+	n, err := io.Copy(w, src)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("n", int64(6), n, func(s string) { t.Fatal(s) })
+	assertEq("s", []int{1, 2, 3}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterFromBytesWithWriteThenReadFrom(t *testing.T) {
+	s := make([]int, 0, 5)
+	w := NewWriterFromBytes(newSliceWriter(&s))(nil)
+
+	// The decoder buffers "2\n" after decoding 1, which must not be lost.
+	_, err := io.WriteString(w, "1\n2\n")
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+
+	// Hides bytes.Buffer.WriteTo, which io.Copy would prefer.
+	src := struct{ io.Reader }{bytes.NewBufferString("3\n4\n")}
+	_, err = io.Copy(w, src)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("s", []int{1, 2, 3, 4}, s, func(s string) { t.Fatal(s) })
+
+	// Writes still work after the ReadFrom hit io.EOF.
+	_, err = io.WriteString(w, "5\n")
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("s", []int{1, 2, 3, 4, 5}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterFromBytesBufferedIdeal(t *testing.T) {
+	s := make([]int, 0, 3)
+	w := NewWriterFromBytesBuffered(newSliceWriter(&s))(RecordCfg{}, nil)
+
+	b := bytes.NewBuffer(nil)
+	json.NewEncoder(b).Encode(12)
+	json.NewEncoder(b).Encode(34)
+	json.NewEncoder(b).Encode(56)
+
+	// One byte at a time, i.e partial frames.
+	for _, p := range b.Bytes() {
+		_, err := w.Write([]byte{p})
+		assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("s", []int{12, 34, 56}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterFromBytesBufferedWithUnterminatedTail(t *testing.T) {
+	s := make([]int, 0, 2)
+	w := NewWriterFromBytesBuffered(newSliceWriter(&s))(RecordCfg{}, nil)
+
+	w.Write([]byte("1\n2"))
+	assertEq("s", []int{1}, s, func(s string) { t.Fatal(s) })
+
+	assertEq("err", *new(error), w.Close(), func(s string) { t.Fatal(s) })
+	assertEq("s", []int{1, 2}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterFromBytesBufferedWithDecodeErr(t *testing.T) {
+	w := NewWriterFromBytesBuffered(NewWriterFromDiscard[int]())(RecordCfg{}, nil)
+
+	_, err := w.Write([]byte("[\n"))
+	assertEq("err", io.ErrUnexpectedEOF, err, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterFromBytesBufferedWithReadFrom(t *testing.T) {
+	s := make([]int, 0, 3)
+	w := NewWriterFromBytesBuffered(newSliceWriter(&s))(RecordCfg{}, nil)
+
+	io.WriteString(w, "1\n")
+	n, err := io.Copy(w, struct{ io.Reader }{bytes.NewBufferString("2\n3")})
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	assertEq("n", int64(3), n, func(s string) { t.Fatal(s) })
+
+	w.Close()
+	assertEq("s", []int{1, 2, 3}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterFromBytesBufferedWithNilWriter(t *testing.T) {
+	w := NewWriterFromBytesBuffered[int](nil)(RecordCfg{}, nil)
+
+	_, err := w.Write([]byte("1\n"))
+	assertEq("err", io.ErrClosedPipe, err, func(s string) { t.Fatal(s) })
+}
+
 // -----------------------------------------------------------------------------
 // Modifiers.
 // -----------------------------------------------------------------------------
@@ -240,6 +447,144 @@ func TestNewWriterWithBatchingWithNilWriter(t *testing.T) {
 	assertEq("err", io.ErrClosedPipe, err, func(s string) { t.Fatal(s) })
 }
 
+func TestNewWriterWithAdaptiveBatchingIdeal(t *testing.T) {
+	s := make([][]int, 0)
+	w := NewWriterWithAdaptiveBatching(newSliceWriter(&s))(
+		AdaptiveBatchingCfg{MinSize: 1, MaxSize: 4, TargetLatency: time.Hour},
+	)
+
+	for i := 0; i < 7; i++ {
+		assertEq("err", *new(error), w.Write(nil, i), func(s string) { t.Fatal(s) })
+	}
+
+	// Fast sink, so the size doubles for each batch, capped at MaxSize.
+	assertEq("val", [][]int{{0}, {1, 2}, {3, 4, 5, 6}}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithAdaptiveBatchingWithSlowWriter(t *testing.T) {
+	s := make([][]int, 0)
+	sw := newSliceWriter(&s)
+	w := NewWriterWithAdaptiveBatching(
+		WriterImpl[[]int]{
+			Impl: func(ctx context.Context, v []int) error {
+				time.Sleep(time.Millisecond * 2)
+				return sw.Write(ctx, v)
+			},
+		},
+	)(
+		AdaptiveBatchingCfg{MinSize: 2, MaxSize: 8, TargetLatency: time.Millisecond},
+	)
+
+	for i := 0; i < 5; i++ {
+		assertEq("err", *new(error), w.Write(nil, i), func(s string) { t.Fatal(s) })
+	}
+
+	// Slow sink, so the size stays at MinSize.
+	assertEq("val", [][]int{{0, 1}, {2, 3}}, s, func(s string) { t.Fatal(s) })
+
+	assertEq("err", *new(error), w.Close(), func(s string) { t.Fatal(s) })
+	assertEq("val", [][]int{{0, 1}, {2, 3}, {4}}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithAdaptiveBatchingWithNilWriter(t *testing.T) {
+	w := NewWriterWithAdaptiveBatching[int](nil)(AdaptiveBatchingCfg{})
+
+	assertEq("err", true, w.Write(nil, 1) == io.ErrClosedPipe, func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), w.Close(), func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithCoalescingIdeal(t *testing.T) {
+	type counter struct {
+		Name string
+		N    int
+	}
+
+	s := make([][]counter, 0, 2)
+	w := NewWriterWithCoalescing[counter, string](newSliceWriter(&s))(
+		func(v counter) string { return v.Name },
+		func(old, new counter) counter { return counter{old.Name, old.N + new.N} },
+		2,
+	)
+
+	for _, v := range []counter{{"a", 1}, {"a", 2}, {"b", 1}, {"c", 1}, {"c", 1}} {
+		assertEq("err", *new(error), w.Write(nil, v), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("err", *new(error), w.Close(), func(s string) { t.Fatal(s) })
+
+	want := [][]counter{{{"a", 3}, {"b", 1}}, {{"c", 2}}}
+	assertEq("vals", want, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithCoalescingWithNilMerge(t *testing.T) {
+	s := make([][]string, 0, 1)
+	w := NewWriterWithCoalescing[string, byte](newSliceWriter(&s))(
+		func(v string) byte { return v[0] },
+		nil,
+		8,
+	)
+
+	for _, v := range []string{"a1", "b1", "a2"} {
+		assertEq("err", *new(error), w.Write(nil, v), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("err", *new(error), w.Close(), func(s string) { t.Fatal(s) })
+	assertEq("vals", [][]string{{"a2", "b1"}}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithRunLengthIdeal(t *testing.T) {
+	s := make([]Counted[string], 0, 3)
+	w := NewWriterWithRunLength(newSliceWriter(&s))
+
+	for _, v := range []string{"a", "a", "b", "a", "a", "a"} {
+		assertEq("err", *new(error), w.Write(nil, v), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("val", []Counted[string]{{"a", 2}, {"b", 1}}, s, func(s string) { t.Fatal(s) })
+
+	assertEq("err", *new(error), w.Close(), func(s string) { t.Fatal(s) })
+	assertEq("val", []Counted[string]{{"a", 2}, {"b", 1}, {"a", 3}}, s, func(s string) { t.Fatal(s) })
+
+	// Nothing left to flush.
+	assertEq("err", *new(error), w.Close(), func(s string) { t.Fatal(s) })
+	assertEq("len", 3, len(s), func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithRunLengthWithWriteErr(t *testing.T) {
+	s := make([]Counted[string], 0, 2)
+	fail := true
+	w := NewWriterWithRunLength[string](WriterImpl[Counted[string]]{
+		Impl: func(ctx context.Context, v Counted[string]) error {
+			if fail {
+				return io.ErrShortWrite
+			}
+
+			s = append(s, v)
+			return nil
+		},
+	})
+
+	assertEq("err", *new(error), w.Write(nil, "a"), func(s string) { t.Fatal(s) })
+	assertEq("err", true, w.Write(nil, "b") == io.ErrShortWrite, func(s string) { t.Fatal(s) })
+
+	// The run of "a" is kept, so the retry writes it.
+	fail = false
+	assertEq("err", *new(error), w.Write(nil, "b"), func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), w.Close(), func(s string) { t.Fatal(s) })
+	assertEq("val", []Counted[string]{{"a", 1}, {"b", 1}}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithBatchingWithCancelledCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s := make([][]int, 0)
+	w := NewWriterWithBatching(newSliceWriter(&s), 1)
+
+	assertEq("err", true, w.Write(ctx, 1) == context.Canceled, func(s string) { t.Fatal(s) })
+	assertEq("vals", [][]int{}, s, func(s string) { t.Fatal(s) })
+}
+
 func TestNewWriterWithUnbatchingIdeal(t *testing.T) {
 	s := make([]int, 0, 4)
 	w := NewWriterWithUnbatching(newSliceWriter(&s))
@@ -290,6 +635,100 @@ func TestNewWriterWithFilterFnWithNilFilter(t *testing.T) {
 	assertEq("val", []int{1, 2, 3}, s, func(s string) { t.Fatal(s) })
 }
 
+func TestNewWriterWithIfFnIdeal(t *testing.T) {
+	even, odd := make([]int, 0, 2), make([]int, 0, 2)
+	w := NewWriterWithIfFn(
+		func(v int) bool { return v%2 == 0 },
+		newSliceWriter(&even),
+		newSliceWriter(&odd),
+	)
+
+	for i := 1; i <= 4; i++ {
+		assertEq("err", *new(error), w.Write(nil, i), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("even", []int{2, 4}, even, func(s string) { t.Fatal(s) })
+	assertEq("odd", []int{1, 3}, odd, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithIfFnWithNilBranch(t *testing.T) {
+	s := make([]int, 0, 2)
+	w := NewWriterWithIfFn(func(v int) bool { return v%2 == 0 }, nil, newSliceWriter(&s))
+
+	assertEq("err", *new(error), w.Write(nil, 1), func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), w.Write(nil, 2), func(s string) { t.Fatal(s) })
+
+	assertEq("val", []int{1}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithIfFnWithNilPred(t *testing.T) {
+	s := make([]int, 0, 2)
+	w := NewWriterWithIfFn(nil, newSliceWriter(&s), nil)
+
+	assertEq("err", *new(error), w.Write(nil, 1), func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), w.Write(nil, 2), func(s string) { t.Fatal(s) })
+
+	assertEq("val", []int{1, 2}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithSampleNthIdeal(t *testing.T) {
+	s := make([]int, 0, 2)
+	w := NewWriterWithSampleNth(newSliceWriter(&s), 2)
+
+	for _, v := range []int{1, 2, 3, 4} {
+		assertEq("err", *new(error), w.Write(nil, v), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("vals", []int{1, 3}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithSampleProbIdeal(t *testing.T) {
+	s := make([]int, 0, 100)
+	w := NewWriterWithSampleProb(newSliceWriter(&s), 0.5, 7)
+
+	for i := 0; i < 1000; i++ {
+		assertEq("err", *new(error), w.Write(nil, i), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("approx", true, len(s) > 400 && len(s) < 600, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithDedupFnIdeal(t *testing.T) {
+	s := make([]string, 0, 3)
+	failing := true
+	w := NewWriterWithDedupFn[string, byte](WriterImpl[string]{
+		Impl: func(ctx context.Context, v string) error {
+			if v == "b1" && failing {
+				failing = false
+				return io.ErrShortWrite
+			}
+
+			s = append(s, v)
+			return nil
+		},
+	})(func(v string) byte { return v[0] })
+
+	for _, v := range []string{"a1", "b1", "a2", "b1", "b2"} {
+		_ = w.Write(nil, v)
+	}
+
+	assertEq("vals", []string{"a1", "b1"}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithDedupWindowFnIdeal(t *testing.T) {
+	s := make([]string, 0, 3)
+	w := NewWriterWithDedupWindowFn[string, byte](newSliceWriter(&s))(
+		func(v string) byte { return v[0] },
+		DedupCfg{MaxEntries: 1},
+	)
+
+	for _, v := range []string{"a1", "a2", "b1", "a3"} {
+		assertEq("err", *new(error), w.Write(nil, v), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("vals", []string{"a1", "b1", "a3"}, s, func(s string) { t.Fatal(s) })
+}
+
 func TestNewWriterWithMapperFnIdeal(t *testing.T) {
 	s := make([]int, 0, 3)
 	w := newSliceWriter(&s)
@@ -315,3 +754,410 @@ func TestNewWriterWithMapperFnWithNilMapper(t *testing.T) {
 
 	assertEq("err", io.ErrClosedPipe, w.Write(nil, 1), func(s string) { t.Fatal(s) })
 }
+
+func TestNewWriterWithFilterFnCtxIdeal(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := make([]int, 0, 2)
+	w := NewWriterWithFilterFnCtx(newSliceWriter(&s))(
+		func(ctx context.Context, v int) bool {
+			return ctx.Err() == nil
+		},
+	)
+
+	assertEq("err", *new(error), w.Write(ctx, 1), func(s string) { t.Fatal(s) })
+	cancel()
+	assertEq("err", *new(error), w.Write(ctx, 2), func(s string) { t.Fatal(s) })
+
+	assertEq("val", []int{1}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithMapperFnCtxIdeal(t *testing.T) {
+	ctx := WithClock(context.Background(), testClock{now: time.Unix(10, 0)})
+	s := make([]int64, 0, 1)
+	w := NewWriterWithMapperFnCtx[int](newSliceWriter(&s))(
+		func(ctx context.Context, v int) int64 {
+			return ClockFrom(ctx).Now().Unix() + int64(v)
+		},
+	)
+
+	assertEq("err", *new(error), w.Write(ctx, 1), func(s string) { t.Fatal(s) })
+	assertEq("val", []int64{11}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithMapperFnEIdeal(t *testing.T) {
+	s := make([]int, 0, 2)
+	w := NewWriterWithMapperFnE[string](newSliceWriter(&s))(
+		func(ctx context.Context, v string) (int, error) {
+			return strconv.Atoi(v)
+		},
+	)
+
+	assertEq("err", *new(error), w.Write(nil, "1"), func(s string) { t.Fatal(s) })
+	err := w.Write(nil, "x")
+	assertEq("err", true, errors.Is(err, strconv.ErrSyntax), func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), w.Write(nil, "3"), func(s string) { t.Fatal(s) })
+
+	assertEq("val", []int{1, 3}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithMapperFnEWithNilMapper(t *testing.T) {
+	s := make([]int, 0, 1)
+	w := NewWriterWithMapperFnE[int](newSliceWriter(&s))(nil)
+
+	assertEq("err", io.ErrClosedPipe, w.Write(nil, 1), func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithTimestampsIdeal(t *testing.T) {
+	now := time.Unix(10, 0)
+	ctx := WithClock(context.Background(), testClock{now: now})
+
+	s := make([]Timestamped[int], 0, 1)
+	w := NewWriterWithTimestamps(newSliceWriter(&s))
+	w.Write(ctx, 1)
+
+	assertEq("s", []Timestamped[int]{{Value: 1, Time: now}}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithSequenceIdeal(t *testing.T) {
+	s := make([]Sequenced[string], 0, 2)
+	failing := true
+	w := NewWriterWithSequence(WriterImpl[Sequenced[string]]{
+		Impl: func(ctx context.Context, v Sequenced[string]) error {
+			if failing {
+				failing = false
+				return io.ErrShortWrite
+			}
+
+			s = append(s, v)
+			return nil
+		},
+	})
+
+	assertEq("err", true, w.Write(nil, "a") == io.ErrShortWrite, func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), w.Write(nil, "a"), func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), w.Write(nil, "b"), func(s string) { t.Fatal(s) })
+
+	want := []Sequenced[string]{{Seq: 0, Value: "a"}, {Seq: 1, Value: "b"}}
+	assertEq("val", want, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithSequenceRoundtrip(t *testing.T) {
+	s := make([]Sequenced[int], 0, 3)
+	w := NewWriterWithSequence(newSliceWriter(&s))
+	for _, v := range []int{1, 2, 3} {
+		assertEq("err", *new(error), w.Write(nil, v), func(s string) { t.Fatal(s) })
+	}
+
+	r := NewReaderWithSequenceCheck(NewReaderFrom(s[0], s[2]))
+
+	_, err := r.Read(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+	_, err = r.Read(nil)
+	assertEq("err", true, errors.Is(err, ErrSequenceGap), func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithExpiryIdeal(t *testing.T) {
+	now := time.Unix(10, 0)
+	ctx := WithClock(context.Background(), testClock{now: now})
+
+	s := make([]int, 0, 3)
+	w, dropped := NewWriterWithExpiry(newSliceWriter(&s))(
+		func(v int) time.Time {
+			if v == 0 {
+				return time.Time{}
+			}
+
+			return time.Unix(int64(v), 0)
+		},
+	)
+
+	for _, v := range []int{0, 5, 10, 11} {
+		assertEq("err", *new(error), w.Write(ctx, v), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("val", []int{0, 11}, s, func(s string) { t.Fatal(s) })
+	assertEq("dropped", int64(2), atomic.LoadInt64(dropped), func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithExpiryWithNilWriter(t *testing.T) {
+	w, _ := NewWriterWithExpiry[int](nil)(func(v int) time.Time { return time.Time{} })
+
+	assertEq("err", io.ErrClosedPipe, w.Write(nil, 1), func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithTeeIdeal(t *testing.T) {
+	s1 := make([]int, 0, 2)
+	s2 := make([]int, 0, 2)
+	w := NewWriterWithTee(newSliceWriter(&s1), newSliceWriter(&s2))
+
+	assertEq("err", *new(error), w.Write(nil, 1), func(s string) { t.Fatal(s) })
+	assertEq("err", *new(error), w.Write(nil, 2), func(s string) { t.Fatal(s) })
+
+	assertEq("primary", []int{1, 2}, s1, func(s string) { t.Fatal(s) })
+	assertEq("audit", []int{1, 2}, s2, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithTeeWithNilWriter(t *testing.T) {
+	s := make([]int, 0, 1)
+	w := NewWriterWithTee(nil, newSliceWriter(&s))
+
+	assertEq("err", io.ErrClosedPipe, w.Write(nil, 1), func(s string) { t.Fatal(s) })
+	assertEq("audit", []int{}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithTeeWithNilAudit(t *testing.T) {
+	s := make([]int, 0, 1)
+	w := NewWriterWithTee(newSliceWriter(&s), nil)
+
+	assertEq("err", *new(error), w.Write(nil, 1), func(s string) { t.Fatal(s) })
+	assertEq("primary", []int{1}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithTeeWithAuditErr(t *testing.T) {
+	s := make([]int, 0, 1)
+	w := NewWriterWithTee(newSliceWriter(&s), WriterImpl[int]{})
+
+	err := w.Write(nil, 1)
+	assertEq("err", true, err == io.ErrClosedPipe, func(s string) { t.Fatal(s) })
+	assertEq("primary", []int{}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithTeeCfgWithIgnoredAuditErr(t *testing.T) {
+	s := make([]int, 0, 1)
+	w := NewWriterWithTeeCfg(newSliceWriter(&s), WriterImpl[int]{})(
+		TeeCfg{OnAuditErr: func(err error) error { return nil }},
+	)
+
+	assertEq("err", *new(error), w.Write(nil, 1), func(s string) { t.Fatal(s) })
+	assertEq("primary", []int{1}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithTeeCfgWithConcurrent(t *testing.T) {
+	s := make([]int, 0, 1)
+	w := NewWriterWithTeeCfg(newSliceWriter(&s), WriterImpl[int]{})(
+		TeeCfg{Concurrent: true},
+	)
+
+	err := w.Write(nil, 1)
+	assertEq("err", true, errors.Is(err, io.ErrClosedPipe), func(s string) { t.Fatal(s) })
+	assertEq("primary", []int{1}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithOnWriteFnIdeal(t *testing.T) {
+	var seen []int
+	failing := WriterImpl[int]{
+		Impl: func(ctx context.Context, v int) error {
+			if v < 0 {
+				return io.ErrShortWrite
+			}
+
+			return nil
+		},
+	}
+
+	w := NewWriterWithOnWriteFn[int](failing)(
+		func(v int, err error) {
+			if err == nil {
+				seen = append(seen, v)
+			}
+		},
+	)
+
+	assertEq("err", *new(error), w.Write(nil, 1), func(s string) { t.Fatal(s) })
+	assertEq("err", true, w.Write(nil, -1) == io.ErrShortWrite, func(s string) { t.Fatal(s) })
+	assertEq("seen", []int{1}, seen, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithStopSignalIdeal(t *testing.T) {
+	s := make([]int, 0, 1)
+	stop := make(chan struct{})
+	w := NewWriterWithStopSignal(newSliceWriter(&s), stop)
+
+	assertEq("err", *new(error), w.Write(nil, 1), func(s string) { t.Fatal(s) })
+	close(stop)
+	assertEq("err", true, w.Write(nil, 2) == io.ErrClosedPipe, func(s string) { t.Fatal(s) })
+	assertEq("vals", []int{1}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithRoundRobinIdeal(t *testing.T) {
+	a := make([]int, 0, 2)
+	b := make([]int, 0, 2)
+	w := NewWriterWithRoundRobin(newSliceWriter(&a), nil, newSliceWriter(&b))
+
+	for i := 1; i <= 4; i++ {
+		assertEq("err", *new(error), w.Write(nil, i), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("a", []int{1, 3}, a, func(s string) { t.Fatal(s) })
+	assertEq("b", []int{2, 4}, b, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithRoundRobinWithErr(t *testing.T) {
+	b := make([]int, 0, 2)
+	w := NewWriterWithRoundRobin(NewWriterFromErr[int](io.ErrShortWrite), newSliceWriter(&b))
+
+	// Failing writers are not skipped by default.
+	for i := 1; i <= 4; i++ {
+		err := w.Write(nil, i)
+		assertEq("err", i%2 == 1, err == io.ErrShortWrite, func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("b", []int{2, 4}, b, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithRoundRobinCfgWithCooldown(t *testing.T) {
+	clock := &testClock{now: time.Unix(100, 0)}
+	ctx := WithClock(context.Background(), clock)
+
+	failing := true
+	a := make([]int, 0, 3)
+	flaky := WriterImpl[int]{
+		Impl: func(ctx context.Context, v int) error {
+			if failing {
+				return io.ErrShortWrite
+			}
+
+			a = append(a, v)
+			return nil
+		},
+	}
+
+	b := make([]int, 0, 3)
+	w := NewWriterWithRoundRobinCfg[int](flaky, newSliceWriter(&b))(RoundRobinCfg{Cooldown: time.Second})
+
+	err := w.Write(ctx, 1)
+	assertEq("err", true, err == io.ErrShortWrite, func(s string) { t.Fatal(s) })
+
+	// 'flaky' is cooling down.
+	for i := 2; i <= 3; i++ {
+		assertEq("err", *new(error), w.Write(ctx, i), func(s string) { t.Fatal(s) })
+	}
+
+	failing = false
+	clock.now = clock.now.Add(time.Second)
+	for i := 4; i <= 5; i++ {
+		assertEq("err", *new(error), w.Write(ctx, i), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("a", []int{4}, a, func(s string) { t.Fatal(s) })
+	assertEq("b", []int{2, 3, 5}, b, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithRoundRobinCfgWithSlowFailure(t *testing.T) {
+	clock := &testClock{now: time.Unix(100, 0)}
+	ctx := WithClock(context.Background(), clock)
+
+	slow := WriterImpl[int]{
+		Impl: func(ctx context.Context, v int) error {
+			clock.now = clock.now.Add(time.Second * 2)
+			return io.ErrShortWrite
+		},
+	}
+
+	b := make([]int, 0, 2)
+	w := NewWriterWithRoundRobinCfg[int](slow, newSliceWriter(&b))(RoundRobinCfg{Cooldown: time.Second})
+
+	err := w.Write(ctx, 1)
+	assertEq("err", true, err == io.ErrShortWrite, func(s string) { t.Fatal(s) })
+
+	// The cooldown started when the write failed, so 'slow' is still skipped.
+	for i := 2; i <= 3; i++ {
+		assertEq("err", *new(error), w.Write(ctx, i), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("b", []int{2, 3}, b, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithRoundRobinCfgWithCtxErr(t *testing.T) {
+	clock := &testClock{now: time.Unix(100, 0)}
+	ctx := WithClock(context.Background(), clock)
+
+	failing := true
+	a := make([]int, 0, 1)
+	flaky := WriterImpl[int]{
+		Impl: func(ctx context.Context, v int) error {
+			if failing {
+				return context.DeadlineExceeded
+			}
+
+			a = append(a, v)
+			return nil
+		},
+	}
+
+	b := make([]int, 0, 1)
+	w := NewWriterWithRoundRobinCfg[int](flaky, newSliceWriter(&b))(RoundRobinCfg{Cooldown: time.Second})
+
+	err := w.Write(ctx, 1)
+	assertEq("err", true, err == context.DeadlineExceeded, func(s string) { t.Fatal(s) })
+
+	// 'flaky' is not cooling down.
+	failing = false
+	for i := 2; i <= 3; i++ {
+		assertEq("err", *new(error), w.Write(ctx, i), func(s string) { t.Fatal(s) })
+	}
+
+	assertEq("a", []int{3}, a, func(s string) { t.Fatal(s) })
+	assertEq("b", []int{2}, b, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithRoundRobinWithNoWriters(t *testing.T) {
+	w := NewWriterWithRoundRobin[int]()
+	assertEq("err", true, w.Write(nil, 1) == io.ErrClosedPipe, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithMapperFnCheckedIdeal(t *testing.T) {
+	s := make([]int, 0, 1)
+	w, err := NewWriterWithMapperFnChecked[int](newSliceWriter(&s))(func(v int) int { return v + 1 })
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+
+	assertEq("err", *new(error), w.Write(nil, 1), func(s string) { t.Fatal(s) })
+	assertEq("val", []int{2}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithMapperFnCheckedWithNilWriter(t *testing.T) {
+	w, err := NewWriterWithMapperFnChecked[int, int](nil)(func(v int) int { return v })
+
+	assertEq("err", true, errors.Is(err, ErrInvalidArg), func(s string) { t.Fatal(s) })
+	assertEq("w", true, w == nil, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithMapperFnCheckedWithNilMapperSameType(t *testing.T) {
+	s := make([]int, 0, 1)
+	w, err := NewWriterWithMapperFnChecked[int](newSliceWriter(&s))(nil)
+	assertEq("err", *new(error), err, func(s string) { t.Fatal(s) })
+
+	assertEq("err", *new(error), w.Write(nil, 1), func(s string) { t.Fatal(s) })
+	assertEq("val", []int{1}, s, func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithMapperFnCheckedWithNilMapperDifferentTypes(t *testing.T) {
+	s := make([]string, 0, 1)
+	_, err := NewWriterWithMapperFnChecked[int](newSliceWriter(&s))(nil)
+
+	assertEq("err", true, errors.Is(err, ErrInvalidArg), func(s string) { t.Fatal(s) })
+	assertEq("msg", "iox: invalid argument: nil mapper func from int to string", err.Error(), func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithBatchingCheckedWithInvalidArgs(t *testing.T) {
+	_, err := NewWriterWithBatchingChecked[int](nil, 2)
+	assertEq("err", true, errors.Is(err, ErrInvalidArg), func(s string) { t.Fatal(s) })
+
+	s := make([][]int, 0)
+	_, err = NewWriterWithBatchingChecked(newSliceWriter(&s), 0)
+	assertEq("err", true, errors.Is(err, ErrInvalidArg), func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithUnbatchingCheckedWithNilWriter(t *testing.T) {
+	_, err := NewWriterWithUnbatchingChecked[int](nil)
+	assertEq("err", true, errors.Is(err, ErrInvalidArg), func(s string) { t.Fatal(s) })
+}
+
+func TestNewWriterWithFilterFnCheckedWithInvalidArgs(t *testing.T) {
+	_, err := NewWriterWithFilterFnChecked[int](nil)(func(int) bool { return true })
+	assertEq("err", true, errors.Is(err, ErrInvalidArg), func(s string) { t.Fatal(s) })
+
+	s := make([]int, 0)
+	_, err = NewWriterWithFilterFnChecked(newSliceWriter(&s))(nil)
+	assertEq("err", true, errors.Is(err, ErrInvalidArg), func(s string) { t.Fatal(s) })
+}